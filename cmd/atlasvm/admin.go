@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// adminFlags are the flags of the subcommands that talk to a node's
+// NodeAdmin service.
+type adminFlags struct {
+	ca      *string
+	cert    *string
+	key     *string
+	timeout *time.Duration
+}
+
+// addAdminFlags registers --ca, --cert, --key and --timeout on fs.
+func addAdminFlags(fs *flag.FlagSet, timeout time.Duration) *adminFlags {
+	return &adminFlags{
+		ca:      fs.String("ca", "", "CA certificate of a cluster that uses TLS"),
+		cert:    fs.String("cert", "", "operator certificate from the cluster CA, for changes"),
+		key:     fs.String("key", "", "key of the client certificate"),
+		timeout: fs.Duration("timeout", timeout, "how long to wait for the node"),
+	}
+}
+
+// valid reports whether the parsed flags go together.
+func (f *adminFlags) valid() bool {
+	return (*f.cert == "") == (*f.key == "")
+}
+
+// dial connects to the node at addr, over TLS if --ca is set.
+func (f *adminFlags) dial(addr string) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if *f.ca != "" {
+		roots, err := network.LoadCertPool(*f.ca)
+		if err != nil {
+			return nil, err
+		}
+		cfg := &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS13}
+		if *f.cert != "" {
+			cert, err := network.LoadClientCertificate(*f.cert, *f.key)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Certificates = []tls.Certificate{cert}
+		}
+		creds = credentials.NewTLS(cfg)
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	return conn, nil
+}
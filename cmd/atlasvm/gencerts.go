@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+)
+
+const gencertsHelpText = `Give an AtlasVM cluster throwaway TLS certificates.
+
+Usage:
+  atlasvm gencerts --config cluster.json
+
+Creates a CA, issues every node in the config a certificate for its ID and
+public key, writes them next to the config as ca.crt and <id>.crt, and
+adds them to the config. It also writes operator.crt and operator.key, a
+certificate and key for the operator, who alone may change a node over
+NodeAdmin, and lists the operator in the config. The CA key is not kept,
+so run it again to add a node. Meant for local clusters; use a real CA
+elsewhere.
+
+Flags:
+`
+
+// runGencerts implements the "gencerts" subcommand and returns the exit
+// code.
+func runGencerts(args []string) int {
+	fs := flag.NewFlagSet("gencerts", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, gencertsHelpText)
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", "cluster.json", "cluster config file")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := network.LoadClusterConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gencerts: %v\n", err)
+		return 1
+	}
+	if err := network.GenerateTLS(cfg, filepath.Dir(*configPath)); err != nil {
+		fmt.Fprintf(os.Stderr, "gencerts: %v\n", err)
+		return 1
+	}
+	if err := network.SaveClusterConfig(*configPath, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "gencerts: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote certificates for %d nodes to %s\n", len(cfg.Nodes), filepath.Dir(*configPath))
+	return 0
+}
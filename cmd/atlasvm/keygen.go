@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+)
+
+const keygenHelpText = `Create a node key for an AtlasVM cluster.
+
+Usage:
+  atlasvm keygen <file>
+
+Writes a fresh ed25519 private key to file and prints the public key to
+list for the node in the cluster config.
+`
+
+// runKeygen implements the "keygen" subcommand and returns the exit code.
+func runKeygen(args []string) int {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, keygenHelpText) }
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	path := fs.Arg(0)
+	if _, err := os.Stat(path); err == nil {
+		fmt.Fprintf(os.Stderr, "keygen: %s already exists\n", path)
+		return 1
+	}
+	pub, priv := network.GenerateKey()
+	if err := network.WritePrivateKey(path, priv); err != nil {
+		fmt.Fprintf(os.Stderr, "keygen: %v\n", err)
+		return 1
+	}
+	fmt.Println(network.EncodePublicKey(pub))
+	return 0
+}
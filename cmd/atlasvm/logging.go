@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// logFlags are the flags that configure the logger of a subcommand.
+type logFlags struct {
+	level  *string
+	format *string
+
+	// levelVar holds the level of the logger, which can change while it
+	// is in use.
+	levelVar slog.LevelVar
+}
+
+// addLogFlags registers --log-level and --log-format on fs.
+func addLogFlags(fs *flag.FlagSet) *logFlags {
+	return &logFlags{
+		level:  fs.String("log-level", "info", "least severe log records to print: debug, info, warn or error"),
+		format: fs.String("log-format", "text", "log record format: text or json"),
+	}
+}
+
+// logger returns the logger the parsed flags ask for, writing to stderr.
+func (f *logFlags) logger() (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*f.level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q", *f.level)
+	}
+	f.levelVar.Set(level)
+	opts := &slog.HandlerOptions{Level: &f.levelVar}
+	switch *f.format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q, expected text or json", *f.format)
+}
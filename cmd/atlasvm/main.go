@@ -2,15 +2,15 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"time"
 
-	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/compiler"
-	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/lexer"
-	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/parser"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl"
 	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
 	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
 	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
@@ -21,145 +21,196 @@ const helpText = `AtlasVM — a distributed virtual machine for AtlasPL programs
 Usage:
   atlasvm [flags] <program.atlas>
   atlasvm [flags]              (reads from stdin)
+  atlasvm test [flags] [dir ...]
+  atlasvm node --config cluster.json --id <id>
+  atlasvm keygen <file>
+  atlasvm gencerts --config cluster.json
+  atlasvm submit [flags] <program.atlas>
+  atlasvm status [flags] <addr>
+  atlasvm member add|remove [flags] <id> <addr>
+  atlasvm sim [flags]
 
 Example:
   atlasvm examples/even_odd.atlas
   atlasvm --local examples/sum.atlas
+  atlasvm test examples
+  atlasvm node --config cluster.json --id node1
+  atlasvm submit --addr localhost:50051 examples/sum.atlas
+  atlasvm status localhost:50051
+  atlasvm member add --config cluster.json node5 localhost:50051
+  atlasvm sim --nodes 16 --latency lognormal:20ms,0.5
 
 Flags:
 `
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "test":
+			os.Exit(runTests(os.Args[2:]))
+		case "node":
+			os.Exit(runNode(os.Args[2:]))
+		case "keygen":
+			os.Exit(runKeygen(os.Args[2:]))
+		case "gencerts":
+			os.Exit(runGencerts(os.Args[2:]))
+		case "submit":
+			os.Exit(runSubmit(os.Args[2:]))
+		case "status":
+			os.Exit(runStatus(os.Args[2:]))
+		case "member":
+			os.Exit(runMember(os.Args[2:]))
+		case "sim":
+			os.Exit(runSim(os.Args[2:]))
+		}
+	}
+
 	flag.Usage = func() {
 		fmt.Fprint(os.Stderr, helpText)
 		flag.PrintDefaults()
 	}
 	localOnly := flag.Bool("local", false, "skip distributed consensus and just print the VM output")
+	inputFile := flag.String("input", "", "file holding the input tape for IN (distributed runs only; --local reads stdin)")
+	logging := addLogFlags(flag.CommandLine)
 	flag.Parse()
 
+	logger, err := logging.logger()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "atlasvm: %v\n", err)
+		os.Exit(2)
+	}
+	fatal := func(msg string, args ...any) {
+		logger.Error(msg, args...)
+		os.Exit(1)
+	}
+
 	// ─── 1. Read source from file or stdin ────────────────────────────────────
 	var src []byte
-	var err error
 
 	switch flag.NArg() {
 	case 0:
-		log.Println("No file given — reading from stdin (Ctrl-D when done)...")
+		logger.Info("no file given, reading from stdin (Ctrl-D when done)")
 		src, err = os.ReadFile("/dev/stdin")
 	case 1:
 		src, err = os.ReadFile(flag.Arg(0))
 		if err == nil {
-			log.Printf("Running %s", flag.Arg(0))
+			logger.Info("running program", "file", flag.Arg(0))
 		}
 	default:
 		flag.Usage()
 		os.Exit(1)
 	}
 	if err != nil {
-		log.Fatalf("Could not read source: %v", err)
+		fatal("could not read source", "err", err)
 	}
 
-	// ─── 2. Lex + Parse ───────────────────────────────────────────────────────
-	l := lexer.NewLexer(bytes.NewReader(src))
-	p := parser.NewParser(l)
-	program := p.ParseProgram()
-
-	if errs := p.Errors(); len(errs) != 0 {
+	// ─── 2. Lex + Parse + Compile AST → bytecode ──────────────────────────────
+	compiled, err := atlaspl.Compile(bytes.NewReader(src))
+	var parseErr *atlaspl.ParseError
+	if errors.As(err, &parseErr) {
 		fmt.Fprintln(os.Stderr, "Parse errors:")
-		for _, e := range errs {
+		for _, e := range parseErr.Errors {
 			fmt.Fprintln(os.Stderr, "  "+e)
 		}
 		os.Exit(1)
 	}
-
-	// ─── 3. Compile AST → bytecode ────────────────────────────────────────────
-	c := compiler.NewCompiler()
-	compiled, err := c.Compile(program)
 	if err != nil {
-		log.Fatalf("Compilation failed: %v", err)
-	}
-	log.Printf("Compiled %d instruction bytes", len(compiled.Bytecode))
-	for i, b := range compiled.Bytecode {
-		log.Printf("  [%02d] 0x%02X", i, b)
+		fatal("compilation failed", "err", err)
 	}
-
-	// ─── 4. Load + run on VM 1 ────────────────────────────────────────────────
-	vm1 := vm.NewVM(os.Stdin, os.Stdout)
-	if err := vm1.LoadProgram(compiled.Bytecode); err != nil {
-		log.Fatalf("LoadProgram: %v", err)
-	}
-	vm1.LoadData(compiled.InitialData)
-
-	log.Println("Running VM...")
-	vm1.Run()
-	log.Printf("VM finished: PC=%d ACC=%d", vm1.Registers.PC, vm1.Registers.ACC)
+	logger.Debug("compiled program", "bytes", len(compiled.Bytecode))
 
 	if *localOnly {
+		// ─── 3. Load + run on a single VM ────────────────────────────────────
+		vm1 := vm.NewVM(os.Stdin, os.Stdout)
+		vm1.SetLogger(logger)
+		if err := vm1.LoadProgram(compiled.Bytecode); err != nil {
+			fatal("loading program failed", "err", err)
+		}
+		vm1.LoadData(compiled.InitialData)
+
+		if err := vm1.Run(); err != nil {
+			fatal("execution failed", "err", err)
+		}
 		return
 	}
 
-	// ─── 5. Distribute across 3 nodes with PBFT consensus ────────────────────
-	vm2 := vm.NewVM(os.Stdin, os.Stdout)
-	vm3 := vm.NewVM(os.Stdin, os.Stdout)
+	var input []byte
+	if *inputFile != "" {
+		if input, err = os.ReadFile(*inputFile); err != nil {
+			fatal("could not read input", "err", err)
+		}
+	}
 
-	node1 := network.NewNode("node1", "localhost:50051", vm1)
-	node2 := network.NewNode("node2", "localhost:50052", vm2)
-	node3 := network.NewNode("node3", "localhost:50053", vm3)
+	// ─── 3. Order the program on 3 nodes with PBFT; each one runs it ─────────
+	node1 := network.NewNode("node1", "localhost:50051", vm.NewVM(nil, io.Discard), logger)
+	node2 := network.NewNode("node2", "localhost:50052", vm.NewVM(nil, io.Discard), logger)
+	node3 := network.NewNode("node3", "localhost:50053", vm.NewVM(nil, io.Discard), logger)
 
 	go func() { _ = node1.Start() }()
 	go func() { _ = node2.Start() }()
 	go func() { _ = node3.Start() }()
-	time.Sleep(time.Second)
-
-	mustConnect(node1, "node2", "localhost:50052")
-	mustConnect(node1, "node3", "localhost:50053")
-	mustConnect(node2, "node1", "localhost:50051")
-	mustConnect(node2, "node3", "localhost:50053")
-	mustConnect(node3, "node1", "localhost:50051")
-	mustConnect(node3, "node2", "localhost:50052")
-
-	c1 := network.NewConsensus(node1)
-	c2 := network.NewConsensus(node2)
-	c3 := network.NewConsensus(node3)
-	node1.SetConsensus(c1)
-	node2.SetConsensus(c2)
-	node3.SetConsensus(c3)
-
-	vmState := &pb.VMState{
-		Memory: vm1.Memory.Data[:],
-		Pc:     uint32(vm1.Registers.PC),
-		Acc:    int32(vm1.Registers.ACC),
-	}
-	if err := c1.StartConsensus(&pb.ConsensusMessage{
-		Type:  pb.ConsensusMessage_PRE_PREPARE,
-		View:  1,
-		State: vmState,
-	}); err != nil {
-		log.Fatalf("Consensus start failed: %v", err)
+
+	for _, pair := range [][2]*network.Node{
+		{node1, node2}, {node1, node3}, {node2, node1}, {node2, node3}, {node3, node1}, {node3, node2},
+	} {
+		n, peer := pair[0], pair[1]
+		if err := n.ConnectToPeer(peer.ID, peer.Address, peer.PublicKey()); err != nil {
+			fatal("connecting to peer failed", "node", n.ID, "peer", peer.ID, "err", err)
+		}
+	}
+
+	members, err := network.NewMembership(node1.ID, node2.ID, node3.ID)
+	if err != nil {
+		fatal("invalid cluster membership", "err", err)
+	}
+	logger.Info("cluster membership", "members", members.String())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, n := range []*network.Node{node1, node2, node3} {
+		if err := n.WaitForPeers(ctx, 2); err != nil {
+			fatal("waiting for peers failed", "node", n.ID, "err", err)
+		}
+	}
+	var c1 *network.Consensus
+	for _, n := range []*network.Node{node1, node2, node3} {
+		c, err := network.NewConsensus(n, members)
+		if err != nil {
+			fatal("consensus setup failed", "err", err)
+		}
+		n.SetReplicator(c)
+		if n == node1 {
+			c1 = c
+		}
+	}
+
+	msg := &pb.ConsensusMessage{
+		Type:    pb.ConsensusMessage_PRE_PREPARE,
+		Request: network.NewExecution(compiled.Bytecode, compiled.InitialData, input),
+	}
+	if err := c1.StartConsensus(msg); err != nil {
+		fatal("consensus start failed", "err", err)
 	}
 
+	// ─── 4. Wait until a quorum of replicas reports the same result ──────────
 	deadline := time.Now().Add(20 * time.Second)
-	var result *pb.ConsensusMessage
+	var result *pb.ExecutionResult
 	for time.Now().Before(deadline) {
-		if result = c1.GetDecidedValue(); result != nil {
+		if res, certified := c1.Result(msg.Sequence); certified {
+			result = res
 			break
 		}
 		time.Sleep(100 * time.Millisecond)
 	}
+	for _, d := range c1.Divergences() {
+		logger.Warn("divergence", "seq", d.Sequence, "replica", d.Node)
+	}
 	if result == nil {
-		log.Fatalf("Consensus timed out after 20s")
+		fatal("no certified result after 20s")
 	}
 
-	log.Printf("Consensus reached. Final VM state: PC=%d, ACC=%d",
-		result.State.Pc, result.State.Acc)
-
-	vm1.UpdateState(result.State)
-	vm2.UpdateState(result.State)
-	vm3.UpdateState(result.State)
-	log.Println("All VMs updated to consensus state.")
-}
-
-func mustConnect(n *network.Node, id, addr string) {
-	if err := n.ConnectToPeer(id, addr); err != nil {
-		log.Fatalf("%s → %s: %v", n.ID, id, err)
+	os.Stdout.Write(result.Output)
+	if result.Fault != "" {
+		fatal("execution failed", "fault", result.Fault)
 	}
+	logger.Info("replicas agree on the result", "seq", msg.Sequence, "pc", result.State.Pc, "acc", result.State.Acc)
 }
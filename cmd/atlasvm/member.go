@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+const memberHelpText = `Add a node to a running PBFT cluster, or remove one.
+
+Usage:
+  atlasvm member add --config cluster.json --admin-key admin.key [flags] <id> <addr>
+  atlasvm member remove --admin-key admin.key [flags] <id> <addr>
+
+The change is sent to the node at addr, and from there to the primary.
+The cluster orders it like any request and, once a quorum agrees, prints
+the epoch it starts and the sequence number it starts at: the new
+membership takes over a full log window after the change, with quorum
+sizes to match. The primary fills the rest of the window with no-ops, so
+this takes a moment rather than a window's worth of requests.
+
+add reads the new node's address and public key from --config, a cluster
+config that lists it: write one that adds the node to the current config
+and start the node with it. The new node catches up from the others'
+latest stable checkpoint once it is a member. Give the config to the other
+nodes too, so that they find the new node when they restart.
+
+A removed node shuts down once the new epoch starts.
+
+Every change is signed with --admin-key, the operator's key, for the epoch
+the cluster is in. Replicas refuse changes that are not signed with the
+key the cluster config lists as "admin_key", so that a faulty primary
+cannot change the membership on its own. Create the key with "atlasvm
+keygen" and put the public key it prints in the config.
+
+Both need the same authorization as "atlasvm status --shutdown": on a
+cluster with TLS, --cert and --key of an operator certificate; without it,
+running on the host of the primary.
+
+Flags:
+`
+
+// runMember implements the "member" subcommand and returns the exit code.
+func runMember(args []string) int {
+	fs := flag.NewFlagSet("member", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, memberHelpText)
+		fs.PrintDefaults()
+	}
+	admin := addAdminFlags(fs, 20*time.Second)
+	configPath := fs.String("config", "", "cluster config listing the node to add")
+	adminKeyPath := fs.String("admin-key", "", "the operator's key, whose public half is the cluster's admin key")
+	if len(args) == 0 {
+		fs.Usage()
+		return 2
+	}
+	action, ok := pb.Reconfiguration_Action_value[map[string]string{"add": "ADD", "remove": "REMOVE"}[args[0]]]
+	fs.Parse(args[1:])
+	if !ok || fs.NArg() != 2 || !admin.valid() || *adminKeyPath == "" ||
+		(action == int32(pb.Reconfiguration_ADD)) != (*configPath != "") {
+		fs.Usage()
+		return 2
+	}
+
+	change := &pb.Reconfiguration{
+		Action: pb.Reconfiguration_Action(action),
+		Member: &pb.Member{Id: fs.Arg(0)},
+	}
+	if change.Action == pb.Reconfiguration_ADD {
+		cfg, err := network.LoadClusterConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "member: %v\n", err)
+			return 1
+		}
+		n, ok := cfg.Node(change.Member.Id)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "member: node %q is not in %s\n", change.Member.Id, *configPath)
+			return 1
+		}
+		key, err := network.DecodePublicKey(n.PublicKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "member: %v\n", err)
+			return 1
+		}
+		change.Member.Address = n.Address
+		change.Member.PublicKey = key
+	}
+
+	conn, err := admin.dial(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "member: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), *admin.timeout)
+	defer cancel()
+
+	adminKey, err := network.LoadPrivateKey(*adminKeyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "member: %v\n", err)
+		return 1
+	}
+	st, err := pb.NewNodeAdminClient(conn).Status(ctx, &pb.StatusRequest{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "member: %v\n", err)
+		return 1
+	}
+	change.Epoch = st.Epoch
+	network.SignReconfiguration(change, adminKey)
+	req := &pb.ChangeMembershipRequest{Change: change}
+	resp, err := pb.NewNodeAdminClient(conn).ChangeMembership(ctx, req)
+	if err == nil && resp.LeaderAddress != "" {
+		// The node is not the primary; send the change there ourselves.
+		conn.Close()
+		if conn, err = admin.dial(resp.LeaderAddress); err != nil {
+			fmt.Fprintf(os.Stderr, "member: %v\n", err)
+			return 1
+		}
+		defer conn.Close()
+		resp, err = pb.NewNodeAdminClient(conn).ChangeMembership(ctx, req)
+	}
+	if err == nil && resp.LeaderAddress != "" {
+		err = fmt.Errorf("%s does not lead the cluster either", resp.LeaderAddress)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "member: %v\n", err)
+		return 1
+	}
+
+	stream, err := pb.NewClientServiceClient(conn).WatchExecutions(ctx, &pb.WatchRequest{FromSequence: resp.Sequence})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "member: %v\n", err)
+		return 1
+	}
+	for {
+		report, err := stream.Recv()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "member: request %s: %v\n", resp.RequestId, err)
+			return 1
+		}
+		if report.RequestId != resp.RequestId {
+			continue
+		}
+		if fault := report.Result.GetFault(); fault != "" || report.Diverged {
+			fmt.Fprintf(os.Stderr, "member: change refused: %s\n", fault)
+			return 1
+		}
+		os.Stdout.Write(report.Result.GetOutput())
+		return 0
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/metrics"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+)
+
+const nodeHelpText = `Run one replica of an AtlasVM cluster until interrupted.
+
+Usage:
+  atlasvm node --config cluster.json --id node1 [--key node1.key] [--wal node1.wal]
+               [--replication pbft|raft] [--metrics-addr :9090]
+               [--log-level debug|info|warn|error] [--log-format text|json]
+
+The cluster config lists every node's ID, address and public key:
+
+  {"nodes": [
+    {"id": "node1", "address": "localhost:50051", "public_key": "..."},
+    ...
+  ]}
+
+Each node's key file is created by "atlasvm keygen", which also prints the
+public key to put in the config.
+
+If the config has a "tls" section, naming the cluster CA, and a "cert" for
+every node, nodes connect to each other over mutual TLS and only accept
+messages a peer signed itself. "atlasvm gencerts" adds both with a
+throwaway CA for local clusters. Without them, peers connect in plaintext.
+
+The node records what it accepts, votes for and decides in a write-ahead
+log, and checkpoints its VM there. Restarted with the same log, it picks up
+where it stopped.
+
+By default the cluster replicates with PBFT, which tolerates f Byzantine
+nodes out of 3f+1. With --replication=raft it uses Raft instead, which
+only tolerates crashes, of fewer than half the nodes, but sends fewer
+messages per request. Every node of a cluster must use the same mode.
+
+As primary, or leader, the node proposes client requests in batches of up to
+--batch-size, collected for at most --batch-delay. Under PBFT every request
+of a batch still gets its own round of votes, but the votes of a batch
+travel together; a Raft leader appends the whole batch at once.
+
+With --metrics-addr, the node serves its metrics at /metrics on that
+address in the Prometheus text format: instructions run by opcode, VM
+faults, consensus rounds and phase latencies, view changes, and messages
+sent, received and lost by peer.
+
+The node logs to stderr, one record per event, each carrying the node ID
+and, where they apply, the view or term, sequence number and request ID.
+--log-format=json writes them as JSON for log collectors; --log-level=debug
+adds every message handled and every program run.
+
+Every node also serves NodeAdmin, for "atlasvm status" and other operator
+tools: health, status, changing the log level while the node runs, and
+graceful shutdown. The last two need an operator certificate from the
+cluster CA, one the config lists under "operators" in its "tls" section,
+or, without TLS, a call from the node's own host. A node shuts down
+gracefully on SIGINT or SIGTERM too, waiting up to --shutdown-timeout for
+calls in progress.
+
+A PBFT cluster changes its membership through "atlasvm member", with
+changes signed by the operator's key, whose public half the config lists
+as "admin_key"; without one, the cluster refuses every change. A node
+joining a running cluster is started with a config that lists it along
+with the current members; it waits for the others to agree to add it, then
+catches up from their latest stable checkpoint. A removed node shuts down.
+
+Flags:
+`
+
+// runNode implements the "node" subcommand and returns the exit code.
+func runNode(args []string) int {
+	fs := flag.NewFlagSet("node", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, nodeHelpText)
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", "cluster.json", "cluster config file")
+	id := fs.String("id", "", "ID of this node in the cluster config")
+	keyPath := fs.String("key", "", "this node's private key file (default <id>.key)")
+	walPath := fs.String("wal", "", "this node's write-ahead log (default <id>.wal)")
+	fsync := fs.String("fsync", "always", "when to sync the write-ahead log: always, interval or never")
+	batchSize := fs.Int("batch-size", network.DefaultBatching.Size, "most client requests the primary proposes together")
+	batchDelay := fs.Duration("batch-delay", network.DefaultBatching.Delay, "how long the primary waits to fill a batch")
+	replication := fs.String("replication", "pbft", "replication protocol: pbft or raft")
+	metricsAddr := fs.String("metrics-addr", "", "address to serve Prometheus metrics on (default none)")
+	sendTimeout := fs.Duration("send-timeout", network.DefaultSendTimeout, "how long sending a message to one peer may take")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 5*time.Second, "how long a graceful shutdown waits for calls in progress")
+	logging := addLogFlags(fs)
+	fs.Parse(args)
+
+	if *id == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+	if *keyPath == "" {
+		*keyPath = *id + ".key"
+	}
+	if *walPath == "" {
+		*walPath = *id + ".wal"
+	}
+	policy, err := network.ParseSyncPolicy(*fsync)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "node: %v\n", err)
+		return 2
+	}
+	mode, err := network.ParseReplication(*replication)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "node: %v\n", err)
+		return 2
+	}
+	if *batchSize < 1 {
+		fmt.Fprintln(os.Stderr, "node: --batch-size must be at least 1")
+		return 2
+	}
+	logger, err := logging.logger()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "node: %v\n", err)
+		return 2
+	}
+
+	node, err := setupNode(*configPath, *id, *keyPath, mode, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "node: %v\n", err)
+		return 1
+	}
+	node.SetSendTimeout(*sendTimeout)
+	node.SetLevelVar(&logging.levelVar)
+	if *metricsAddr != "" {
+		reg := metrics.NewRegistry()
+		node.SetMetrics(reg)
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", reg)
+		metricsLis, err := net.Listen("tcp", *metricsAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "node: %v\n", err)
+			return 1
+		}
+		go func() {
+			if err := http.Serve(metricsLis, mux); err != nil {
+				logger.Error("serving metrics failed", "node", node.ID, "err", err)
+			}
+		}()
+		logger.Info("serving metrics", "node", node.ID, "url", "http://"+metricsLis.Addr().String()+"/metrics")
+	}
+	node.Replicator().SetBatching(network.Batching{Size: *batchSize, Delay: *batchDelay})
+	wal, err := network.OpenWAL(*walPath, network.WALOptions{Sync: policy, Logger: logger.With("node", node.ID)})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "node: %v\n", err)
+		return 1
+	}
+	defer wal.Close()
+	if err := node.Replicator().Recover(wal); err != nil {
+		fmt.Fprintf(os.Stderr, "node: recovering from %s: %v\n", *walPath, err)
+		return 1
+	}
+
+	lis, err := net.Listen("tcp", node.Address)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "node: %v\n", err)
+		return 1
+	}
+	served := make(chan error, 1)
+	go func() { served <- node.Serve(lis) }()
+
+	// Peers come up in any order; wait for enough of them in the
+	// background and keep serving meanwhile.
+	members := node.Replicator().Membership()
+	quorum := members.Quorum()
+	if mode == network.ReplicationRaft {
+		quorum = members.Majority()
+	}
+	go func() {
+		if err := node.WaitForPeers(context.Background(), quorum-1); err != nil {
+			return
+		}
+		logger.Info("reached a quorum of peers", "node", node.ID)
+		// A PBFT node that was down while the others moved on starts from
+		// their latest stable checkpoint. A Raft leader sends its log
+		// instead.
+		if mode != network.ReplicationPBFT {
+			return
+		}
+		if err := node.Replicator().CatchUp(context.Background()); err == nil {
+			logger.Info("caught up", "node", node.ID, "seq", node.Replicator().LastApplied())
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	select {
+	case sig := <-stop:
+		logger.Info("shutting down", "node", node.ID, "signal", sig.String())
+		node.Shutdown(*shutdownTimeout)
+		return 0
+	case <-node.ShutdownRequested():
+		logger.Info("shutting down on request", "node", node.ID)
+		node.Shutdown(*shutdownTimeout)
+		return 0
+	case err := <-served:
+		fmt.Fprintf(os.Stderr, "node: %v\n", err)
+		return 1
+	}
+}
+
+// setupNode builds the replica id of the cluster described at configPath,
+// signing with the key at keyPath, replicating in the given mode and logging
+// to logger.
+func setupNode(configPath, id, keyPath string, mode network.Replication, logger *slog.Logger) (*network.Node, error) {
+	cfg, err := network.LoadClusterConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	self, ok := cfg.Node(id)
+	if !ok {
+		return nil, fmt.Errorf("node %q is not in %s", id, configPath)
+	}
+	key, err := network.LoadPrivateKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := network.DecodePublicKey(self.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !pub.Equal(key.Public()) {
+		return nil, fmt.Errorf("%s does not match the public key of %s in %s", keyPath, id, configPath)
+	}
+	members, err := cfg.Membership()
+	if err != nil {
+		return nil, err
+	}
+
+	machine := vm.NewVM(nil, io.Discard)
+	machine.SetLogger(logger.With("node", id))
+	node := network.NewNode(self.ID, self.Address, machine, logger)
+	node.SetKey(key)
+	if cfg.TLS != nil {
+		t, err := network.LoadNodeTLS(cfg, id, key)
+		if err != nil {
+			return nil, err
+		}
+		node.SetTLS(t)
+	} else {
+		logger.Warn("no TLS configured, peers connect in plaintext", "node", id, "config", configPath)
+	}
+	r, err := network.NewReplicator(mode, node, members)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := r.(*network.Consensus); ok && cfg.AdminKey != "" {
+		admin, err := network.DecodePublicKey(cfg.AdminKey)
+		if err != nil {
+			return nil, err
+		}
+		c.SetAdminKey(admin)
+	}
+	node.SetReplicator(r)
+	if err := node.ConnectToCluster(cfg); err != nil {
+		return nil, err
+	}
+	logger.Info("joining cluster", "node", id, "members", members.String(), "replication", string(mode))
+	return node, nil
+}
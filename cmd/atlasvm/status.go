@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+const statusHelpText = `Show the status of a running node, or change it.
+
+Usage:
+  atlasvm status [flags] <addr>
+
+Asks the node at addr over NodeAdmin whether it is healthy, which view or
+term it is in and who leads it, the consensus phase it is in, the last
+slot it decided, the cluster members and any change to them under way,
+and how its connections to its peers stand. The exit code
+is 1 if the node is not serving.
+
+--set-log-level changes the level the node logs at, and --shutdown makes it
+shut down gracefully. On a cluster with TLS both need --cert and --key: a
+certificate the cluster CA issued to an operator the cluster config lists,
+such as the operator.crt and operator.key "atlasvm gencerts" writes. A
+node's certificate does not do. Without TLS they only work from the
+node's own host.
+
+Flags:
+`
+
+// runStatus implements the "status" subcommand and returns the exit code.
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, statusHelpText)
+		fs.PrintDefaults()
+	}
+	admin := addAdminFlags(fs, 5*time.Second)
+	setLevel := fs.String("set-log-level", "", "change the node's log level: debug, info, warn or error")
+	shutdown := fs.Bool("shutdown", false, "shut the node down gracefully")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || !admin.valid() {
+		fs.Usage()
+		return 2
+	}
+
+	conn, err := admin.dial(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "status: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+	client := pb.NewNodeAdminClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), *admin.timeout)
+	defer cancel()
+
+	if *setLevel != "" {
+		resp, err := client.SetLogLevel(ctx, &pb.SetLogLevelRequest{Level: *setLevel})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "status: %v\n", err)
+			return 1
+		}
+		fmt.Printf("log level changed from %s\n", resp.Previous)
+	}
+	if *shutdown {
+		if _, err := client.Shutdown(ctx, &pb.ShutdownRequest{}); err != nil {
+			fmt.Fprintf(os.Stderr, "status: %v\n", err)
+			return 1
+		}
+		fmt.Println("node is shutting down")
+		return 0
+	}
+
+	health, err := client.Health(ctx, &pb.HealthRequest{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "status: %v\n", err)
+		return 1
+	}
+	st, err := client.Status(ctx, &pb.StatusRequest{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "status: %v\n", err)
+		return 1
+	}
+	printStatus(health, st)
+	if health.Status != pb.HealthResponse_SERVING {
+		return 1
+	}
+	return 0
+}
+
+// printStatus writes what a node reported about itself to stdout.
+func printStatus(health *pb.HealthResponse, st *pb.NodeStatus) {
+	view := "view"
+	if st.Replication == string(network.ReplicationRaft) {
+		view = "term"
+	}
+	leader := st.Leader
+	if leader == "" {
+		leader = "unknown"
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "node\t%s at %s\n", st.NodeId, st.Address)
+	fmt.Fprintf(w, "health\t%s\n", health.Status)
+	fmt.Fprintf(w, "replication\t%s\n", st.Replication)
+	fmt.Fprintf(w, "%s\t%d, led by %s\n", view, st.View, leader)
+	fmt.Fprintf(w, "phase\t%s\n", st.Phase)
+	fmt.Fprintf(w, "applied\t%d\n", st.LastApplied)
+	if st.LastDecided > 0 {
+		fmt.Fprintf(w, "decided\t%d, request %.12s\n", st.LastDecided, st.LastDecidedDigest)
+	} else {
+		fmt.Fprintf(w, "decided\tnothing yet\n")
+	}
+	if len(st.Members) > 0 {
+		epoch := ""
+		if st.Replication == string(network.ReplicationPBFT) {
+			epoch = fmt.Sprintf(" in epoch %d", st.Epoch)
+		}
+		fmt.Fprintf(w, "members\t%s%s\n", strings.Join(st.Members, ", "), epoch)
+	}
+	if len(st.NextMembers) > 0 {
+		fmt.Fprintf(w, "next\t%s from sequence %d\n", strings.Join(st.NextMembers, ", "), st.NextEpochStart)
+	}
+	fmt.Fprintf(w, "log level\t%s\n", st.LogLevel)
+	for i, p := range st.Peers {
+		label := ""
+		if i == 0 {
+			label = "peers"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", label, p.Id, p.Address, p.State)
+	}
+	w.Flush()
+}
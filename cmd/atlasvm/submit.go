@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const submitHelpText = `Run an AtlasPL program on a running cluster.
+
+Usage:
+  atlasvm submit [flags] <program.atlas>
+  atlasvm submit --watch [flags]
+
+The program is sent to the node at --addr, which compiles it and passes it
+to the primary. Once a quorum of replicas agrees on the result, its output
+is printed. With --watch, every execution the node settles is listed
+instead, until interrupted.
+
+If the cluster uses TLS, pass its CA certificate with --ca.
+
+Flags:
+`
+
+// runSubmit implements the "submit" subcommand and returns the exit code.
+func runSubmit(args []string) int {
+	fs := flag.NewFlagSet("submit", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, submitHelpText)
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", "localhost:50051", "address of any cluster node")
+	inputFile := fs.String("input", "", "file holding the input tape for IN")
+	timeout := fs.Duration("timeout", 20*time.Second, "how long to wait for a certified result")
+	watch := fs.Bool("watch", false, "list every settled execution instead of submitting")
+	caPath := fs.String("ca", "", "CA certificate of a cluster that uses TLS")
+	fs.Parse(args)
+
+	if *watch != (fs.NArg() == 0) {
+		fs.Usage()
+		return 2
+	}
+
+	creds := insecure.NewCredentials()
+	if *caPath != "" {
+		roots, err := network.LoadCertPool(*caPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "submit: %v\n", err)
+			return 1
+		}
+		creds = credentials.NewTLS(&tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS13})
+	}
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "submit: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+	client := pb.NewClientServiceClient(conn)
+
+	if *watch {
+		return watchExecutions(client)
+	}
+
+	src, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "submit: %v\n", err)
+		return 1
+	}
+	req := &pb.SubmitRequest{Program: &pb.SubmitRequest_Source{Source: string(src)}}
+	if *inputFile != "" {
+		if req.Input, err = os.ReadFile(*inputFile); err != nil {
+			fmt.Fprintf(os.Stderr, "submit: %v\n", err)
+			return 1
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	resp, err := client.SubmitProgram(ctx, req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "submit: %v\n", err)
+		return 1
+	}
+
+	// Every replica executes the request in the slot the primary gave it,
+	// so watching from there finds it on whichever node we talk to.
+	stream, err := client.WatchExecutions(ctx, &pb.WatchRequest{FromSequence: resp.Sequence})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "submit: %v\n", err)
+		return 1
+	}
+	for {
+		report, err := stream.Recv()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "submit: request %s: %v\n", resp.RequestId, err)
+			return 1
+		}
+		if report.RequestId != resp.RequestId {
+			continue
+		}
+		os.Stdout.Write(report.Result.GetOutput())
+		switch {
+		case report.Diverged:
+			fmt.Fprintf(os.Stderr, "submit: node at %s diverged from the quorum on request %s\n", *addr, resp.RequestId)
+			return 1
+		case report.Result.GetFault() != "":
+			fmt.Fprintf(os.Stderr, "submit: %s\n", report.Result.GetFault())
+			return 1
+		}
+		return 0
+	}
+}
+
+// watchExecutions prints every execution the node settles.
+func watchExecutions(client pb.ClientServiceClient) int {
+	stream, err := client.WatchExecutions(context.Background(), &pb.WatchRequest{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "submit: %v\n", err)
+		return 1
+	}
+	for {
+		r, err := stream.Recv()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "submit: %v\n", err)
+			return 1
+		}
+		state := "certified"
+		if r.Diverged {
+			state = "diverged"
+		}
+		fmt.Printf("seq=%d id=%s %s acc=%d fault=%q output=%q\n",
+			r.Sequence, r.RequestId, state, r.Result.GetState().GetAcc(), r.Result.GetFault(), r.Result.GetOutput())
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/conformance"
+)
+
+const testHelpText = `Run AtlasPL programs against their golden files.
+
+Usage:
+  atlasvm test [flags] [dir ...]   (default dir: examples)
+
+Every foo.atlas with a foo.expected or foo.err next to it is compiled and
+run; foo.input, when present, is fed to the program's input.
+
+Flags:
+`
+
+// runTests implements the "test" subcommand and returns the exit code.
+func runTests(args []string) int {
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, testHelpText)
+		fs.PrintDefaults()
+	}
+	update := fs.Bool("update", false, "rewrite golden files from the observed behaviour")
+	steps := fs.Int("steps", conformance.DefaultStepLimit, "maximum instructions executed per program")
+	verbose := fs.Bool("v", false, "also list passing programs")
+	fs.Parse(args)
+
+	dirs := fs.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"examples"}
+	}
+
+	discover := conformance.Discover
+	if *update {
+		discover = conformance.DiscoverAll
+	}
+	cases, err := discover(dirs...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "test: %v\n", err)
+		return 1
+	}
+
+	results, err := conformance.RunAll(cases, conformance.Options{Update: *update, StepLimit: *steps})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "test: %v\n", err)
+		return 1
+	}
+
+	failed := 0
+	for _, res := range results {
+		switch {
+		case res.Updated:
+			fmt.Printf("UPDATE %s\n", res.Case.Source)
+		case res.Passed():
+			if *verbose {
+				fmt.Printf("PASS   %s\n", res.Case.Source)
+			}
+		default:
+			failed++
+			fmt.Printf("FAIL   %s\n%s", res.Case.Source, res.Diff)
+		}
+	}
+	fmt.Printf("%d programs, %d failed\n", len(results), failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -36,10 +36,11 @@ func (p *Program) TokenLiteral() string {
 // ---------------------------------------------------------------------------
 
 type VarStatement struct {
-	Token lexer.Token // the 'var' token
-	Name  *Identifier
-	Type  string
-	Value Expression
+	Token  lexer.Token // the 'var' token
+	Name   *Identifier
+	Type   string // int, int16 or int32; the element type for arrays
+	Length int64  // element count of an array declaration, 0 for scalars
+	Value  Expression
 }
 
 func (vs *VarStatement) statementNode()       {}
@@ -82,6 +83,7 @@ func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
 type AssignmentStatement struct {
 	Token lexer.Token // the '=' token
 	Name  *Identifier
+	Index Expression // element index when assigning into an array, else nil
 	Value Expression
 }
 
@@ -134,3 +136,12 @@ type InfixExpression struct {
 
 func (ie *InfixExpression) expressionNode()      {}
 func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
+
+type IndexExpression struct {
+	Token lexer.Token // the '[' token
+	Left  Expression
+	Index Expression
+}
+
+func (ie *IndexExpression) expressionNode()      {}
+func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
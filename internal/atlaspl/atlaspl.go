@@ -0,0 +1,33 @@
+// Package atlaspl ties the lexer, parser and compiler together so callers
+// can turn AtlasPL source into a loadable program in one step.
+package atlaspl
+
+import (
+	"io"
+	"strings"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/compiler"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/lexer"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/parser"
+)
+
+// ParseError carries every error the parser reported for a source file.
+type ParseError struct {
+	Errors []string
+}
+
+func (e *ParseError) Error() string {
+	return "parse errors: " + strings.Join(e.Errors, "; ")
+}
+
+// Compile lexes, parses and compiles the AtlasPL source read from src.
+// Parse failures are reported as a *ParseError; compile failures are
+// returned as-is from the compiler.
+func Compile(src io.Reader) (*compiler.CompiledProgram, error) {
+	p := parser.NewParser(lexer.NewLexer(src))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		return nil, &ParseError{Errors: errs}
+	}
+	return compiler.NewCompiler().Compile(program)
+}
@@ -7,12 +7,19 @@ import (
 )
 
 // ---------------------------------------------------------------------------
-// Data-segment memory layout (4-bit operand → addresses 0x00–0x0F only)
+// Data-segment memory layout
 // ---------------------------------------------------------------------------
-//   0x00 – 0x05  User variables   (up to 6)
-//   0x06          tempReg1         scratch register 1
-//   0x07          tempReg2         scratch register 2
-//   0x08 – 0x0F  Constant pool    (up to 8 distinct integer literals)
+//   0x00 – 0x05  int variables      (up to 6)
+//   0x06          tempReg1            scratch register 1
+//   0x07          tempReg2            scratch register 2
+//   0x08 – 0x0F  int constant pool  (up to 8 distinct integer literals)
+//   0x10 – 0x13  wideTempReg1        scratch register 1 for int16/int32
+//   0x14 – 0x17  wideTempReg2        scratch register 2 for int16/int32
+//   0x18 – ↑     High area: arrays and int16/int32 variables, growing up
+//      ↓ – 0xFF  int16/int32 constant pool, growing down
+//
+// Everything from 0x10 up is beyond the 4-bit operand and is reached only
+// through long-form and indexed instructions.
 // ---------------------------------------------------------------------------
 
 const (
@@ -22,6 +29,10 @@ const (
 	tempReg2      = byte(0x07)
 	constAreaBase = byte(0x08)
 	maxConsts     = 8
+	wideTempReg1  = byte(0x10)
+	wideTempReg2  = byte(0x14)
+	highAreaBase  = 0x18
+	highAreaEnd   = 0x100
 )
 
 // Opcode bytes — upper nibble pre-shifted, OR'd with a 4-bit operand to
@@ -42,6 +53,16 @@ const (
 	bIN    = byte(0xC0)
 	bOUT   = byte(0xD0)
 	bHALT  = byte(0xE0)
+	bEXT   = byte(0xF0)
+)
+
+// Extended operations, placed in the low nibble of an EXT byte and followed
+// by a full operand byte.
+const (
+	xIDX = byte(0x0C) // bounds-check ACC against the operand, then IX = ACC
+	xLDX = byte(0x0D) // ACC = mem[operand + IX*W]
+	xSTX = byte(0x0E) // mem[operand + IX*W] = ACC
+	xWID = byte(0x0F) // W = operand: the width of memory operands and ACC
 )
 
 // CompiledProgram is the output of a successful compilation.
@@ -55,24 +76,35 @@ type CompiledProgram struct {
 	Bytecode []byte
 }
 
+// constKey identifies a constant-pool entry: the same value stored at a
+// different width needs a slot of its own.
+type constKey struct {
+	value int64
+	width byte
+}
+
 // Compiler walks an AtlasPL AST and emits AtlasVM bytecode.
 type Compiler struct {
-	varTable    map[string]byte // variable name → data-segment address
-	constTable  map[int64]byte  // constant value → data-segment address
-	initialData map[uint8]byte  // initial memory values passed to vm.LoadData
-	code        []byte          // emitted bytecode (code-segment bytes)
-	nextVar     byte            // next free variable address
-	nextConst   byte            // next free constant-pool address
+	symbols     *symbolTable      // lexically scoped variable addresses
+	constTable  map[constKey]byte // constant value → data-segment address
+	initialData map[uint8]byte    // initial memory values passed to vm.LoadData
+	code        []byte            // emitted bytecode (code-segment bytes)
+	nextConst   byte              // next free int constant-pool address
+	width       byte              // width of the expression being compiled
+	vmWidth     byte              // W the VM holds at this point in the code, 0 if unknown
+	jumpWidths  map[int]byte      // vmWidth at each jump still waiting for patch
 }
 
 // NewCompiler returns a ready-to-use Compiler.
 func NewCompiler() *Compiler {
 	return &Compiler{
-		varTable:    make(map[string]byte),
-		constTable:  make(map[int64]byte),
+		symbols:     newSymbolTable(varAreaBase, varAreaBase+maxUserVars, highAreaBase, highAreaEnd),
+		constTable:  make(map[constKey]byte),
 		initialData: make(map[uint8]byte),
-		nextVar:     varAreaBase,
+		jumpWidths:  make(map[int]byte),
 		nextConst:   constAreaBase,
+		width:       1,
+		vmWidth:     1, // vm.LoadProgram resets W to 1
 	}
 }
 
@@ -85,6 +117,11 @@ func (c *Compiler) Compile(program *ast.Program) (*CompiledProgram, error) {
 	}
 	c.emit(bHALT, 0) // guarantee termination
 
+	// PC is a single byte, so nothing past offset 255 can be reached.
+	if len(c.code) > maxProgramSize {
+		return nil, fmt.Errorf("program too large: %d bytes of code, maximum is %d", len(c.code), maxProgramSize)
+	}
+
 	return &CompiledProgram{
 		InitialData: c.initialData,
 		Bytecode:    c.code,
@@ -95,58 +132,144 @@ func (c *Compiler) Compile(program *ast.Program) (*CompiledProgram, error) {
 // Emission helpers
 // ---------------------------------------------------------------------------
 
+// emit appends one instruction. Operands that do not fit in the 4-bit field
+// are encoded with the two-byte long form of the same opcode instead.
 func (c *Compiler) emit(opcode, operand byte) {
+	if operand > 0x0F {
+		c.code = append(c.code, bEXT|opcode>>4, operand)
+		return
+	}
 	c.code = append(c.code, opcode|operand)
 }
 
+// emitExt appends a two-byte extended instruction.
+func (c *Compiler) emitExt(op, operand byte) {
+	c.code = append(c.code, bEXT|op, operand)
+}
+
+// setWidth makes sure the VM's operand width is w at this point in the
+// code, emitting WID only when it may differ.
+func (c *Compiler) setWidth(w byte) {
+	if c.vmWidth != w {
+		c.emitExt(xWID, w)
+		c.vmWidth = w
+	}
+}
+
 // currentPC returns the index of the next instruction to be emitted —
 // i.e. the code-segment offset the runtime PC will hold at that point.
 func (c *Compiler) currentPC() byte { return byte(len(c.code)) }
 
-// emitJump appends a jump instruction with a placeholder operand (0) and
-// returns the index so it can be back-patched via patch().
+// emitJump appends the long form of a jump instruction with a placeholder
+// target and returns the index of its operand byte so it can be
+// back-patched via patch(). The long form is used because the target is not
+// known yet and may lie beyond the 4-bit range.
 func (c *Compiler) emitJump(opcode byte) int {
-	idx := len(c.code)
-	c.code = append(c.code, opcode|0x00)
+	c.code = append(c.code, bEXT|opcode>>4, 0x00)
+	idx := len(c.code) - 1
+	c.jumpWidths[idx] = c.vmWidth
 	return idx
 }
 
-// patch writes the correct 4-bit target into a previously emitted jump.
+// patch writes the target into a previously emitted jump. The target is
+// reached both from the jump and from the code before it, so the VM's
+// operand width there is only known if both paths agree on it.
 func (c *Compiler) patch(idx int, target byte) {
-	c.code[idx] = (c.code[idx] & 0xF0) | (target & 0x0F)
+	c.code[idx] = target
+	if c.jumpWidths[idx] != c.vmWidth {
+		c.vmWidth = 0
+	}
+	delete(c.jumpWidths, idx)
 }
 
 // ---------------------------------------------------------------------------
 // Address allocation
 // ---------------------------------------------------------------------------
 
-// allocVar reserves a data-segment address for name, reusing it on
-// re-declaration to support simple variable shadowing.
-func (c *Compiler) allocVar(name string) (byte, error) {
-	if addr, ok := c.varTable[name]; ok {
-		return addr, nil
+// allocVar reserves a data-segment address for name in the current scope.
+func (c *Compiler) allocVar(name string, width byte) (byte, error) {
+	return c.symbols.declare(name, width)
+}
+
+// allocArray reserves length contiguous values for name in the current scope.
+func (c *Compiler) allocArray(name string, width byte, length int64) (byte, error) {
+	return c.symbols.declareArray(name, width, length)
+}
+
+// lookupVar returns the innermost visible scalar variable name.
+func (c *Compiler) lookupVar(name string) (symbol, error) {
+	sym, ok := c.symbols.resolve(name)
+	if !ok {
+		return symbol{}, fmt.Errorf("undefined variable: %s", name)
 	}
-	if c.nextVar >= constAreaBase {
-		return 0, fmt.Errorf("too many variables: maximum is %d", maxUserVars)
+	if sym.isArray() {
+		return symbol{}, fmt.Errorf("array %s used without an index", name)
 	}
-	addr := c.nextVar
-	c.varTable[name] = addr
-	c.nextVar++
-	return addr, nil
+	return sym, nil
+}
+
+// lookupArray returns the array symbol that expr names.
+func (c *Compiler) lookupArray(expr ast.Expression) (symbol, error) {
+	ident, ok := expr.(*ast.Identifier)
+	if !ok {
+		return symbol{}, fmt.Errorf("only named arrays can be indexed")
+	}
+	sym, ok := c.symbols.resolve(ident.Value)
+	if !ok {
+		return symbol{}, fmt.Errorf("undefined variable: %s", ident.Value)
+	}
+	if !sym.isArray() {
+		return symbol{}, fmt.Errorf("cannot index non-array variable %s", ident.Value)
+	}
+	return sym, nil
+}
+
+// elementAddr returns the address of arr[index] for a constant index,
+// rejecting indexes that are out of bounds at compile time.
+func elementAddr(arr symbol, index int64) (byte, error) {
+	if index < 0 || index >= arr.length {
+		return 0, fmt.Errorf("index %d out of range for array of length %d", index, arr.length)
+	}
+	return arr.addr + byte(index)*arr.width, nil
 }
 
-// allocConst returns the constant-pool address for val, allocating a new
-// slot and seeding InitialData if this value has not been seen before.
+// allocConst returns the constant-pool address for val at the width of the
+// expression being compiled, allocating a new slot and seeding InitialData
+// if this value has not been seen at that width before. Values that do not
+// fit the width are rejected rather than silently truncated.
 func (c *Compiler) allocConst(val int64) (byte, error) {
-	if addr, ok := c.constTable[val]; ok {
+	key := constKey{value: val, width: c.width}
+	if addr, ok := c.constTable[key]; ok {
 		return addr, nil
 	}
-	if c.nextConst > constAreaBase+maxConsts-1 {
-		return 0, fmt.Errorf("constant pool full (max %d distinct constants)", maxConsts)
+	if !fitsWidth(val, c.width) {
+		return 0, fmt.Errorf("constant %d does not fit in %s", val, typeName(c.width))
+	}
+
+	var addr byte
+	if c.width == 1 {
+		if c.nextConst > constAreaBase+maxConsts-1 {
+			return 0, fmt.Errorf("constant pool full (max %d distinct constants)", maxConsts)
+		}
+		addr = c.nextConst
+		c.nextConst++
+	} else {
+		var ok bool
+		if addr, ok = c.symbols.claimHigh(int(c.width)); !ok {
+			return 0, fmt.Errorf("no data space left for constant %d", val)
+		}
+	}
+	c.constTable[key] = addr
+	for i := byte(0); i < c.width; i++ {
+		c.initialData[addr+i] = byte(val >> (8 * i))
 	}
-	addr := c.nextConst
-	c.constTable[val] = addr
-	c.initialData[addr] = byte(val)
-	c.nextConst++
 	return addr, nil
 }
+
+// temps returns the two scratch registers for the current width.
+func (c *Compiler) temps() (byte, byte) {
+	if c.width == 1 {
+		return tempReg1, tempReg2
+	}
+	return wideTempReg1, wideTempReg2
+}
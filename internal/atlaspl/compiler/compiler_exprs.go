@@ -18,12 +18,11 @@ func (c *Compiler) compileExpression(expr ast.Expression) error {
 		return nil
 
 	case *ast.Identifier:
-		addr, ok := c.varTable[e.Value]
-		if !ok {
-			return fmt.Errorf("undefined variable: %s", e.Value)
+		sym, err := c.lookupVar(e.Value)
+		if err != nil {
+			return err
 		}
-		c.emit(bLOAD, addr)
-		return nil
+		return c.emitLoad(e.Value, sym.addr, sym.width)
 
 	case *ast.InfixExpression:
 		return c.compileInfixExpression(e)
@@ -31,28 +30,93 @@ func (c *Compiler) compileExpression(expr ast.Expression) error {
 	case *ast.PrefixExpression:
 		return c.compilePrefixExpression(e)
 
+	case *ast.IndexExpression:
+		return c.compileIndexExpression(e)
+
+	case nil:
+		return fmt.Errorf("missing expression")
+
 	default:
 		return fmt.Errorf("unsupported expression type: %T", expr)
 	}
 }
 
+// emitLoad loads the value of width bytes at addr into ACC. A narrower value
+// is read at its own width and widened by the sign extension of the VM; a
+// wider one cannot be used without losing bits.
+func (c *Compiler) emitLoad(name string, addr, width byte) error {
+	if width > c.width {
+		return fmt.Errorf("cannot use %s variable %s in %s context", typeName(width), name, typeName(c.width))
+	}
+	c.setWidth(width)
+	c.emit(bLOAD, addr)
+	c.setWidth(c.width)
+	return nil
+}
+
 // simpleAddr returns the memory address for a simple expression (identifier
-// or integer literal) without emitting code. Returns error for compound exprs.
+// or integer literal) without emitting code. Returns error for compound exprs
+// and for values whose width differs from the expression being compiled.
 func (c *Compiler) simpleAddr(expr ast.Expression) (byte, error) {
 	switch e := expr.(type) {
 	case *ast.Identifier:
-		addr, ok := c.varTable[e.Value]
-		if !ok {
-			return 0, fmt.Errorf("undefined variable: %s", e.Value)
+		sym, err := c.lookupVar(e.Value)
+		if err != nil {
+			return 0, err
 		}
-		return addr, nil
+		if sym.width != c.width {
+			return 0, fmt.Errorf("complex expression")
+		}
+		return sym.addr, nil
 	case *ast.IntegerLiteral:
 		return c.allocConst(e.Value)
+	case *ast.IndexExpression:
+		lit, ok := e.Index.(*ast.IntegerLiteral)
+		if !ok {
+			return 0, fmt.Errorf("complex expression")
+		}
+		arr, err := c.lookupArray(e.Left)
+		if err != nil {
+			return 0, err
+		}
+		if arr.width != c.width {
+			return 0, fmt.Errorf("complex expression")
+		}
+		return elementAddr(arr, lit.Value)
 	default:
 		return 0, fmt.Errorf("complex expression")
 	}
 }
 
+// compileIndexExpression loads one array element into ACC. A constant index
+// is resolved at compile time; any other index is evaluated at its own width
+// and bounds-checked at run time by IDX before LDX reads the element.
+func (c *Compiler) compileIndexExpression(expr *ast.IndexExpression) error {
+	arr, err := c.lookupArray(expr.Left)
+	if err != nil {
+		return err
+	}
+	name := expr.Left.(*ast.Identifier).Value // lookupArray accepted it
+	if lit, ok := expr.Index.(*ast.IntegerLiteral); ok {
+		addr, err := elementAddr(arr, lit.Value)
+		if err != nil {
+			return err
+		}
+		return c.emitLoad(name, addr, arr.width)
+	}
+	if arr.width > c.width {
+		return fmt.Errorf("cannot use %s variable %s in %s context", typeName(arr.width), name, typeName(c.width))
+	}
+	if err := c.withWidth(c.exprWidth(expr.Index), expr.Index); err != nil {
+		return err
+	}
+	c.emitExt(xIDX, byte(arr.length))
+	c.setWidth(arr.width)
+	c.emitExt(xLDX, arr.addr)
+	c.setWidth(c.width)
+	return nil
+}
+
 func (c *Compiler) compileInfixExpression(expr *ast.InfixExpression) error {
 	if expr.Operator == "==" || expr.Operator == "!=" {
 		return c.compileEqualityExpression(expr)
@@ -68,17 +132,18 @@ func (c *Compiler) compileInfixExpression(expr *ast.InfixExpression) error {
 		}
 		return c.emitBinaryOp(expr.Operator, rightAddr)
 	}
-	// General case: spill left to tempReg1.
+	// General case: spill left to the first scratch register.
+	t1, t2 := c.temps()
 	if err := c.compileExpression(expr.Left); err != nil {
 		return err
 	}
-	c.emit(bSTORE, tempReg1)
+	c.emit(bSTORE, t1)
 	if err := c.compileExpression(expr.Right); err != nil {
 		return err
 	}
-	c.emit(bSTORE, tempReg2)
-	c.emit(bLOAD, tempReg1)
-	return c.emitBinaryOp(expr.Operator, tempReg2)
+	c.emit(bSTORE, t2)
+	c.emit(bLOAD, t1)
+	return c.emitBinaryOp(expr.Operator, t2)
 }
 
 func (c *Compiler) emitBinaryOp(op string, rightAddr byte) error {
@@ -110,16 +175,17 @@ func (c *Compiler) compileEqualityExpression(expr *ast.InfixExpression) error {
 		}
 		c.emit(bSUB, rightAddr)
 	} else {
+		t1, t2 := c.temps()
 		if err := c.compileExpression(expr.Left); err != nil {
 			return err
 		}
-		c.emit(bSTORE, tempReg1)
+		c.emit(bSTORE, t1)
 		if err := c.compileExpression(expr.Right); err != nil {
 			return err
 		}
-		c.emit(bSTORE, tempReg2)
-		c.emit(bLOAD, tempReg1)
-		c.emit(bSUB, tempReg2)
+		c.emit(bSTORE, t2)
+		c.emit(bLOAD, t1)
+		c.emit(bSUB, t2)
 	}
 
 	c1, err := c.allocConst(1)
@@ -158,16 +224,17 @@ func (c *Compiler) compileComparisonExpression(expr *ast.InfixExpression) error
 		}
 		c.emit(bSUB, rightAddr)
 	} else {
+		t1, t2 := c.temps()
 		if err := c.compileExpression(expr.Left); err != nil {
 			return err
 		}
-		c.emit(bSTORE, tempReg1)
+		c.emit(bSTORE, t1)
 		if err := c.compileExpression(expr.Right); err != nil {
 			return err
 		}
-		c.emit(bSTORE, tempReg2)
-		c.emit(bLOAD, tempReg1)
-		c.emit(bSUB, tempReg2)
+		c.emit(bSTORE, t2)
+		c.emit(bLOAD, t1)
+		c.emit(bSUB, t2)
 	}
 
 	c1, err := c.allocConst(1)
@@ -199,18 +266,29 @@ func (c *Compiler) compileComparisonExpression(expr *ast.InfixExpression) error
 }
 
 func (c *Compiler) compilePrefixExpression(expr *ast.PrefixExpression) error {
+	// A negative literal is one constant, so that the most negative value
+	// of a type, whose magnitude does not fit, can still be written.
+	if lit, ok := expr.Right.(*ast.IntegerLiteral); ok && expr.Operator == "-" {
+		addr, err := c.allocConst(-lit.Value)
+		if err != nil {
+			return err
+		}
+		c.emit(bLOAD, addr)
+		return nil
+	}
 	if err := c.compileExpression(expr.Right); err != nil {
 		return err
 	}
 	switch expr.Operator {
 	case "-":
-		c.emit(bSTORE, tempReg1)
+		t1, _ := c.temps()
+		c.emit(bSTORE, t1)
 		c0, err := c.allocConst(0)
 		if err != nil {
 			return err
 		}
 		c.emit(bLOAD, c0)
-		c.emit(bSUB, tempReg1)
+		c.emit(bSUB, t1)
 	case "!":
 		c1, err := c.allocConst(1)
 		if err != nil {
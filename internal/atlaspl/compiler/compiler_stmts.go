@@ -18,25 +18,109 @@ func (c *Compiler) compileStatement(stmt ast.Statement) error {
 		return c.compileReturnStatement(s)
 	case *ast.ExpressionStatement:
 		return c.compileExpressionStatement(s)
+	case nil:
+		return fmt.Errorf("missing statement")
 	default:
 		return fmt.Errorf("unsupported statement type: %T", stmt)
 	}
 }
 
 func (c *Compiler) compileVarStatement(stmt *ast.VarStatement) error {
-	_, err := c.allocVar(stmt.Name.Value)
-	return err
+	if stmt.Name == nil {
+		return fmt.Errorf("var statement is missing a name")
+	}
+	width, err := typeWidth(stmt.Type)
+	if err != nil {
+		return err
+	}
+	if stmt.Length > 0 {
+		_, err := c.allocArray(stmt.Name.Value, width, stmt.Length)
+		return err
+	}
+	// The initializer is compiled before the name is declared, so it sees
+	// any outer variable the declaration shadows.
+	if stmt.Value != nil {
+		if err := c.withWidth(width, stmt.Value); err != nil {
+			return err
+		}
+	}
+	addr, err := c.allocVar(stmt.Name.Value, width)
+	if err != nil {
+		return err
+	}
+	if stmt.Value != nil {
+		c.emit(bSTORE, addr)
+	}
+	return nil
 }
 
 func (c *Compiler) compileAssignmentStatement(stmt *ast.AssignmentStatement) error {
-	if err := c.compileExpression(stmt.Value); err != nil {
+	if stmt.Name == nil {
+		return fmt.Errorf("assignment is missing a target")
+	}
+	if stmt.Index != nil {
+		return c.compileElementAssignment(stmt)
+	}
+	sym, err := c.lookupVar(stmt.Name.Value)
+	if err != nil {
+		return err
+	}
+	if err := c.withWidth(sym.width, stmt.Value); err != nil {
+		return err
+	}
+	c.emit(bSTORE, sym.addr)
+	return nil
+}
+
+// compileElementAssignment stores into one array element. With a constant
+// index the element address is known at compile time. Otherwise the value is
+// parked in a hidden variable while the index is evaluated, since evaluating
+// it may clobber ACC, the scratch registers and (via nested indexing) IX:
+//
+//	<value>          at the element width
+//	STORE hidden
+//	<index>          at the index's own width
+//	IDX  len
+//	WID  element width
+//	LOAD hidden
+//	STX  base
+func (c *Compiler) compileElementAssignment(stmt *ast.AssignmentStatement) error {
+	arr, err := c.lookupArray(stmt.Name)
+	if err != nil {
 		return err
 	}
-	addr, ok := c.varTable[stmt.Name.Value]
-	if !ok {
-		return fmt.Errorf("undefined variable: %s", stmt.Name.Value)
+	if lit, ok := stmt.Index.(*ast.IntegerLiteral); ok {
+		addr, err := elementAddr(arr, lit.Value)
+		if err != nil {
+			return err
+		}
+		if err := c.withWidth(arr.width, stmt.Value); err != nil {
+			return err
+		}
+		c.emit(bSTORE, addr)
+		return nil
 	}
-	c.emit(bSTORE, addr)
+
+	// The hidden variable lives in a scope of its own so its address is
+	// released as soon as the statement is compiled. Its name cannot clash
+	// with an identifier.
+	c.symbols.enter()
+	defer c.symbols.leave()
+	hidden, err := c.allocVar(" element value", arr.width)
+	if err != nil {
+		return err
+	}
+	if err := c.withWidth(arr.width, stmt.Value); err != nil {
+		return err
+	}
+	c.emit(bSTORE, hidden)
+	if err := c.withWidth(c.exprWidth(stmt.Index), stmt.Index); err != nil {
+		return err
+	}
+	c.emitExt(xIDX, byte(arr.length))
+	c.setWidth(arr.width)
+	c.emit(bLOAD, hidden)
+	c.emitExt(xSTX, arr.addr)
 	return nil
 }
 
@@ -50,7 +134,7 @@ func (c *Compiler) compileAssignmentStatement(stmt *ast.AssignmentStatement) err
 //	<alternative>
 //	[end]:
 func (c *Compiler) compileIfStatement(stmt *ast.IfStatement) error {
-	if err := c.compileExpression(stmt.Condition); err != nil {
+	if err := c.withWidth(c.exprWidth(stmt.Condition), stmt.Condition); err != nil {
 		return err
 	}
 	jzIdx := c.emitJump(bJZ)
@@ -73,7 +157,7 @@ func (c *Compiler) compileIfStatement(stmt *ast.IfStatement) error {
 }
 
 func (c *Compiler) compileReturnStatement(stmt *ast.ReturnStatement) error {
-	if err := c.compileExpression(stmt.ReturnValue); err != nil {
+	if err := c.withWidth(c.exprWidth(stmt.ReturnValue), stmt.ReturnValue); err != nil {
 		return err
 	}
 	c.emit(bOUT, 0)
@@ -82,10 +166,17 @@ func (c *Compiler) compileReturnStatement(stmt *ast.ReturnStatement) error {
 }
 
 func (c *Compiler) compileExpressionStatement(stmt *ast.ExpressionStatement) error {
-	return c.compileExpression(stmt.Expression)
+	return c.withWidth(c.exprWidth(stmt.Expression), stmt.Expression)
 }
 
+// compileBlockStatement compiles block in a scope of its own, so variables
+// declared inside it are invisible afterwards and their addresses are reused.
 func (c *Compiler) compileBlockStatement(block *ast.BlockStatement) error {
+	if block == nil {
+		return fmt.Errorf("missing block")
+	}
+	c.symbols.enter()
+	defer c.symbols.leave()
 	for _, stmt := range block.Statements {
 		if err := c.compileStatement(stmt); err != nil {
 			return err
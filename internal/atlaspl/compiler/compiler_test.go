@@ -130,3 +130,145 @@ var i: int;`
 		t.Error("expected compilation error for too many variables, got nil")
 	}
 }
+
+func compileErr(t *testing.T, src string) error {
+	t.Helper()
+	l := lexer.NewLexer(strings.NewReader(src))
+	p := parser.NewParser(l)
+	prog := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("parse errors: %v", errs)
+	}
+	_, err := compiler.NewCompiler().Compile(prog)
+	return err
+}
+
+func TestCompile_DuplicateDeclarationInSameScope(t *testing.T) {
+	err := compileErr(t, "var x: int; var x: int;")
+	if err == nil || !strings.Contains(err.Error(), "already declared") {
+		t.Errorf("expected duplicate-declaration error, got %v", err)
+	}
+}
+
+func TestCompile_BlockVariableDoesNotLeak(t *testing.T) {
+	err := compileErr(t, "if (1) { var y: int; y = 2; } y = 3;")
+	if err == nil || !strings.Contains(err.Error(), "undefined variable: y") {
+		t.Errorf("expected undefined-variable error, got %v", err)
+	}
+}
+
+func TestCompile_ShadowingUsesNewAddress(t *testing.T) {
+	// x = 1; if (1) { var x; x = 2; } — the inner STORE must not hit outer x.
+	out := compile(t, "var x: int; x = 1; if (1) { var x: int; x = 2; }")
+
+	var stores []byte
+	for _, b := range out.Bytecode {
+		if b>>4 == 0x8 {
+			stores = append(stores, b&0x0F)
+		}
+	}
+	if len(stores) != 2 {
+		t.Fatalf("expected 2 STOREs, got %d", len(stores))
+	}
+	if stores[0] == stores[1] {
+		t.Errorf("inner x shares address 0x%X with outer x", stores[0])
+	}
+}
+
+func TestCompile_SiblingScopesReuseAddresses(t *testing.T) {
+	// Each block alone fills the variable area; without reuse the second
+	// block would run out of addresses.
+	src := `
+	if (1) { var a: int; var b: int; var c: int; var d: int; var e: int; var f: int; }
+	if (1) { var g: int; var h: int; var i: int; var j: int; var k: int; var l: int; }`
+	if err := compileErr(t, src); err != nil {
+		t.Errorf("expected sibling scopes to share addresses, got %v", err)
+	}
+}
+
+func TestCompile_ArrayElementsUseLongForm(t *testing.T) {
+	// xs lives above the 4-bit window, so xs[1] = 5 needs a long-form STORE.
+	out := compile(t, "var xs: [4]int; xs[1] = 5;")
+
+	// LOAD <const5>, STORE.L <xs+1>, HALT
+	if len(out.Bytecode) != 4 {
+		t.Fatalf("expected 4 bytes, got % X", out.Bytecode)
+	}
+	if out.Bytecode[1] != 0xF8 {
+		t.Errorf("expected long-form STORE prefix 0xF8, got 0x%02X", out.Bytecode[1])
+	}
+	if out.Bytecode[2] < 0x10 {
+		t.Errorf("expected element address above 0x0F, got 0x%02X", out.Bytecode[2])
+	}
+}
+
+func TestCompile_ArraysOutgrowingDataArea(t *testing.T) {
+	err := compileErr(t, "var xs: [200]int; var ys: [100]int;")
+	if err == nil || !strings.Contains(err.Error(), "does not fit") {
+		t.Errorf("expected array-space error, got %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Sized integer types
+// ---------------------------------------------------------------------------
+
+func TestCompile_LiteralTooLargeForType(t *testing.T) {
+	for _, src := range []string{
+		"var x: int = 300;",
+		"var x: int = 128;",
+		"var x: int = 200;",
+		"var x: int = -129;",
+		"var x: int; x = 300;",
+		"var x: int16 = 32768;",
+		"var x: int16 = 70000;",
+		"var x: int32 = 5000000000;",
+	} {
+		err := compileErr(t, src)
+		if err == nil || !strings.Contains(err.Error(), "does not fit") {
+			t.Errorf("%s: expected does-not-fit error, got %v", src, err)
+		}
+	}
+}
+
+func TestCompile_LiteralsAtTheEdgesOfTheirType(t *testing.T) {
+	for _, src := range []string{
+		"var x: int = 127;",
+		"var x: int = -128;",
+		"var x: int16 = 32767;",
+		"var x: int16 = -32768;",
+	} {
+		if err := compileErr(t, src); err != nil {
+			t.Errorf("%s: %v", src, err)
+		}
+	}
+}
+
+func TestCompile_WideConstantStoredLittleEndian(t *testing.T) {
+	out := compile(t, "var x: int16 = 300;")
+
+	// 300 = 0x012C must appear as 2C 01 at consecutive addresses.
+	found := false
+	for addr, v := range out.InitialData {
+		if v == 0x2C && out.InitialData[addr+1] == 0x01 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected 2C 01 in initial data, got %v", out.InitialData)
+	}
+}
+
+func TestCompile_WiderVariableInNarrowContext(t *testing.T) {
+	err := compileErr(t, "var big: int32; var small: int; small = big;")
+	if err == nil || !strings.Contains(err.Error(), "cannot use int32 variable big in int context") {
+		t.Errorf("expected narrowing error, got %v", err)
+	}
+}
+
+func TestCompile_UnknownType(t *testing.T) {
+	err := compileErr(t, "var x: int64;")
+	if err == nil || !strings.Contains(err.Error(), "unknown type: int64") {
+		t.Errorf("expected unknown-type error, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package compiler_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/ast"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/compiler"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/lexer"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+)
+
+// fuzzStepBudget bounds the execution of each successfully compiled program.
+const fuzzStepBudget = 4096
+
+// FuzzCompile builds a random AST from the fuzz input and compiles it.
+// Compilation may fail, but must not panic; programs that do compile are run
+// on a VM under a step budget, which must not panic either.
+func FuzzCompile(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{3, 0, 0, 1, 0, 1, 0, 5, 3, 1, 0})
+	f.Add([]byte{4, 2, 2, 0, 1, 3, 1, 2, 1, 1, 1, 4, 0})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		g := &astGen{data: data}
+		prog := g.program()
+
+		out, err := compiler.NewCompiler().Compile(prog)
+		if err != nil {
+			return
+		}
+		v := vm.NewVM(bytes.NewReader(nil), io.Discard)
+		if err := v.LoadProgram(out.Bytecode); err != nil {
+			return
+		}
+		v.LoadData(out.InitialData)
+		var fault *vm.Fault
+		if err := v.RunSteps(fuzzStepBudget); err != nil && !errors.As(err, &fault) {
+			t.Fatalf("RunSteps returned a non-fault error: %v", err)
+		}
+	})
+}
+
+// astGen derives an AST from a byte string, consuming one byte per decision.
+// Once the input runs out every decision reads as zero, which always picks
+// a leaf, so generation terminates. Some choices deliberately produce nil
+// children, mirroring the partial nodes the parser leaves behind on errors.
+type astGen struct {
+	data []byte
+}
+
+const maxGenDepth = 4
+
+var (
+	genNames     = []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	genInfixOps  = []string{"+", "-", "*", "/", "&", "|", "==", "!=", "<", ">", "<=", ">=", "^"}
+	genPrefixOps = []string{"-", "!", "~"}
+)
+
+func (g *astGen) next() byte {
+	if len(g.data) == 0 {
+		return 0
+	}
+	b := g.data[0]
+	g.data = g.data[1:]
+	return b
+}
+
+func (g *astGen) program() *ast.Program {
+	prog := &ast.Program{}
+	for n := int(g.next() % 12); n > 0; n-- {
+		prog.Statements = append(prog.Statements, g.statement(0))
+	}
+	return prog
+}
+
+func (g *astGen) block(depth int) *ast.BlockStatement {
+	if g.next()%8 == 7 {
+		return nil
+	}
+	block := &ast.BlockStatement{Token: lexer.Token{Type: lexer.LBRACE, Literal: "{"}}
+	for n := int(g.next() % 4); n > 0; n-- {
+		block.Statements = append(block.Statements, g.statement(depth+1))
+	}
+	return block
+}
+
+func (g *astGen) statement(depth int) ast.Statement {
+	choice := g.next() % 6
+	if depth >= maxGenDepth && choice == 2 {
+		choice = 0
+	}
+	switch choice {
+	case 0:
+		stmt := &ast.VarStatement{
+			Token: lexer.Token{Type: lexer.VAR, Literal: "var"},
+			Name:  g.ident(),
+			Type:  [...]string{"int", "int16", "int32", "int"}[g.next()%4],
+		}
+		if b := g.next(); b >= 0xC0 {
+			stmt.Length = int64(b - 0xBF)
+		} else if b >= 0x80 {
+			stmt.Value = g.expression(depth + 1)
+		}
+		return stmt
+	case 1:
+		stmt := &ast.AssignmentStatement{
+			Token: lexer.Token{Type: lexer.EQUAL, Literal: "="},
+			Name:  g.ident(),
+			Value: g.expression(depth),
+		}
+		if g.next()%4 == 3 {
+			stmt.Index = g.expression(depth + 1)
+		}
+		return stmt
+	case 2:
+		return &ast.IfStatement{
+			Token:       lexer.Token{Type: lexer.IF, Literal: "if"},
+			Condition:   g.expression(depth),
+			Consequence: g.block(depth),
+			Alternative: g.block(depth),
+		}
+	case 3:
+		return &ast.ReturnStatement{
+			Token:       lexer.Token{Type: lexer.RETURN, Literal: "return"},
+			ReturnValue: g.expression(depth),
+		}
+	case 4:
+		return &ast.ExpressionStatement{Expression: g.expression(depth)}
+	default:
+		if block := g.block(depth); block != nil {
+			return block
+		}
+		return nil
+	}
+}
+
+func (g *astGen) ident() *ast.Identifier {
+	b := g.next()
+	if b == 0xFF {
+		return nil
+	}
+	name := genNames[int(b)%len(genNames)]
+	return &ast.Identifier{Token: lexer.Token{Type: lexer.IDENT, Literal: name}, Value: name}
+}
+
+func (g *astGen) expression(depth int) ast.Expression {
+	choice := g.next() % 7
+	if depth >= maxGenDepth {
+		choice %= 3
+	}
+	switch choice {
+	case 0:
+		v := int64(int8(g.next()))
+		return &ast.IntegerLiteral{Token: lexer.Token{Type: lexer.INT}, Value: v}
+	case 1:
+		if id := g.ident(); id != nil {
+			return id
+		}
+		return nil
+	case 2:
+		return &ast.BooleanLiteral{Token: lexer.Token{Type: lexer.TRUE, Literal: "true"}, Value: g.next()%2 == 0}
+	case 3, 4:
+		op := genInfixOps[int(g.next())%len(genInfixOps)]
+		return &ast.InfixExpression{
+			Token:    lexer.Token{Literal: op},
+			Left:     g.expression(depth + 1),
+			Operator: op,
+			Right:    g.expression(depth + 1),
+		}
+	case 5:
+		return &ast.IndexExpression{
+			Token: lexer.Token{Type: lexer.LBRACKET, Literal: "["},
+			Left:  g.expression(depth + 1),
+			Index: g.expression(depth + 1),
+		}
+	default:
+		op := genPrefixOps[int(g.next())%len(genPrefixOps)]
+		return &ast.PrefixExpression{
+			Token:    lexer.Token{Literal: op},
+			Operator: op,
+			Right:    g.expression(depth + 1),
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package compiler
+
+import "fmt"
+
+// symbol is a declared variable: a scalar at addr, or an array whose length
+// elements are stored contiguously from addr.
+type symbol struct {
+	addr   byte
+	width  byte  // bytes per value: 1 for int, 2 for int16, 4 for int32
+	length int64 // 0 for scalars
+}
+
+func (s symbol) isArray() bool { return s.length > 0 }
+
+// scope holds the variables declared directly inside one lexical block.
+type scope struct {
+	parent   *scope
+	vars     map[string]symbol
+	mark     byte // symbolTable.next when the scope was entered
+	highMark int  // symbolTable.nextHigh when the scope was entered
+}
+
+// symbolTable resolves variable names through a chain of nested scopes.
+//
+// Addresses are handed out like a stack: a scope's variables sit above those
+// of every enclosing scope, and leaving a scope releases its addresses for
+// the next sibling block. A reused address keeps whatever value the dead
+// variable left in it, the same as any uninitialised variable.
+//
+// int scalars live in the low area, which one-byte instructions can reach.
+// Arrays and int16/int32 scalars live in the high area, which grows upward
+// towards data claimed from its top for the whole program (see claimHigh).
+type symbolTable struct {
+	current   *scope
+	next      byte // next free low-area address
+	limit     byte // first address past the low area
+	nextHigh  int  // next free high-area address
+	highLimit int  // first address past the free part of the high area
+}
+
+func newSymbolTable(base, limit byte, highBase, highLimit int) *symbolTable {
+	t := &symbolTable{next: base, limit: limit, nextHigh: highBase, highLimit: highLimit}
+	t.enter()
+	return t
+}
+
+// enter opens a new innermost scope.
+func (t *symbolTable) enter() {
+	t.current = &scope{
+		parent:   t.current,
+		vars:     make(map[string]symbol),
+		mark:     t.next,
+		highMark: t.nextHigh,
+	}
+}
+
+// leave closes the innermost scope and frees the addresses it allocated.
+func (t *symbolTable) leave() {
+	t.next = t.current.mark
+	t.nextHigh = t.current.highMark
+	t.current = t.current.parent
+}
+
+// declare allocates an address for the scalar name in the innermost scope.
+// Declaring a name that an enclosing scope already holds shadows it;
+// declaring it twice in the same scope is an error.
+func (t *symbolTable) declare(name string, width byte) (byte, error) {
+	if err := t.checkRedeclared(name); err != nil {
+		return 0, err
+	}
+	if width > 1 {
+		addr, ok := t.allocHigh(int(width))
+		if !ok {
+			return 0, fmt.Errorf("variable %s does not fit: %d bytes of data space left",
+				name, t.highLimit-t.nextHigh)
+		}
+		t.current.vars[name] = symbol{addr: addr, width: width}
+		return addr, nil
+	}
+	if t.next >= t.limit {
+		return 0, fmt.Errorf("too many variables: maximum is %d", maxUserVars)
+	}
+	addr := t.next
+	t.current.vars[name] = symbol{addr: addr, width: width}
+	t.next++
+	return addr, nil
+}
+
+// declareArray allocates length contiguous values of the given width for
+// the array name in the innermost scope, with the same shadowing rules as
+// declare.
+func (t *symbolTable) declareArray(name string, width byte, length int64) (byte, error) {
+	if err := t.checkRedeclared(name); err != nil {
+		return 0, err
+	}
+	if length <= 0 || length > int64(t.highLimit-t.nextHigh)/int64(width) {
+		return 0, fmt.Errorf("array %s does not fit: %d bytes of array space left",
+			name, t.highLimit-t.nextHigh)
+	}
+	addr, _ := t.allocHigh(int(length) * int(width))
+	t.current.vars[name] = symbol{addr: addr, width: width, length: length}
+	return addr, nil
+}
+
+func (t *symbolTable) allocHigh(size int) (byte, bool) {
+	if t.nextHigh+size > t.highLimit {
+		return 0, false
+	}
+	addr := byte(t.nextHigh)
+	t.nextHigh += size
+	return addr, true
+}
+
+// claimHigh takes size bytes from the top of the high area for data that
+// lives as long as the program, such as wide constants. Variables can no
+// longer be allocated there afterwards.
+func (t *symbolTable) claimHigh(size int) (byte, bool) {
+	if t.highLimit-size < t.nextHigh {
+		return 0, false
+	}
+	t.highLimit -= size
+	return byte(t.highLimit), true
+}
+
+func (t *symbolTable) checkRedeclared(name string) error {
+	if _, ok := t.current.vars[name]; ok {
+		return fmt.Errorf("variable %s already declared in this scope", name)
+	}
+	return nil
+}
+
+// resolve finds the innermost visible declaration of name.
+func (t *symbolTable) resolve(name string) (symbol, bool) {
+	for s := t.current; s != nil; s = s.parent {
+		if sym, ok := s.vars[name]; ok {
+			return sym, true
+		}
+	}
+	return symbol{}, false
+}
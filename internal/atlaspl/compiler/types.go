@@ -0,0 +1,75 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/ast"
+)
+
+// maxProgramSize is the number of code bytes the 8-bit PC can address.
+const maxProgramSize = 256
+
+// typeWidth returns the width in bytes of an AtlasPL integer type.
+func typeWidth(name string) (byte, error) {
+	switch name {
+	case "int":
+		return 1, nil
+	case "int16":
+		return 2, nil
+	case "int32":
+		return 4, nil
+	default:
+		return 0, fmt.Errorf("unknown type: %s", name)
+	}
+}
+
+// typeName is the inverse of typeWidth, used in error messages.
+func typeName(width byte) string {
+	switch width {
+	case 2:
+		return "int16"
+	case 4:
+		return "int32"
+	default:
+		return "int"
+	}
+}
+
+// fitsWidth reports whether a literal is in the range of the signed type
+// width bytes wide, so that storing it does not change its value.
+func fitsWidth(val int64, width byte) bool {
+	bits := 8 * uint(width)
+	return val >= -(1<<(bits-1)) && val < 1<<(bits-1)
+}
+
+// exprWidth returns the width an expression is evaluated at when nothing
+// else decides it: that of its widest variable, or int if it has none.
+// Unknown names count as int; compiling them reports the error.
+func (c *Compiler) exprWidth(expr ast.Expression) byte {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		if sym, ok := c.symbols.resolve(e.Value); ok {
+			return sym.width
+		}
+	case *ast.IndexExpression:
+		if ident, ok := e.Left.(*ast.Identifier); ok {
+			if sym, ok := c.symbols.resolve(ident.Value); ok {
+				return sym.width
+			}
+		}
+	case *ast.InfixExpression:
+		return max(c.exprWidth(e.Left), c.exprWidth(e.Right))
+	case *ast.PrefixExpression:
+		return c.exprWidth(e.Right)
+	}
+	return 1
+}
+
+// withWidth compiles expr at the given width, leaving its value in ACC.
+func (c *Compiler) withWidth(width byte, expr ast.Expression) error {
+	saved := c.width
+	c.width = width
+	defer func() { c.width = saved }()
+	c.setWidth(width)
+	return c.compileExpression(expr)
+}
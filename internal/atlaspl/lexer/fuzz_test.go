@@ -0,0 +1,29 @@
+package lexer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/lexer"
+)
+
+// FuzzNextToken feeds arbitrary bytes to the lexer. Every call to NextToken
+// consumes at least one byte, so reaching EOF must take at most len(src)+1
+// tokens; anything more means the lexer is stuck.
+func FuzzNextToken(f *testing.F) {
+	f.Add("var number: int;\nnumber = 10;")
+	f.Add("if ((a & 1) == 0) { return (0); } else { return (1); }")
+	f.Add("@ comment only")
+	f.Add("!= <= >= == ! < > = & | + - * /")
+	f.Add("\x00var")
+
+	f.Fuzz(func(t *testing.T, src string) {
+		l := lexer.NewLexer(strings.NewReader(src))
+		for i := 0; i <= len(src)+1; i++ {
+			if l.NextToken().Type == lexer.EOF {
+				return
+			}
+		}
+		t.Fatalf("lexer did not reach EOF within %d tokens", len(src)+1)
+	})
+}
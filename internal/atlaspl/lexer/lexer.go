@@ -25,6 +25,8 @@ const (
 	RPAREN
 	LBRACE
 	RBRACE
+	LBRACKET
+	RBRACKET
 	PLUS
 	MINUS
 	ASTERISK
@@ -60,6 +62,8 @@ var tokenNames = [...]string{
 	RPAREN:    "RPAREN",
 	LBRACE:    "LBRACE",
 	RBRACE:    "RBRACE",
+	LBRACKET:  "LBRACKET",
+	RBRACKET:  "RBRACKET",
 	PLUS:      "PLUS",
 	MINUS:     "MINUS",
 	ASTERISK:  "ASTERISK",
@@ -130,6 +134,10 @@ func (l *Lexer) NextToken() Token {
 		return Token{Type: LBRACE, Literal: "{"}
 	case ch == '}':
 		return Token{Type: RBRACE, Literal: "}"}
+	case ch == '[':
+		return Token{Type: LBRACKET, Literal: "["}
+	case ch == ']':
+		return Token{Type: RBRACKET, Literal: "]"}
 	case ch == ':':
 		return Token{Type: COLON, Literal: ":"}
 	case ch == '+':
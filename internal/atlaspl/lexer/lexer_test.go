@@ -42,6 +42,29 @@ func TestNextToken_Operators(t *testing.T) {
 	assertTokens(t, input, want)
 }
 
+func TestNextToken_Brackets(t *testing.T) {
+	input := "var xs: [8]int; xs[i] = 1;"
+	want := []lexer.Token{
+		{Type: lexer.VAR, Literal: "var"},
+		{Type: lexer.IDENT, Literal: "xs"},
+		{Type: lexer.COLON, Literal: ":"},
+		{Type: lexer.LBRACKET, Literal: "["},
+		{Type: lexer.INT, Literal: "8"},
+		{Type: lexer.RBRACKET, Literal: "]"},
+		{Type: lexer.IDENT, Literal: "int"},
+		{Type: lexer.SEMICOLON, Literal: ";"},
+		{Type: lexer.IDENT, Literal: "xs"},
+		{Type: lexer.LBRACKET, Literal: "["},
+		{Type: lexer.IDENT, Literal: "i"},
+		{Type: lexer.RBRACKET, Literal: "]"},
+		{Type: lexer.EQUAL, Literal: "="},
+		{Type: lexer.INT, Literal: "1"},
+		{Type: lexer.SEMICOLON, Literal: ";"},
+		{Type: lexer.EOF},
+	}
+	assertTokens(t, input, want)
+}
+
 func TestNextToken_Comment(t *testing.T) {
 	// Comments start with @ and run to end of line — they should be emitted
 	// as a COMMENT token (the parser skips them, but the lexer produces them).
@@ -0,0 +1,62 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/ast"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/lexer"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl/parser"
+)
+
+// FuzzParseProgram checks that the parser terminates without panicking on
+// arbitrary input, and that it never hands back nil statements: callers
+// walk the AST without nil checks.
+func FuzzParseProgram(f *testing.F) {
+	f.Add("var x: int; x = 42;")
+	f.Add("if (x == 0) { return (1); } else { return (0); }")
+	f.Add("2 + 3 * 4;")
+	f.Add("@ comment\nvar x: int;")
+
+	f.Fuzz(func(t *testing.T, src string) {
+		p := parser.NewParser(lexer.NewLexer(strings.NewReader(src)))
+		prog := p.ParseProgram()
+		checkStatements(t, prog.Statements)
+	})
+}
+
+func checkStatements(t *testing.T, stmts []ast.Statement) {
+	t.Helper()
+	for i, stmt := range stmts {
+		switch s := stmt.(type) {
+		case nil:
+			t.Fatalf("statement %d is nil", i)
+		case *ast.VarStatement:
+			if s == nil {
+				t.Fatalf("statement %d is a nil *ast.VarStatement", i)
+			}
+		case *ast.AssignmentStatement:
+			if s == nil {
+				t.Fatalf("statement %d is a nil *ast.AssignmentStatement", i)
+			}
+		case *ast.ReturnStatement:
+			if s == nil {
+				t.Fatalf("statement %d is a nil *ast.ReturnStatement", i)
+			}
+		case *ast.ExpressionStatement:
+			if s == nil {
+				t.Fatalf("statement %d is a nil *ast.ExpressionStatement", i)
+			}
+		case *ast.IfStatement:
+			if s == nil {
+				t.Fatalf("statement %d is a nil *ast.IfStatement", i)
+			}
+			if s.Consequence != nil {
+				checkStatements(t, s.Consequence.Statements)
+			}
+			if s.Alternative != nil {
+				checkStatements(t, s.Alternative.Statements)
+			}
+		}
+	}
+}
@@ -17,6 +17,7 @@ const (
 	PRODUCT     // *
 	PREFIX      // -X or !X
 	CALL        // myFunction(X)
+	INDEX       // array[index]
 )
 
 var precedences = map[lexer.TokenType]int{
@@ -30,6 +31,7 @@ var precedences = map[lexer.TokenType]int{
 	lexer.ASTERISK: PRODUCT,
 	lexer.AND:      PRODUCT,
 	lexer.OR:       SUM,
+	lexer.LBRACKET: INDEX,
 }
 
 type (
@@ -74,6 +76,7 @@ func NewParser(l *lexer.Lexer) *Parser {
 	p.registerInfix(lexer.GT, p.parseInfixExpression)
 	p.registerInfix(lexer.AND, p.parseInfixExpression)
 	p.registerInfix(lexer.OR, p.parseInfixExpression)
+	p.registerInfix(lexer.LBRACKET, p.parseIndexExpression)
 
 	p.nextToken()
 	p.nextToken()
@@ -121,22 +124,36 @@ func (p *Parser) Errors() []string { return p.errors }
 // Statement parsing
 // ---------------------------------------------------------------------------
 
+// parseStatement returns nil when the statement could not be parsed.
+// The typed parse functions return nil pointers on error, so each result is
+// checked here to avoid handing callers a non-nil interface wrapping nil.
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case lexer.VAR:
-		return p.parseVarStatement()
+		if stmt := p.parseVarStatement(); stmt != nil {
+			return stmt
+		}
 	case lexer.RETURN:
 		return p.parseReturnStatement()
 	case lexer.IF:
-		return p.parseIfStatement()
+		if stmt := p.parseIfStatement(); stmt != nil {
+			return stmt
+		}
 	case lexer.IDENT:
 		if p.peekTokenIs(lexer.EQUAL) {
-			return p.parseAssignmentStatement()
+			if stmt := p.parseAssignmentStatement(); stmt != nil {
+				return stmt
+			}
+			return nil
 		}
-		fallthrough
+		if p.peekTokenIs(lexer.LBRACKET) {
+			return p.parseIndexedStatement()
+		}
+		return p.parseExpressionStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
+	return nil
 }
 
 func (p *Parser) parseVarStatement() *ast.VarStatement {
@@ -150,11 +167,34 @@ func (p *Parser) parseVarStatement() *ast.VarStatement {
 	if !p.expectPeek(lexer.COLON) {
 		return nil
 	}
+	if p.peekTokenIs(lexer.LBRACKET) {
+		// Array type: [N]elem
+		p.nextToken()
+		if !p.expectPeek(lexer.INT) {
+			return nil
+		}
+		length, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+		if err != nil || length <= 0 {
+			p.errors = append(p.errors, fmt.Sprintf("invalid array length %q", p.curToken.Literal))
+			return nil
+		}
+		stmt.Length = length
+		if !p.expectPeek(lexer.RBRACKET) {
+			return nil
+		}
+	}
 	if !p.expectPeek(lexer.IDENT) {
 		return nil
 	}
 	stmt.Type = p.curToken.Literal
 
+	// Optional initializer: var x: T = expr;
+	if stmt.Length == 0 && p.peekTokenIs(lexer.EQUAL) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Value = p.parseExpression(LOWEST)
+	}
+
 	if p.peekTokenIs(lexer.SEMICOLON) {
 		p.nextToken()
 	}
@@ -236,6 +276,37 @@ func (p *Parser) parseAssignmentStatement() *ast.AssignmentStatement {
 	return stmt
 }
 
+// parseIndexedStatement handles statements that start with `name[`. They are
+// either element assignments (xs[i] = v;) or ordinary expression statements.
+func (p *Parser) parseIndexedStatement() ast.Statement {
+	tok := p.curToken
+	expr := p.parseExpression(LOWEST)
+
+	index, ok := expr.(*ast.IndexExpression)
+	if !ok || !p.peekTokenIs(lexer.EQUAL) {
+		stmt := &ast.ExpressionStatement{Token: tok, Expression: expr}
+		if p.peekTokenIs(lexer.SEMICOLON) {
+			p.nextToken()
+		}
+		return stmt
+	}
+	name, ok := index.Left.(*ast.Identifier)
+	if !ok {
+		p.errors = append(p.errors, "only elements of a named array can be assigned")
+		return nil
+	}
+
+	stmt := &ast.AssignmentStatement{Token: tok, Name: name, Index: index.Index}
+	p.nextToken()
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(lexer.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
 // ---------------------------------------------------------------------------
 // Expression parsing
 // ---------------------------------------------------------------------------
@@ -297,6 +368,16 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expr
 }
 
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	expr := &ast.IndexExpression{Token: p.curToken, Left: left}
+	p.nextToken()
+	expr.Index = p.parseExpression(LOWEST)
+	if !p.expectPeek(lexer.RBRACKET) {
+		return nil
+	}
+	return expr
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
 	p.nextToken()
 	exp := p.parseExpression(LOWEST)
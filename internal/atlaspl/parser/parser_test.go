@@ -40,6 +40,61 @@ func TestParseVarStatement(t *testing.T) {
 	}
 }
 
+func TestParseVarStatementWithInitializer(t *testing.T) {
+	prog := parse(t, "var x: int16 = 2 * y;")
+	vs, ok := prog.Statements[0].(*ast.VarStatement)
+	if !ok {
+		t.Fatalf("expected *ast.VarStatement, got %T", prog.Statements[0])
+	}
+	if vs.Type != "int16" {
+		t.Errorf("expected type 'int16', got %q", vs.Type)
+	}
+	if _, ok := vs.Value.(*ast.InfixExpression); !ok {
+		t.Errorf("expected infix initializer, got %T", vs.Value)
+	}
+}
+
+func TestParseArrayDeclaration(t *testing.T) {
+	prog := parse(t, "var xs: [8]int;")
+	vs, ok := prog.Statements[0].(*ast.VarStatement)
+	if !ok {
+		t.Fatalf("expected *ast.VarStatement, got %T", prog.Statements[0])
+	}
+	if vs.Length != 8 || vs.Type != "int" {
+		t.Errorf("expected [8]int, got [%d]%s", vs.Length, vs.Type)
+	}
+}
+
+func TestParseElementAssignment(t *testing.T) {
+	prog := parse(t, "xs[i + 1] = xs[i];")
+	as, ok := prog.Statements[0].(*ast.AssignmentStatement)
+	if !ok {
+		t.Fatalf("expected *ast.AssignmentStatement, got %T", prog.Statements[0])
+	}
+	if as.Name.Value != "xs" {
+		t.Errorf("expected name 'xs', got %q", as.Name.Value)
+	}
+	if _, ok := as.Index.(*ast.InfixExpression); !ok {
+		t.Errorf("expected index to be *ast.InfixExpression, got %T", as.Index)
+	}
+	if _, ok := as.Value.(*ast.IndexExpression); !ok {
+		t.Errorf("expected value to be *ast.IndexExpression, got %T", as.Value)
+	}
+}
+
+func TestParseIndexPrecedence(t *testing.T) {
+	// xs[0] * 2 must index before multiplying.
+	prog := parse(t, "xs[0] * 2;")
+	es := prog.Statements[0].(*ast.ExpressionStatement)
+	mul, ok := es.Expression.(*ast.InfixExpression)
+	if !ok {
+		t.Fatalf("expected *ast.InfixExpression, got %T", es.Expression)
+	}
+	if _, ok := mul.Left.(*ast.IndexExpression); !ok {
+		t.Errorf("expected left to be *ast.IndexExpression, got %T", mul.Left)
+	}
+}
+
 func TestParseAssignmentStatement(t *testing.T) {
 	prog := parse(t, "x = 42;")
 	if len(prog.Statements) != 1 {
@@ -0,0 +1,233 @@
+// Package conformance runs AtlasPL programs through the full pipeline and
+// compares what they do against golden files stored next to the source.
+//
+// For a program foo.atlas the runner looks for:
+//
+//	foo.expected  exact VM output (what OUT printed)
+//	foo.input     optional input tape fed to IN
+//	foo.err       expected diagnostic when the program must not succeed
+//
+// A program is a test case when it has a .expected or a .err file.
+package conformance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+)
+
+// DefaultStepLimit bounds every program run so a looping case fails instead
+// of hanging the suite.
+const DefaultStepLimit = 100000
+
+// Case is one .atlas program together with its golden files.
+type Case struct {
+	Name   string // source path without the .atlas extension
+	Source string
+}
+
+func (c Case) expectedPath() string { return c.Name + ".expected" }
+func (c Case) inputPath() string    { return c.Name + ".input" }
+func (c Case) errPath() string      { return c.Name + ".err" }
+
+// Options controls how cases are run.
+type Options struct {
+	// Update rewrites the golden files from the observed behaviour instead
+	// of comparing against them.
+	Update bool
+
+	// StepLimit caps VM execution; zero means DefaultStepLimit.
+	StepLimit int
+}
+
+// Result is the outcome of running one case.
+type Result struct {
+	Case       Case
+	Output     string // everything the program printed
+	Diagnostic string // parse, compile or runtime error, empty on success
+	Diff       string // empty when the case passed
+	Updated    bool   // golden files were rewritten
+}
+
+// Passed reports whether the observed behaviour matched the golden files.
+func (r Result) Passed() bool { return r.Diff == "" }
+
+// Discover walks each directory and returns every .atlas file that has a
+// .expected or .err golden file, sorted by path.
+func Discover(dirs ...string) ([]Case, error) {
+	return discover(dirs, func(c Case) bool {
+		return fileExists(c.expectedPath()) || fileExists(c.errPath())
+	})
+}
+
+// DiscoverAll is like Discover but also returns .atlas files that have no
+// golden files yet. It is used with Options.Update to bootstrap new cases.
+func DiscoverAll(dirs ...string) ([]Case, error) {
+	return discover(dirs, func(Case) bool { return true })
+}
+
+func discover(dirs []string, keep func(Case) bool) ([]Case, error) {
+	var cases []Case
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || filepath.Ext(path) != ".atlas" {
+				return nil
+			}
+			c := Case{Name: strings.TrimSuffix(path, ".atlas"), Source: path}
+			if keep(c) {
+				cases = append(cases, c)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Source < cases[j].Source })
+	return cases, nil
+}
+
+// Run executes c and compares the result against its golden files.
+// An error is returned only when the case itself cannot be read or updated.
+func Run(c Case, opts Options) (Result, error) {
+	res := Result{Case: c}
+
+	src, err := os.ReadFile(c.Source)
+	if err != nil {
+		return res, err
+	}
+	input, err := readOptional(c.inputPath())
+	if err != nil {
+		return res, err
+	}
+	res.Output, res.Diagnostic = execute(src, input, opts.stepLimit())
+
+	if opts.Update {
+		if err := res.update(); err != nil {
+			return res, err
+		}
+		res.Updated = true
+		return res, nil
+	}
+
+	wantOut, err := readOptional(c.expectedPath())
+	if err != nil {
+		return res, err
+	}
+	wantErr, err := readOptional(c.errPath())
+	if err != nil {
+		return res, err
+	}
+
+	var diff strings.Builder
+	if fileExists(c.expectedPath()) || res.Output != "" {
+		if d := Diff(string(wantOut), res.Output); d != "" {
+			fmt.Fprintf(&diff, "output mismatch (%s):\n%s", c.expectedPath(), d)
+		}
+	}
+	if d := Diff(normalize(string(wantErr)), normalize(res.Diagnostic)); d != "" {
+		fmt.Fprintf(&diff, "diagnostic mismatch (%s):\n%s", c.errPath(), d)
+	}
+	res.Diff = diff.String()
+	return res, nil
+}
+
+// RunAll runs every case and returns the results in order.
+func RunAll(cases []Case, opts Options) ([]Result, error) {
+	results := make([]Result, 0, len(cases))
+	for _, c := range cases {
+		res, err := Run(c, opts)
+		if err != nil {
+			return results, fmt.Errorf("%s: %w", c.Source, err)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func (o Options) stepLimit() int {
+	if o.StepLimit > 0 {
+		return o.StepLimit
+	}
+	return DefaultStepLimit
+}
+
+// execute runs src through lexer, parser, compiler and VM, returning what
+// the program printed and a one-line diagnostic per failure.
+func execute(src, input []byte, stepLimit int) (output, diagnostic string) {
+	compiled, err := atlaspl.Compile(bytes.NewReader(src))
+	if err != nil {
+		var perr *atlaspl.ParseError
+		if errors.As(err, &perr) {
+			var b strings.Builder
+			for _, e := range perr.Errors {
+				fmt.Fprintf(&b, "parse error: %s\n", e)
+			}
+			return "", b.String()
+		}
+		return "", fmt.Sprintf("compile error: %v\n", err)
+	}
+
+	var out bytes.Buffer
+	machine := vm.NewVM(bytes.NewReader(input), &out)
+	if err := machine.LoadProgram(compiled.Bytecode); err != nil {
+		return "", fmt.Sprintf("load error: %v\n", err)
+	}
+	machine.LoadData(compiled.InitialData)
+	if err := machine.RunSteps(stepLimit); err != nil {
+		return out.String(), fmt.Sprintf("runtime error: %v\n", err)
+	}
+	return out.String(), ""
+}
+
+// update rewrites the golden files to match r. A .err file is kept only
+// while the program fails, and a .expected file only while it prints
+// something or has no diagnostic.
+func (r Result) update() error {
+	if r.Diagnostic == "" || r.Output != "" {
+		if err := os.WriteFile(r.Case.expectedPath(), []byte(r.Output), 0o644); err != nil {
+			return err
+		}
+	} else if err := removeIfExists(r.Case.expectedPath()); err != nil {
+		return err
+	}
+	if r.Diagnostic != "" {
+		return os.WriteFile(r.Case.errPath(), []byte(r.Diagnostic), 0o644)
+	}
+	return removeIfExists(r.Case.errPath())
+}
+
+func normalize(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n"))
+}
+
+func readOptional(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return b, err
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,53 @@
+package conformance_test
+
+import (
+	"flag"
+	"path/filepath"
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/conformance"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files from the observed behaviour")
+
+// suiteDirs holds the example programs and the language-feature cases.
+var suiteDirs = []string{
+	filepath.Join("..", "..", "examples"),
+	"testdata",
+}
+
+func TestConformance(t *testing.T) {
+	discover := conformance.Discover
+	if *update {
+		discover = conformance.DiscoverAll
+	}
+	cases, err := discover(suiteDirs...)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	if len(cases) == 0 {
+		t.Fatal("no conformance cases found")
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			res, err := conformance.Run(c, conformance.Options{Update: *update})
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if !res.Passed() {
+				t.Errorf("%s", res.Diff)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if d := conformance.Diff("7\n", "7\n"); d != "" {
+		t.Errorf("identical input: want empty diff, got %q", d)
+	}
+	want := "  1\n- 2\n+ 3\n"
+	if d := conformance.Diff("1\n2\n", "1\n3\n"); d != want {
+		t.Errorf("want %q, got %q", want, d)
+	}
+}
@@ -0,0 +1,328 @@
+// Package metrics counts what a process does and exposes the counts in the
+// Prometheus text format.
+//
+// Metrics belong to a Registry and come in families: a Counter or
+// Histogram with a name, help text and label names, holding one series per
+// combination of label values. Everything is safe for concurrent use, and
+// a nil Registry, Counter, Histogram or Series does nothing, so code can
+// count unconditionally whether or not anyone collects its metrics.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry holds metric families by name.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+}
+
+// family is a Counter or a Histogram.
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+// register returns the family called name, creating it with create if
+// there is none. It panics if name is taken by a family of another kind.
+func register[F family](r *Registry, name string, create func() F) F {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		existing, ok := f.(F)
+		if !ok {
+			panic(fmt.Sprintf("metrics: %s is already registered as a %T", name, f))
+		}
+		return existing
+	}
+	f := create()
+	r.families[name] = f
+	return f
+}
+
+// WriteText writes every family in the Prometheus text exposition format,
+// in name order.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := make([]family, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		families[i] = r.families[name]
+	}
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP serves the registry's metrics for Prometheus to scrape.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteText(w)
+}
+
+// labelKey joins label values into a map key.
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs formats label names and values for the text format, adding
+// extra, already formatted, at the end.
+func labelPairs(names, values []string, extra string) string {
+	if len(names) == 0 && extra == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escape(values[i]))
+		b.WriteByte('"')
+	}
+	if extra != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(extra)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(s string) string { return escaper.Replace(s) }
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, strings.ReplaceAll(help, "\n", " "))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+// Counter is a family of counts that only go up.
+type Counter struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]*Series
+	values map[string][]string
+}
+
+// Counter returns the counter family called name with the given label
+// names, registering it if needed.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	if r == nil {
+		return nil
+	}
+	return register(r, name, func() *Counter {
+		return &Counter{
+			name:   name,
+			help:   help,
+			labels: labels,
+			series: make(map[string]*Series),
+			values: make(map[string][]string),
+		}
+	})
+}
+
+// With returns the series for the given label values, one per label name.
+// Keep the series to count on a hot path without looking it up each time.
+func (c *Counter) With(values ...string) *Series {
+	if c == nil {
+		return nil
+	}
+	if len(values) != len(c.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", c.name, len(c.labels), len(values)))
+	}
+	key := labelKey(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &Series{}
+		c.series[key] = s
+		c.values[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// Inc adds one to the series for the given label values.
+func (c *Counter) Inc(values ...string) { c.With(values...).Add(1) }
+
+// Value returns the count of the series for the given label values.
+func (c *Counter) Value(values ...string) uint64 { return c.With(values...).Value() }
+
+func (c *Counter) write(w *bufio.Writer) {
+	writeHeader(w, c.name, c.help, "counter")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.series) {
+		fmt.Fprintf(w, "%s%s %d\n", c.name, labelPairs(c.labels, c.values[key], ""), c.series[key].Value())
+	}
+}
+
+// Series is one count of a Counter.
+type Series struct {
+	n atomic.Uint64
+}
+
+// Add adds n to the count.
+func (s *Series) Add(n uint64) {
+	if s != nil {
+		s.n.Add(n)
+	}
+}
+
+// Inc adds one to the count.
+func (s *Series) Inc() { s.Add(1) }
+
+// Value returns the count.
+func (s *Series) Value() uint64 {
+	if s == nil {
+		return 0
+	}
+	return s.n.Load()
+}
+
+// DefaultBuckets suits latencies in seconds, from half a millisecond to
+// ten seconds.
+var DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram is a family of distributions: each series counts observations
+// into buckets by upper bound and keeps their sum.
+type Histogram struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+	values map[string][]string
+}
+
+type histogramSeries struct {
+	counts []uint64 // per bucket, not cumulative; the last is +Inf
+	sum    float64
+	count  uint64
+}
+
+// Histogram returns the histogram family called name with the given
+// bucket upper bounds, in increasing order, and label names, registering
+// it if needed.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if r == nil {
+		return nil
+	}
+	return register(r, name, func() *Histogram {
+		return &Histogram{
+			name:    name,
+			help:    help,
+			labels:  labels,
+			buckets: append([]float64(nil), buckets...),
+			series:  make(map[string]*histogramSeries),
+			values:  make(map[string][]string),
+		}
+	})
+}
+
+// Observe records v in the series for the given label values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	if h == nil {
+		return
+	}
+	if len(values) != len(h.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", h.name, len(h.labels), len(values)))
+	}
+	key := labelKey(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets)+1)}
+		h.series[key] = s
+		h.values[key] = append([]string(nil), values...)
+	}
+	s.counts[sort.SearchFloat64s(h.buckets, v)]++
+	s.sum += v
+	s.count++
+}
+
+// Count returns how many values the series for the given label values has
+// observed.
+func (h *Histogram) Count(values ...string) uint64 {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok := h.series[labelKey(values)]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	writeHeader(w, h.name, h.help, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		s, values := h.series[key], h.values[key]
+		var cumulative uint64
+		for i, n := range s.counts {
+			cumulative += n
+			le := math.Inf(1)
+			if i < len(h.buckets) {
+				le = h.buckets[i]
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name,
+				labelPairs(h.labels, values, `le="`+formatFloat(le)+`"`), cumulative)
+		}
+		pairs := labelPairs(h.labels, values, "")
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, pairs, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, pairs, s.count)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,111 @@
+package metrics_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/metrics"
+)
+
+func TestRegistry_WritesTextFormat(t *testing.T) {
+	reg := metrics.NewRegistry()
+	sent := reg.Counter("test_sent_total", "Messages sent.", "peer", "type")
+	sent.Inc("node2", "PREPARE")
+	sent.With("node1", "COMMIT").Add(3)
+	sent.Inc("node2", "PREPARE")
+	latency := reg.Histogram("test_latency_seconds", "Latency.", []float64{0.1, 1}, "phase")
+	latency.Observe(0.05, "commit")
+	latency.Observe(0.5, "commit")
+	latency.Observe(2, "commit")
+	reg.Counter("test_quoted_total", "Quoted.", "name").Inc(`a"b\c`)
+
+	var b strings.Builder
+	if err := reg.WriteText(&b); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	want := `# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{phase="commit",le="0.1"} 1
+test_latency_seconds_bucket{phase="commit",le="1"} 2
+test_latency_seconds_bucket{phase="commit",le="+Inf"} 3
+test_latency_seconds_sum{phase="commit"} 2.55
+test_latency_seconds_count{phase="commit"} 3
+# HELP test_quoted_total Quoted.
+# TYPE test_quoted_total counter
+test_quoted_total{name="a\"b\\c"} 1
+# HELP test_sent_total Messages sent.
+# TYPE test_sent_total counter
+test_sent_total{peer="node1",type="COMMIT"} 3
+test_sent_total{peer="node2",type="PREPARE"} 2
+`
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegistry_ServesHTTP(t *testing.T) {
+	reg := metrics.NewRegistry()
+	reg.Counter("test_total", "Things.").Inc()
+
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "\ntest_total 1\n") {
+		t.Errorf("test_total missing from:\n%s", rec.Body.String())
+	}
+}
+
+func TestRegistry_ReturnsTheSameFamily(t *testing.T) {
+	reg := metrics.NewRegistry()
+	reg.Counter("test_total", "Things.", "kind").Inc("a")
+	if got := reg.Counter("test_total", "Things.", "kind").Value("a"); got != 1 {
+		t.Errorf("expected the registered counter at 1, got %d", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a histogram under a counter's name did not panic")
+		}
+	}()
+	reg.Histogram("test_total", "Things.", metrics.DefaultBuckets)
+}
+
+func TestRegistry_CountsConcurrently(t *testing.T) {
+	reg := metrics.NewRegistry()
+	c := reg.Counter("test_total", "Things.", "kind")
+	h := reg.Histogram("test_seconds", "Times.", metrics.DefaultBuckets)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Inc("a")
+				h.Observe(0.01)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := c.Value("a"); got != 8000 {
+		t.Errorf("expected 8000, got %d", got)
+	}
+	if got := h.Count(); got != 8000 {
+		t.Errorf("expected 8000 observations, got %d", got)
+	}
+}
+
+func TestRegistry_NilDoesNothing(t *testing.T) {
+	var reg *metrics.Registry
+	c := reg.Counter("test_total", "Things.", "kind")
+	c.Inc("a")
+	c.With("b").Add(2)
+	h := reg.Histogram("test_seconds", "Times.", metrics.DefaultBuckets)
+	h.Observe(1)
+	if c.Value("a") != 0 || h.Count() != 0 {
+		t.Error("a nil registry counted")
+	}
+}
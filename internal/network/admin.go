@@ -0,0 +1,221 @@
+package network
+
+import (
+	"context"
+	"crypto/ed25519"
+	"log/slog"
+	"net"
+	"time"
+
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// NodeAdminService lets operators inspect and control a running node.
+type NodeAdminService struct {
+	pb.UnimplementedNodeAdminServer
+	node *Node
+}
+
+// SetLevelVar lets NodeAdmin change the level of the node's logger while it
+// runs. level must be the level of the handler the logger passed to
+// NewNode writes to.
+func (n *Node) SetLevelVar(level *slog.LevelVar) {
+	n.levelVar = level
+}
+
+// ShutdownRequested returns a channel that is closed once a NodeAdmin
+// client asks the node to shut down. Whoever runs the node should then
+// call Shutdown.
+func (n *Node) ShutdownRequested() <-chan struct{} {
+	return n.shutdown
+}
+
+// Shutdown stops the node gracefully: it stops accepting calls and waits
+// up to timeout for those in progress before stopping as Stop does, which
+// still sends the messages already queued.
+func (n *Node) Shutdown(timeout time.Duration) {
+	n.requestShutdown()
+	n.mu.Lock()
+	server := n.server
+	n.mu.Unlock()
+	if server != nil {
+		done := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(timeout):
+			n.logger.Warn("calls still in progress at shutdown", "timeout", timeout)
+		}
+	}
+	n.Stop()
+}
+
+func (n *Node) requestShutdown() {
+	n.shutdownOnce.Do(func() { close(n.shutdown) })
+}
+
+// serving reports whether the node is up, replicating and not shutting
+// down.
+func (n *Node) serving() bool {
+	select {
+	case <-n.shutdown:
+		return false
+	default:
+	}
+	return !n.stopped.Load() && n.replicator != nil
+}
+
+// logLevel returns the least severe level the node logs.
+func (n *Node) logLevel() slog.Level {
+	if n.levelVar != nil {
+		return n.levelVar.Level()
+	}
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn} {
+		if n.logger.Enabled(context.Background(), level) {
+			return level
+		}
+	}
+	return slog.LevelError
+}
+
+// peerStatus reports the node's connection to each of its peers, in ID
+// order.
+func (n *Node) peerStatus() []*pb.PeerStatus {
+	var peers []*pb.PeerStatus
+	for _, p := range n.peerList() {
+		st := &pb.PeerStatus{Id: p.ID}
+		if p.conn != nil {
+			st.Address = p.conn.Target()
+			st.State = p.conn.GetState().String()
+		}
+		peers = append(peers, st)
+	}
+	return peers
+}
+
+// authorizeAdmin checks that a NodeAdmin call that changes the node comes
+// from an operator: a client with a certificate the cluster CA issued to
+// one of the operators in the cluster config, or, on a cluster without
+// TLS, a process on the node's own host. The certificates of nodes never
+// count, so that a node cannot shut down or reconfigure its peers.
+func (n *Node) authorizeAdmin(ctx context.Context) error {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "no peer information")
+	}
+	if n.tls != nil {
+		info, ok := p.AuthInfo.(credentials.TLSInfo)
+		if !ok || len(info.State.VerifiedChains) == 0 {
+			return status.Errorf(codes.PermissionDenied, "node %s needs an operator certificate from the cluster CA", n.ID)
+		}
+		name := info.State.VerifiedChains[0][0].Subject.CommonName
+		if _, isPeer := n.peer(name); !n.tls.operators[name] || isPeer || name == n.ID {
+			return status.Errorf(codes.PermissionDenied, "%q is not an operator of node %s", name, n.ID)
+		}
+		return nil
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			return nil
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "node %s has no TLS and only takes admin calls from its own host", n.ID)
+}
+
+func (s *NodeAdminService) Health(ctx context.Context, req *pb.HealthRequest) (*pb.HealthResponse, error) {
+	resp := &pb.HealthResponse{Status: pb.HealthResponse_SERVING, NodeId: s.node.ID}
+	if !s.node.serving() {
+		resp.Status = pb.HealthResponse_NOT_SERVING
+	}
+	return resp, nil
+}
+
+func (s *NodeAdminService) Status(ctx context.Context, req *pb.StatusRequest) (*pb.NodeStatus, error) {
+	st := &pb.NodeStatus{}
+	if r := s.node.replicator; r != nil {
+		st = r.Status()
+	}
+	st.NodeId = s.node.ID
+	st.Address = s.node.Address
+	st.Peers = s.node.peerStatus()
+	st.LogLevel = s.node.logLevel().String()
+	return st, nil
+}
+
+func (s *NodeAdminService) SetLogLevel(ctx context.Context, req *pb.SetLogLevelRequest) (*pb.SetLogLevelResponse, error) {
+	if err := s.node.authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if s.node.levelVar == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "node %s has a fixed log level", s.node.ID)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown log level %q", req.Level)
+	}
+	previous := s.node.levelVar.Level()
+	s.node.levelVar.Set(level)
+	s.node.logger.Info("log level changed", "from", previous.String(), "to", level.String())
+	return &pb.SetLogLevelResponse{Previous: previous.String()}, nil
+}
+
+func (s *NodeAdminService) Shutdown(ctx context.Context, req *pb.ShutdownRequest) (*pb.Empty, error) {
+	if err := s.node.authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+	s.node.logger.Info("shutdown requested over NodeAdmin")
+	s.node.requestShutdown()
+	return &pb.Empty{}, nil
+}
+
+func (s *NodeAdminService) ChangeMembership(ctx context.Context, req *pb.ChangeMembershipRequest) (*pb.ChangeMembershipResponse, error) {
+	if err := s.node.authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+	r := s.node.replicator
+	if r == nil {
+		return nil, status.Errorf(codes.Unavailable, "node %s has no replicator", s.node.ID)
+	}
+	c, ok := r.(*Consensus)
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "membership changes need PBFT replication")
+	}
+	change := req.GetChange()
+	if change.GetMember().GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "no member given")
+	}
+	if change.Action == pb.Reconfiguration_ADD && len(change.Member.PublicKey) != ed25519.PublicKeySize {
+		return nil, status.Errorf(codes.InvalidArgument, "new member %s needs a %d-byte public key", change.Member.Id, ed25519.PublicKeySize)
+	}
+	if err := c.VerifyReconfiguration(change); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	// As with programs, only the leader may order the change. The caller
+	// is authorized here, not at the leader, so it is sent there rather
+	// than passed on in this node's name.
+	leader := r.Leader()
+	if leader != s.node.ID {
+		peer, ok := s.node.peer(leader)
+		if !ok || peer.conn == nil {
+			return nil, status.Errorf(codes.Unavailable, "leader %s is not connected to node %s", leader, s.node.ID)
+		}
+		return &pb.ChangeMembershipResponse{Leader: leader, LeaderAddress: peer.conn.Target()}, nil
+	}
+
+	exec := &pb.Execution{Id: newRequestID(), Reconfiguration: change}
+	seq, err := r.Submit(ctx, exec)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	s.node.logger.Info("membership change submitted", "action", change.Action, "member", change.Member.Id,
+		"request", exec.Id, "seq", seq)
+	return &pb.ChangeMembershipResponse{RequestId: exec.Id, Sequence: seq}, nil
+}
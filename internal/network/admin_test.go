@@ -0,0 +1,251 @@
+package network_test
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// adminClient connects to node's NodeAdmin service in plaintext.
+func adminClient(t *testing.T, node *network.Node) pb.NodeAdminClient {
+	t.Helper()
+	conn, err := grpc.NewClient(node.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewNodeAdminClient(conn)
+}
+
+func TestAdmin_ReportsHealthAndStatus(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := nodes[1].WaitForPeers(ctx, 3); err != nil {
+		t.Fatalf("WaitForPeers: %v", err)
+	}
+	if err := cs[0].StartConsensus(proposal(7)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	waitDecided(t, cs[1], nodes[1].ID)
+
+	admin := adminClient(t, nodes[1])
+	health, err := admin.Health(ctx, &pb.HealthRequest{})
+	if err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	if health.Status != pb.HealthResponse_SERVING || health.NodeId != "node2" {
+		t.Errorf("unexpected health %v", health)
+	}
+
+	st, err := admin.Status(ctx, &pb.StatusRequest{})
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if st.NodeId != "node2" || st.Replication != "pbft" || st.View != 0 || st.Leader != "node1" {
+		t.Errorf("unexpected status %v", st)
+	}
+	if st.LastDecided != 1 || st.LastDecidedDigest == "" {
+		t.Errorf("expected slot 1 to be the last decided, got %d (%q)", st.LastDecided, st.LastDecidedDigest)
+	}
+	if st.Phase == "" || st.LogLevel == "" {
+		t.Errorf("status is missing the phase or log level: %v", st)
+	}
+	if len(st.Peers) != 3 {
+		t.Fatalf("expected 3 peers, got %v", st.Peers)
+	}
+	for i, p := range st.Peers {
+		if want := nodes[[]int{0, 2, 3}[i]]; p.Id != want.ID || p.Address != want.Address || p.State != "READY" {
+			t.Errorf("peer %d: got %v, expected %s at %s, READY", i, p, want.ID, want.Address)
+		}
+	}
+}
+
+func TestAdmin_SetsLogLevel(t *testing.T) {
+	nodes, _ := startCluster(t, 1)
+	admin := adminClient(t, nodes[0])
+	ctx := context.Background()
+
+	_, err := admin.SetLogLevel(ctx, &pb.SetLogLevelRequest{Level: "debug"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("a fixed log level changed: %v", err)
+	}
+
+	var level slog.LevelVar
+	nodes[0].SetLevelVar(&level)
+	resp, err := admin.SetLogLevel(ctx, &pb.SetLogLevelRequest{Level: "debug"})
+	if err != nil {
+		t.Fatalf("SetLogLevel: %v", err)
+	}
+	if resp.Previous != "INFO" || level.Level() != slog.LevelDebug {
+		t.Errorf("expected INFO to become DEBUG, got %s to %s", resp.Previous, level.Level())
+	}
+	if _, err := admin.SetLogLevel(ctx, &pb.SetLogLevelRequest{Level: "loud"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an unknown level, got %v", err)
+	}
+	if st, err := admin.Status(ctx, &pb.StatusRequest{}); err != nil || st.LogLevel != "DEBUG" {
+		t.Errorf("status reports log level %q, %v", st.GetLogLevel(), err)
+	}
+}
+
+func TestAdmin_ShutdownIsGraceful(t *testing.T) {
+	nodes, _ := startCluster(t, 1)
+	admin := adminClient(t, nodes[0])
+	ctx := context.Background()
+
+	if _, err := admin.Shutdown(ctx, &pb.ShutdownRequest{}); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	select {
+	case <-nodes[0].ShutdownRequested():
+	case <-time.After(time.Second):
+		t.Fatal("the shutdown request did not reach whoever runs the node")
+	}
+	if health, err := admin.Health(ctx, &pb.HealthRequest{}); err != nil || health.Status != pb.HealthResponse_NOT_SERVING {
+		t.Errorf("a node shutting down reports %v, %v", health, err)
+	}
+
+	nodes[0].Shutdown(time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if _, err := admin.Health(ctx, &pb.HealthRequest{}); err == nil {
+		t.Error("the node still answers after shutting down")
+	}
+}
+
+func TestAdmin_ChangesNeedAnOperatorCertificate(t *testing.T) {
+	dir := t.TempDir()
+	nodes, _, _ := startTLSCluster(t, 4, dir)
+	roots, err := network.LoadCertPool(filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		t.Fatalf("LoadCertPool: %v", err)
+	}
+	dial := func(certs ...tls.Certificate) pb.NodeAdminClient {
+		config := &tls.Config{RootCAs: roots, ServerName: nodes[0].ID, Certificates: certs}
+		conn, err := grpc.NewClient(nodes[0].Address, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return pb.NewNodeAdminClient(conn)
+	}
+	ctx := context.Background()
+
+	anonymous := dial()
+	if _, err := anonymous.Status(ctx, &pb.StatusRequest{}); err != nil {
+		t.Errorf("Status without a certificate: %v", err)
+	}
+	if _, err := anonymous.Shutdown(ctx, &pb.ShutdownRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for a shutdown without a certificate, got %v", err)
+	}
+
+	// A node's certificate is from the cluster CA too, but does not make
+	// it an operator.
+	keyPath := filepath.Join(dir, "node2.key")
+	if err := network.WritePrivateKey(keyPath, keyFor("node2")); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := network.LoadClientCertificate(filepath.Join(dir, "node2.crt"), keyPath)
+	if err != nil {
+		t.Fatalf("LoadClientCertificate: %v", err)
+	}
+	if _, err := dial(cert).Shutdown(ctx, &pb.ShutdownRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for a shutdown with a node certificate, got %v", err)
+	}
+
+	cert, err = network.LoadClientCertificate(filepath.Join(dir, "operator.crt"), filepath.Join(dir, "operator.key"))
+	if err != nil {
+		t.Fatalf("LoadClientCertificate: %v", err)
+	}
+	if _, err := dial(cert).Shutdown(ctx, &pb.ShutdownRequest{}); err != nil {
+		t.Fatalf("Shutdown with a certificate: %v", err)
+	}
+	select {
+	case <-nodes[0].ShutdownRequested():
+	case <-time.After(time.Second):
+		t.Fatal("the shutdown request did not reach whoever runs the node")
+	}
+}
+
+func TestAdmin_ChangesMembership(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+	// Closing the epoch takes rounds of no-ops over real connections,
+	// which the tight test timeouts do not leave room for under load: the
+	// test is about the admin API, not view changes.
+	for _, c := range cs {
+		c.SetTimeouts(network.DefaultTimeouts)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := nodes[1].WaitForPeers(ctx, 3); err != nil {
+		t.Fatalf("WaitForPeers: %v", err)
+	}
+
+	admin := adminClient(t, nodes[1])
+	_, err := admin.ChangeMembership(ctx, &pb.ChangeMembershipRequest{Change: &pb.Reconfiguration{
+		Action: pb.Reconfiguration_ADD, Member: &pb.Member{Id: "node5"},
+	}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a new member without a key, got %v", err)
+	}
+
+	req := &pb.ChangeMembershipRequest{Change: &pb.Reconfiguration{
+		Action: pb.Reconfiguration_REMOVE, Member: &pb.Member{Id: "node4"},
+	}}
+	if _, err := admin.ChangeMembership(ctx, req); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for a change the operator did not sign, got %v", err)
+	}
+	network.SignReconfiguration(req.Change, adminKey)
+
+	// node2 sends the caller to the primary rather than passing the
+	// change on in its own name.
+	resp, err := admin.ChangeMembership(ctx, req)
+	if err != nil {
+		t.Fatalf("ChangeMembership: %v", err)
+	}
+	if resp.Leader != "node1" || resp.LeaderAddress != nodes[0].Address || resp.RequestId != "" {
+		t.Errorf("expected to be sent to node1 at %s, got %v", nodes[0].Address, resp)
+	}
+	resp, err = adminClient(t, nodes[0]).ChangeMembership(ctx, req)
+	if err != nil {
+		t.Fatalf("ChangeMembership: %v", err)
+	}
+	if resp.RequestId == "" || resp.Sequence != 1 {
+		t.Errorf("unexpected response %v", resp)
+	}
+
+	st, err := admin.Status(ctx, &pb.StatusRequest{})
+	for err == nil && st.Epoch == 0 && ctx.Err() == nil {
+		time.Sleep(10 * time.Millisecond)
+		st, err = admin.Status(ctx, &pb.StatusRequest{})
+	}
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if st.Epoch != 1 || len(st.Members) != 3 || len(st.NextMembers) != 0 {
+		t.Errorf("expected epoch 1 with 3 members, got %v", st)
+	}
+	select {
+	case <-nodes[3].ShutdownRequested():
+	case <-ctx.Done():
+		t.Error("node4 was removed but did not ask to shut down")
+	}
+	// node2 may enter the new epoch before the primary does.
+	for cs[0].Membership().N() != 3 && ctx.Err() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := cs[0].Membership().N(); n != 3 {
+		t.Errorf("the primary has %d members, expected 3", n)
+	}
+}
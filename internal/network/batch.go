@@ -0,0 +1,249 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+// Batching controls how the primary groups client requests: a batch is
+// proposed once it holds Size requests, or Delay after its first request
+// was submitted, whichever comes first. A batch that does not fit below the
+// high watermark waits for earlier slots to be applied.
+type Batching struct {
+	Size  int
+	Delay time.Duration
+}
+
+// DefaultBatching is what NewConsensus starts with.
+var DefaultBatching = Batching{Size: 32, Delay: 2 * time.Millisecond}
+
+// SetBatching sets how Submit groups requests into batches.
+func (c *Consensus) SetBatching(b Batching) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batching = b
+}
+
+// queuedRequest is a request waiting in Submit for the next batch.
+type queuedRequest struct {
+	req  *pb.Execution
+	seq  int64
+	err  error
+	done chan struct{}
+}
+
+// Submit queues req for the primary's next batch and returns the slot it
+// was proposed in. Only the primary of the current view may call it. If
+// ctx ends first, Submit returns at once, but the request may still be
+// proposed.
+func (c *Consensus) Submit(ctx context.Context, req *pb.Execution) (int64, error) {
+	c.mu.Lock()
+	if err := c.canPropose(0); err != nil {
+		c.mu.Unlock()
+		return 0, err
+	}
+	q := &queuedRequest{req: req, done: make(chan struct{})}
+	c.queue = append(c.queue, q)
+	if len(c.queue) >= c.batching.Size {
+		c.flushQueue()
+	} else if c.batchTimer == nil {
+		c.batchTimer = c.clock.AfterFunc(c.batching.Delay, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.batchTimer = nil
+			c.flushQueue()
+		})
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return q.seq, q.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// flushQueue proposes as many queued requests as fit below the high
+// watermark, in batches of at most the batch size. What does not fit stays
+// queued until applyDecided makes room. c.mu must be held.
+func (c *Consensus) flushQueue() {
+	if c.batchTimer != nil {
+		c.batchTimer.Stop()
+		c.batchTimer = nil
+	}
+	if err := c.canPropose(0); err != nil {
+		c.failQueue(err)
+		return
+	}
+	for len(c.queue) > 0 {
+		n := min(int64(len(c.queue)), int64(c.batching.Size), c.highWatermark()-c.nextSeq+1)
+		if n <= 0 {
+			c.node.logger.Debug("requests wait for the log window", "queued", len(c.queue))
+			return
+		}
+		batch := c.queue[:n]
+		c.queue = c.queue[n:]
+		reqs := make([]*pb.Execution, len(batch))
+		for i, q := range batch {
+			reqs[i] = q.req
+		}
+		seqs, err := c.proposeBatch(reqs)
+		for i, q := range batch {
+			if i < len(seqs) {
+				q.seq = seqs[i]
+			}
+			q.err = err
+			close(q.done)
+		}
+	}
+}
+
+// failQueue gives up on every queued request. c.mu must be held.
+func (c *Consensus) failQueue(err error) {
+	for _, q := range c.queue {
+		q.err = err
+		close(q.done)
+	}
+	c.queue = nil
+}
+
+// ProposeBatch proposes reqs for consecutive free slots and returns the
+// slots they got. Each slot still goes through its own three-phase round,
+// but the PRE_PREPAREs travel to each peer in one batch message, as do the
+// PREPAREs and COMMITs replicas answer them with. Only the primary of the
+// current view may call it, and the whole batch must fit below the high
+// watermark.
+func (c *Consensus) ProposeBatch(reqs []*pb.Execution) ([]int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.canPropose(int64(len(reqs))); err != nil {
+		return nil, err
+	}
+	return c.proposeBatch(reqs)
+}
+
+// proposeBatch is ProposeBatch with c.mu held.
+func (c *Consensus) proposeBatch(reqs []*pb.Execution) ([]int64, error) {
+	defer c.bundle()()
+	seqs := make([]int64, 0, len(reqs))
+	for _, req := range reqs {
+		msg := &pb.ConsensusMessage{Request: req}
+		if err := c.propose(msg); err != nil {
+			return seqs, err
+		}
+		seqs = append(seqs, msg.Sequence)
+	}
+	return seqs, nil
+}
+
+// batchable reports whether messages of type t may be sent in a batch.
+func batchable(t pb.ConsensusMessage_Type) bool {
+	switch t {
+	case pb.ConsensusMessage_PRE_PREPARE, pb.ConsensusMessage_PREPARE, pb.ConsensusMessage_COMMIT:
+		return true
+	}
+	return false
+}
+
+// bundle makes broadcast hold back the messages it sends and returns a
+// function that sends them, those of one type in a single batch. Calls
+// nest: only the outermost one sends. c.mu must be held.
+func (c *Consensus) bundle() func() {
+	if c.outbox != nil {
+		return func() {}
+	}
+	c.outbox = []*pb.ConsensusMessage{}
+	return func() {
+		c.flush()
+		c.outbox = nil
+	}
+}
+
+// flush sends the messages bundle has held back so far, and goes on
+// holding back the ones sent after. c.mu must be held.
+func (c *Consensus) flush() {
+	if len(c.outbox) == 0 {
+		return
+	}
+	out := c.outbox
+	c.outbox = []*pb.ConsensusMessage{}
+	c.sendBatches(out)
+}
+
+// sendBatches sends msgs, grouped by type in the order each type first
+// appears. c.mu must be held.
+func (c *Consensus) sendBatches(msgs []*pb.ConsensusMessage) {
+	var order []pb.ConsensusMessage_Type
+	byType := make(map[pb.ConsensusMessage_Type][]*pb.ConsensusMessage)
+	for _, msg := range msgs {
+		if byType[msg.Type] == nil {
+			order = append(order, msg.Type)
+		}
+		byType[msg.Type] = append(byType[msg.Type], msg)
+	}
+	for _, t := range order {
+		// Each message was signed as it was queued.
+		batch := byType[t]
+		send := c.node.broadcastSigned
+		msg := batch[0]
+		if len(batch) > 1 {
+			send = c.node.Broadcast
+			msg = &pb.ConsensusMessage{Type: t, Batch: batch}
+		}
+		if err := send(msg); err != nil {
+			c.node.logger.Warn("sending batch failed", "type", t, "err", err)
+		}
+	}
+}
+
+// HandleBatch handles each of the messages batched in msg as if it had
+// arrived on its own, and sends the messages that causes in batches too.
+func (c *Consensus) HandleBatch(msg *pb.ConsensusMessage) (*pb.Empty, error) {
+	// A batched message must come from the same sender. A PRE_PREPARE or
+	// PREPARE must also carry its own signature, since it may be shown to
+	// other nodes as proof that its value was prepared. Like admit, this
+	// checks signatures before taking c.mu, so they hold up nothing else.
+	var errs []error
+	valid := make([]*pb.ConsensusMessage, 0, len(msg.Batch))
+	for _, m := range msg.Batch {
+		if m.Type != msg.Type || m.Sender != msg.Sender || len(m.Batch) > 0 {
+			errs = append(errs, fmt.Errorf("%v from %s in a batch of %v from %s", m.Type, m.Sender, msg.Type, msg.Sender))
+			continue
+		}
+		if m.Type != pb.ConsensusMessage_COMMIT {
+			if err := c.node.Verify(m); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		valid = append(valid, m)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.bundle()()
+
+	c.node.logger.Debug("handling batch", "type", msg.Type, "size", len(msg.Batch), "from", msg.Sender)
+	for _, m := range valid {
+		var err error
+		switch m.Type {
+		case pb.ConsensusMessage_PRE_PREPARE:
+			err = c.handlePrePrepare(m)
+		case pb.ConsensusMessage_PREPARE:
+			err = c.handlePrepare(m)
+		case pb.ConsensusMessage_COMMIT:
+			err = c.handleCommit(m)
+		default:
+			err = fmt.Errorf("%v messages are not batched", m.Type)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return &pb.Empty{}, errors.Join(errs...)
+}
@@ -0,0 +1,186 @@
+package network_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+// requests returns n executions built by proposal, with acc from+0 to
+// from+n-1.
+func requests(from, n int) []*pb.Execution {
+	reqs := make([]*pb.Execution, n)
+	for i := range reqs {
+		reqs[i] = proposal(int8(from + i)).Request
+	}
+	return reqs
+}
+
+func TestBatch_OnePrePrepareOrdersManyRequests(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	seqs, err := cs[0].ProposeBatch(requests(1, 10))
+	if err != nil {
+		t.Fatalf("ProposeBatch: %v", err)
+	}
+	if len(seqs) != 10 || seqs[0] != 1 || seqs[9] != 10 {
+		t.Fatalf("expected slots 1 to 10, got %v", seqs)
+	}
+	if !net.RunUntil(allApplied(10, cs...), 10000) {
+		t.Fatal("the cluster did not apply the batch")
+	}
+	sameDecisions(t, 10, nodes, cs)
+	for seq := int64(1); seq <= 10; seq++ {
+		if got := proposedAcc(cs[2].Decided(seq)); got != int32(seq) {
+			t.Errorf("slot %d: expected acc %d, got %d", seq, seq, got)
+		}
+	}
+
+	// One PRE_PREPARE, and one PREPARE and one COMMIT per replica, instead
+	// of one of each per request.
+	batched := net.Stats().Sent
+	net, _, cs = startMemoryCluster(t, 4, 1)
+	propose(t, cs[0], 1, 10)
+	if !net.RunUntil(allApplied(10, cs...), 10000) {
+		t.Fatal("the cluster did not apply the requests")
+	}
+	if unbatched := net.Stats().Sent; batched*2 > unbatched {
+		t.Errorf("expected batching to at least halve the messages sent, got %d vs %d", batched, unbatched)
+	}
+}
+
+func TestBatch_RejectsVotesForgedIntoABatch(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	net.SetFaults(network.Faults{Delay: time.Millisecond})
+	if _, err := cs[0].ProposeBatch(requests(1, 2)); err != nil {
+		t.Fatalf("ProposeBatch: %v", err)
+	}
+
+	// node2 signs a batch that claims to carry node3's vote.
+	batch := &pb.ConsensusMessage{
+		Type: pb.ConsensusMessage_COMMIT,
+		Batch: []*pb.ConsensusMessage{
+			{Type: pb.ConsensusMessage_COMMIT, Sequence: 1, Request: proposal(5).Request, Sender: "node3"},
+		},
+	}
+	nodes[1].Sign(batch)
+	if err := nodes[0].Deliver(batch); err == nil {
+		t.Error("node1 accepted a vote from node3 in a batch signed by node2")
+	}
+
+	if !net.RunUntil(allApplied(2, cs...), 10000) {
+		t.Fatal("the cluster did not apply the batch")
+	}
+	if got := proposedAcc(cs[0].Decided(1)); got != 1 {
+		t.Errorf("expected acc 1 in slot 1, got %d", got)
+	}
+}
+
+func TestBatch_SubmitWaitsForTheLogWindow(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+	for _, c := range cs {
+		c.SetLogWindow(4)
+	}
+	cs[0].SetBatching(network.Batching{Size: 3, Delay: time.Millisecond})
+
+	// More requests than fit in the window at once: the ones that do not
+	// fit wait for the earlier slots to be applied.
+	const n = 20
+	seqs := make([]int64, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			seqs[i], errs[i] = cs[0].Submit(context.Background(), proposal(int8(i)).Request)
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[int64]bool)
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Submit %d: %v", i, err)
+		}
+		if seen[seqs[i]] {
+			t.Fatalf("two requests got slot %d", seqs[i])
+		}
+		seen[seqs[i]] = true
+	}
+	waitApplied(t, n, under(nodes, cs)...)
+}
+
+// benchmarkThroughput orders b.N requests on a memory cluster of four, in
+// batches of size, and reports how many requests it orders per second of
+// real time.
+func benchmarkThroughput(b *testing.B, size int) {
+	net, _, cs := startMemoryCluster(b, 4, 1)
+	for _, c := range cs {
+		c.SetCheckpointInterval(0)
+	}
+	b.ResetTimer()
+	for done := 0; done < b.N; {
+		n := min(size, b.N-done)
+		if _, err := cs[0].ProposeBatch(requests(0, n)); err != nil {
+			b.Fatalf("ProposeBatch: %v", err)
+		}
+		done += n
+		if !net.RunUntil(allApplied(int64(done), cs...), 1000000) {
+			b.Fatalf("the cluster did not apply %d requests", done)
+		}
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "req/s")
+}
+
+func BenchmarkThroughput(b *testing.B) {
+	for _, size := range []int{1, 8, 32} {
+		b.Run(fmt.Sprintf("batch=%d", size), func(b *testing.B) {
+			benchmarkThroughput(b, size)
+		})
+	}
+}
+
+// BenchmarkThroughputGRPC submits b.N requests from 64 concurrent clients
+// to a cluster of four over loopback gRPC.
+func BenchmarkThroughputGRPC(b *testing.B) {
+	for _, size := range []int{1, 32} {
+		b.Run(fmt.Sprintf("batch=%d", size), func(b *testing.B) {
+			_, cs := startCluster(b, 4)
+			for _, c := range cs {
+				c.SetCheckpointInterval(0)
+			}
+			cs[0].SetBatching(network.Batching{Size: size, Delay: time.Millisecond})
+
+			b.ResetTimer()
+			var wg sync.WaitGroup
+			next := make(chan int)
+			for w := 0; w < 64; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range next {
+						if _, err := cs[0].Submit(context.Background(), proposal(int8(i)).Request); err != nil {
+							b.Errorf("Submit: %v", err)
+						}
+					}
+				}()
+			}
+			for i := 0; i < b.N; i++ {
+				next <- i
+			}
+			close(next)
+			wg.Wait()
+			for _, c := range cs {
+				for c.LastApplied() < int64(b.N) {
+					time.Sleep(time.Millisecond)
+				}
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "req/s")
+		})
+	}
+}
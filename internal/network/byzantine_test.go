@@ -0,0 +1,338 @@
+package network_test
+
+import (
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// The behaviors below turn a node Byzantine by intercepting what it sends.
+// The node still runs the honest protocol internally; only its messages
+// lie.
+
+// equivocate makes a primary propose alt, instead of what it proposed, to
+// the peers in fooled.
+func equivocate(node *network.Node, alt *pb.Execution, fooled ...string) network.Interceptor {
+	return func(to string, msg *pb.ConsensusMessage) []*pb.ConsensusMessage {
+		if msg.Type == pb.ConsensusMessage_PRE_PREPARE && contains(fooled, to) {
+			msg.Request = alt
+			node.Sign(msg)
+		}
+		return []*pb.ConsensusMessage{msg}
+	}
+}
+
+// bogusVotes makes a replica vote for a request nobody proposed and report
+// a result hash nobody computed.
+func bogusVotes(node *network.Node) network.Interceptor {
+	bogus := proposal(-1).Request
+	return func(to string, msg *pb.ConsensusMessage) []*pb.ConsensusMessage {
+		switch msg.Type {
+		case pb.ConsensusMessage_PREPARE, pb.ConsensusMessage_COMMIT:
+			msg.Request = bogus
+		case pb.ConsensusMessage_RESULT:
+			msg.Digest = "bogus"
+		}
+		node.Sign(msg)
+		return []*pb.ConsensusMessage{msg}
+	}
+}
+
+// forgeViewChange makes a replica claim in its VIEW_CHANGEs that it has
+// applied nothing and that req was prepared in slot 1 in a view far ahead
+// of any other, so that a new primary that takes its word re-proposes req.
+func forgeViewChange(node *network.Node, req *pb.Execution) network.Interceptor {
+	return func(to string, msg *pb.ConsensusMessage) []*pb.ConsensusMessage {
+		if msg.Type == pb.ConsensusMessage_VIEW_CHANGE {
+			msg.Sequence = 0
+			msg.Checkpoint = nil
+			msg.Prepared = []*pb.PreparedEntry{{Sequence: 1, View: 99, Request: req}}
+			node.Sign(msg)
+		}
+		return []*pb.ConsensusMessage{msg}
+	}
+}
+
+// silent makes a node send nothing.
+func silent(string, *pb.ConsensusMessage) []*pb.ConsensusMessage { return nil }
+
+// record passes a node's messages through unchanged, keeping a copy of
+// each in *log.
+func record(log *[]*pb.ConsensusMessage) network.Interceptor {
+	return func(to string, msg *pb.ConsensusMessage) []*pb.ConsensusMessage {
+		*log = append(*log, proto.Clone(msg).(*pb.ConsensusMessage))
+		return []*pb.ConsensusMessage{msg}
+	}
+}
+
+func contains(ids []string, id string) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+// honestAgree fails t unless the honest nodes, given by index, decided
+// the same request in every one of the first n slots and certified the
+// same result for it. Slots a node has not decided yet are skipped.
+func honestAgree(t *testing.T, n int64, nodes []*network.Node, cs []*network.Consensus, honest ...int) {
+	t.Helper()
+	for seq := int64(1); seq <= n; seq++ {
+		var want *pb.ConsensusMessage
+		var wantResult *pb.ExecutionResult
+		from := ""
+		for _, i := range honest {
+			d := cs[i].Decided(seq)
+			if d == nil {
+				continue
+			}
+			r, certified := cs[i].Result(seq)
+			if from == "" {
+				want, wantResult, from = d, r, nodes[i].ID
+				continue
+			}
+			if !proto.Equal(d.Request, want.Request) {
+				t.Errorf("slot %d: %s and %s decided different requests", seq, from, nodes[i].ID)
+			}
+			if certified && wantResult != nil && !proto.Equal(r, wantResult) {
+				t.Errorf("slot %d: %s and %s certified different results", seq, from, nodes[i].ID)
+			}
+		}
+	}
+}
+
+func TestByzantine_EquivocatingPrimary(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		net, nodes, cs := startMemoryCluster(t, 4, seed)
+		net.Intercept("node1", equivocate(nodes[0], proposal(2).Request, "node3", "node4"))
+
+		if err := cs[0].StartConsensus(proposal(1)); err != nil {
+			t.Fatalf("StartConsensus: %v", err)
+		}
+		if !net.RunUntil(allApplied(1, cs[1:]...), 20000) {
+			t.Errorf("seed %d: the honest replicas did not decide", seed)
+		}
+		honestAgree(t, 1, nodes, cs, 1, 2, 3)
+	}
+}
+
+func TestByzantine_EquivocatingPrimarySplitsSevenNodes(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		net, nodes, cs := startMemoryCluster(t, 7, seed)
+		net.Intercept("node1", equivocate(nodes[0], proposal(2).Request, "node5", "node6", "node7"))
+		net.Intercept("node7", bogusVotes(nodes[6]))
+
+		if err := cs[0].StartConsensus(proposal(1)); err != nil {
+			t.Fatalf("StartConsensus: %v", err)
+		}
+		if !net.RunUntil(allApplied(1, cs[1:6]...), 50000) {
+			t.Errorf("seed %d: the honest replicas did not decide", seed)
+		}
+		honestAgree(t, 1, nodes, cs, 1, 2, 3, 4, 5)
+	}
+}
+
+func TestByzantine_BogusVotesAreOutvoted(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	net.Intercept("node4", bogusVotes(nodes[3]))
+
+	for i := int8(1); i <= 3; i++ {
+		if err := cs[0].StartConsensus(proposal(i)); err != nil {
+			t.Fatalf("StartConsensus %d: %v", i, err)
+		}
+	}
+	honest := cs[:3]
+	certified := func() bool {
+		for _, c := range honest {
+			if _, ok := c.Result(3); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	if !net.RunUntil(certified, 20000) {
+		t.Fatal("the honest replicas did not certify slot 3")
+	}
+	honestAgree(t, 3, nodes, cs, 0, 1, 2)
+	for i, c := range honest {
+		for seq := int64(1); seq <= 3; seq++ {
+			if got := proposedAcc(c.Decided(seq)); got != int32(seq) {
+				t.Errorf("%s: slot %d decided acc %d", nodes[i].ID, seq, got)
+			}
+		}
+		blamed := false
+		for _, d := range c.Divergences() {
+			if d.Node != "node4" {
+				t.Errorf("%s blamed %s", nodes[i].ID, d)
+			}
+			blamed = true
+		}
+		if !blamed {
+			t.Errorf("%s did not report node4's bogus results", nodes[i].ID)
+		}
+	}
+}
+
+func TestByzantine_SilentReplicas(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 7, 1)
+	net.Intercept("node6", silent)
+	net.Intercept("node7", silent)
+
+	for i := int8(1); i <= 3; i++ {
+		if err := cs[0].StartConsensus(proposal(i)); err != nil {
+			t.Fatalf("StartConsensus %d: %v", i, err)
+		}
+	}
+	if !net.RunUntil(allApplied(3, cs[:5]...), 20000) {
+		t.Fatal("the honest replicas did not decide")
+	}
+	honestAgree(t, 3, nodes, cs, 0, 1, 2, 3, 4)
+}
+
+func TestByzantine_ReplaysFromOldViews(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	net.SetFaults(network.Faults{Delay: 1})
+	var recorded []*pb.ConsensusMessage
+	for _, n := range nodes {
+		net.Intercept(n.ID, record(&recorded))
+	}
+
+	// As in TestMemory_PartitionedPrimaryIsReplaced, losing node1 after
+	// node2 has its PRE_PREPARE moves the cluster to view 1.
+	if err := cs[0].StartConsensus(proposal(42)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	net.Step()
+	net.Partition([]string{"node1"})
+	if !net.RunUntil(allApplied(1, cs[1:]...), 10000) {
+		t.Fatal("the survivors did not decide slot 1")
+	}
+	if v := cs[1].View(); v != 1 {
+		t.Fatalf("expected view 1, got %d", v)
+	}
+
+	// node1 comes back Byzantine: it stays silent itself but replays every
+	// message it has seen, each still carrying its original signature.
+	net.Heal()
+	net.Intercept("node1", silent)
+	if err := cs[1].StartConsensus(proposal(7)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	for _, msg := range recorded {
+		for _, to := range []string{"node2", "node3", "node4"} {
+			net.Inject("node1", to, msg)
+		}
+	}
+	if !net.RunUntil(allApplied(2, cs[1:]...), 10000) {
+		t.Fatal("the survivors did not decide slot 2")
+	}
+	honestAgree(t, 2, nodes, cs, 1, 2, 3)
+	for i, c := range cs[1:] {
+		if got := proposedAcc(c.Decided(2)); got != 7 {
+			t.Errorf("%s: slot 2 decided acc %d", nodes[i+1].ID, got)
+		}
+		if v := c.View(); v != 1 {
+			t.Errorf("%s: replays moved it to view %d", nodes[i+1].ID, v)
+		}
+	}
+}
+
+func TestByzantine_ForgedViewChange(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	forge, bogus := forgeViewChange(nodes[3], proposal(-1).Request), bogusVotes(nodes[3])
+	net.Intercept("node4", func(to string, msg *pb.ConsensusMessage) []*pb.ConsensusMessage {
+		if msg.Type == pb.ConsensusMessage_VIEW_CHANGE {
+			return forge(to, msg)
+		}
+		return bogus(to, msg)
+	})
+	// Only node1 sees the COMMITs for slot 1, so it alone decides it;
+	// node2 and node3 have it prepared.
+	net.Intercept("node1", func(to string, msg *pb.ConsensusMessage) []*pb.ConsensusMessage {
+		if msg.Type == pb.ConsensusMessage_COMMIT {
+			return nil
+		}
+		return []*pb.ConsensusMessage{msg}
+	})
+
+	if err := cs[0].StartConsensus(proposal(1)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	if !net.RunUntil(func() bool { return cs[0].Decided(1) != nil }, 10000) {
+		t.Fatal("node1 did not decide slot 1")
+	}
+
+	// With node1 cut off, node2 and node3 change views, and node4 tells
+	// them the slot holds something else, prepared in view 99.
+	net.Partition([]string{"node1"})
+	net.RunUntil(func() bool { return cs[1].Decided(1) != nil || cs[2].Decided(1) != nil }, 1000)
+	honestAgree(t, 1, nodes, cs, 0, 1, 2)
+
+	// Only once node1 is back do they have a quorum without node4.
+	net.Heal()
+	net.Intercept("node1", nil)
+	if !net.RunUntil(allApplied(1, cs[:3]...), 20000) {
+		t.Fatal("the honest replicas did not decide slot 1")
+	}
+	honestAgree(t, 1, nodes, cs, 0, 1, 2)
+	for i, c := range cs[:3] {
+		if got := proposedAcc(c.Decided(1)); got != 1 {
+			t.Errorf("%s: slot 1 decided acc %d", nodes[i].ID, got)
+		}
+	}
+}
+
+func TestByzantine_ViewChangesMustProveWhatWasPrepared(t *testing.T) {
+	_, nodes, cs := startMemoryCluster(t, 4, 1)
+	forged := proposal(-1).Request
+	vote := func(node *network.Node, typ pb.ConsensusMessage_Type, view int64) *pb.ConsensusMessage {
+		msg := &pb.ConsensusMessage{Type: typ, View: view, Sequence: 1, Request: forged}
+		node.Sign(msg)
+		return msg
+	}
+
+	for _, tc := range []struct {
+		name  string
+		entry *pb.PreparedEntry
+	}{
+		{"no proof", &pb.PreparedEntry{Sequence: 1, View: 0, Request: forged}},
+		{"its own vote only", &pb.PreparedEntry{Sequence: 1, View: 0, Request: forged, Proof: []*pb.ConsensusMessage{
+			vote(nodes[3], pb.ConsensusMessage_PREPARE, 0),
+			vote(nodes[3], pb.ConsensusMessage_PREPARE, 0),
+		}}},
+		{"votes for another view", &pb.PreparedEntry{Sequence: 1, View: 0, Request: forged, Proof: []*pb.ConsensusMessage{
+			vote(nodes[0], pb.ConsensusMessage_PRE_PREPARE, 1),
+			vote(nodes[1], pb.ConsensusMessage_PREPARE, 1),
+			vote(nodes[3], pb.ConsensusMessage_PREPARE, 1),
+		}}},
+		{"the view it asks for", &pb.PreparedEntry{Sequence: 1, View: 2, Request: forged, Proof: []*pb.ConsensusMessage{
+			vote(nodes[1], pb.ConsensusMessage_PRE_PREPARE, 2),
+			vote(nodes[2], pb.ConsensusMessage_PREPARE, 2),
+			vote(nodes[3], pb.ConsensusMessage_PREPARE, 2),
+		}}},
+	} {
+		vc := &pb.ConsensusMessage{Type: pb.ConsensusMessage_VIEW_CHANGE, View: 2, Prepared: []*pb.PreparedEntry{tc.entry}}
+		nodes[3].Sign(vc)
+		if _, err := cs[2].HandleViewChange(vc); err == nil {
+			t.Errorf("node3 took a VIEW_CHANGE whose prepared value has %s", tc.name)
+		}
+	}
+
+	// The same claim, proven by a quorum of a view before the one asked
+	// for, is taken.
+	vc := &pb.ConsensusMessage{Type: pb.ConsensusMessage_VIEW_CHANGE, View: 2, Prepared: []*pb.PreparedEntry{{
+		Sequence: 1, View: 1, Request: forged, Proof: []*pb.ConsensusMessage{
+			vote(nodes[1], pb.ConsensusMessage_PRE_PREPARE, 1),
+			vote(nodes[2], pb.ConsensusMessage_PREPARE, 1),
+			vote(nodes[3], pb.ConsensusMessage_PREPARE, 1),
+		},
+	}}}
+	nodes[3].Sign(vc)
+	if _, err := cs[2].HandleViewChange(vc); err != nil {
+		t.Errorf("node3 refused a proven VIEW_CHANGE: %v", err)
+	}
+}
@@ -0,0 +1,382 @@
+package network
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"sort"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultCheckpointInterval is how many slots apart checkpoints are.
+const DefaultCheckpointInterval = 16
+
+// SetCheckpointInterval sets how many applied slots apart this node
+// checkpoints its VM state. Every member of the cluster must use the same
+// interval, or their checkpoints never line up into a stable one. Zero
+// turns checkpoints off.
+func (c *Consensus) SetCheckpointInterval(n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkpointInterval = n
+}
+
+// setRoot computes the Merkle root of state's memory from scratch, for
+// states that come from outside this node's VM.
+func setRoot(state *pb.VMState) {
+	state.MemoryRoot = vm.NewMerkleTree(state.Memory).Root()
+}
+
+// StableCheckpoint returns the latest checkpoint a quorum has certified, or
+// nil if there is none yet.
+func (c *Consensus) StableCheckpoint() *pb.StableCheckpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stable
+}
+
+// checkpoint records the VM state and configuration after the last
+// applied slot in the WAL and tells the other replicas their digest. c.mu
+// must be held.
+func (c *Consensus) checkpoint() {
+	seq := c.lastApplied
+	state := vmState(c.node.VM)
+	c.snapshots[seq] = &pb.StateSnapshot{State: state, Configuration: c.config}
+	if c.replaying {
+		return
+	}
+
+	if c.wal != nil {
+		rec := &pb.WALRecord{Type: pb.WALRecord_CHECKPOINT, Sequence: seq, State: state, Configuration: c.config}
+		if err := c.wal.Append(rec); err != nil {
+			c.node.logger.Error("checkpoint failed", "seq", seq, "err", err)
+		}
+	}
+
+	msg := &pb.ConsensusMessage{
+		Type:     pb.ConsensusMessage_CHECKPOINT,
+		View:     c.currentView,
+		Sequence: seq,
+		Digest:   checkpointDigest(state, c.config),
+	}
+	// The message goes into this node's own proof, so it must be signed
+	// even if it cannot be sent.
+	c.node.Sign(msg)
+	c.recordCheckpoint(msg)
+	if err := c.node.Broadcast(msg); err != nil {
+		c.node.logger.Warn("sending checkpoint failed", "seq", seq, "err", err)
+	}
+	c.checkStable(seq)
+}
+
+// HandleCheckpoint records another replica's state digest for a slot. A
+// node that sees a quorum agree on a checkpoint past its own last applied
+// slot has fallen behind, and fetches the checkpoint from its peers.
+func (c *Consensus) HandleCheckpoint(msg *pb.ConsensusMessage) (*pb.Empty, error) {
+	c.mu.Lock()
+	if stable := c.stable.GetSequence(); msg.Sequence <= stable {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("Checkpoint for sequence %d, already stable up to %d", msg.Sequence, stable)
+	}
+	c.node.logger.Debug("handling Checkpoint", "seq", msg.Sequence, "from", msg.Sender)
+	c.recordCheckpoint(msg)
+	c.checkStable(msg.Sequence)
+	behind := c.behind(msg.Sequence)
+	c.mu.Unlock()
+
+	if behind {
+		if err := c.CatchUp(context.Background()); err != nil {
+			c.node.logger.Warn("catching up failed", "err", err)
+		}
+	}
+	return &pb.Empty{}, nil
+}
+
+func (c *Consensus) recordCheckpoint(msg *pb.ConsensusMessage) {
+	if c.checkpoints[msg.Sequence] == nil {
+		c.checkpoints[msg.Sequence] = make(map[string]*pb.ConsensusMessage)
+	}
+	c.checkpoints[msg.Sequence][msg.Sender] = msg
+}
+
+// certify returns a certificate for seq if a quorum of replicas sent
+// CHECKPOINTs for it with the same digest. c.mu must be held.
+func (c *Consensus) certify(seq int64) *pb.StableCheckpoint {
+	byDigest := make(map[string][]*pb.ConsensusMessage)
+	for _, msg := range c.checkpoints[seq] {
+		byDigest[msg.Digest] = append(byDigest[msg.Digest], msg)
+	}
+	for d, proof := range byDigest {
+		if len(proof) >= c.decisionQuorum {
+			sort.Slice(proof, func(i, j int) bool { return proof[i].Sender < proof[j].Sender })
+			return &pb.StableCheckpoint{Sequence: seq, Digest: d, Proof: proof}
+		}
+	}
+	return nil
+}
+
+// checkStable makes seq the stable checkpoint once a quorum has certified
+// the state this node itself reached there. c.mu must be held.
+func (c *Consensus) checkStable(seq int64) {
+	snap, ok := c.snapshots[seq]
+	if !ok {
+		return
+	}
+	cert := c.certify(seq)
+	if cert == nil {
+		return
+	}
+	if cert.Digest != checkpointDigest(snap.State, snap.Configuration) {
+		c.node.logger.Error("a quorum certified a different state", "seq", seq)
+		return
+	}
+	c.node.logger.Info("checkpoint is stable", "seq", seq)
+	c.stabilize(cert, snap.State, snap.Configuration)
+	c.compact()
+}
+
+// behind reports whether a quorum has certified a checkpoint at seq that
+// this node has not reached, and no fetch is already under way. c.mu must
+// be held.
+func (c *Consensus) behind(seq int64) bool {
+	return seq > c.lastApplied && !c.fetching && c.certify(seq) != nil
+}
+
+// stabilize makes cert, which certifies state and cfg, the stable
+// checkpoint and garbage-collects the log up to it: the slots, votes and
+// checkpoints it covers are no longer needed by anyone, since a replica
+// that lacks them fetches the checkpoint instead. Results that have not settled yet are
+// kept until a later checkpoint, so clients still learn how their request
+// ended. c.mu must be held.
+func (c *Consensus) stabilize(cert *pb.StableCheckpoint, state *pb.VMState, cfg *pb.Configuration) {
+	seq := cert.Sequence
+	c.stable = cert
+	c.stableState = state
+	c.stableConfig = cfg
+
+	for n := range c.slots {
+		if n <= seq {
+			delete(c.slots, n)
+		}
+	}
+	c.collect(seq)
+	c.prepares.collect(seq)
+	c.commits.collect(seq)
+	for s := range c.checkpoints {
+		if s <= seq {
+			delete(c.checkpoints, s)
+		}
+	}
+	for s := range c.snapshots {
+		if s <= seq {
+			delete(c.snapshots, s)
+		}
+	}
+}
+
+// compact rewrites the WAL to start from the stable checkpoint, dropping
+// what it covers. c.mu must be held.
+func (c *Consensus) compact() {
+	if c.wal == nil || c.replaying {
+		return
+	}
+	seq := c.stable.Sequence
+	view := c.currentView
+	head := &pb.WALRecord{
+		Type:          pb.WALRecord_CHECKPOINT,
+		Sequence:      seq,
+		State:         c.stableState,
+		Stable:        c.stable,
+		Configuration: c.stableConfig,
+	}
+	keep := func(rec *pb.WALRecord) bool {
+		msg := rec.Message
+		switch rec.Type {
+		case pb.WALRecord_CHECKPOINT, pb.WALRecord_PREPARED:
+			return rec.Sequence > seq
+		case pb.WALRecord_VIEW:
+			return msg.View == view
+		case pb.WALRecord_SENT:
+			if msg.Type == pb.ConsensusMessage_VIEW_CHANGE {
+				return msg.View > view
+			}
+		}
+		return msg.Sequence > seq
+	}
+	if err := c.wal.Compact(head, keep); err != nil {
+		c.node.logger.Error("compacting WAL failed", "err", err)
+	}
+}
+
+// Snapshot returns this node's latest stable checkpoint and the state and
+// configuration it certifies, or nil if it has none yet.
+func (c *Consensus) Snapshot() *pb.StateSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stable == nil {
+		return nil
+	}
+	return &pb.StateSnapshot{Checkpoint: c.stable, State: c.stableState, Configuration: c.stableConfig}
+}
+
+// signers returns the members of cfg that signed a CHECKPOINT for cert's
+// sequence and digest. A member that cfg lists a key for must have signed
+// with it.
+func (c *Consensus) signers(cert *pb.StableCheckpoint, cfg *pb.Configuration) map[string]bool {
+	keys := make(map[string]ed25519.PublicKey)
+	for _, m := range cfg.GetMembers() {
+		keys[m.Id] = m.PublicKey
+	}
+	senders := make(map[string]bool)
+	for _, msg := range cert.Proof {
+		key, member := keys[msg.Sender]
+		if msg.Type != pb.ConsensusMessage_CHECKPOINT || msg.Sequence != cert.Sequence ||
+			msg.Digest != cert.Digest || senders[msg.Sender] || !member {
+			continue
+		}
+		if err := c.node.verifyWith(msg, key); err == nil {
+			senders[msg.Sender] = true
+		}
+	}
+	return senders
+}
+
+// verifyCheckpoint checks that cert carries CHECKPOINTs for its sequence
+// and digest signed by a quorum of the members of cfg, the configuration
+// in force at that sequence.
+func (c *Consensus) verifyCheckpoint(cert *pb.StableCheckpoint, cfg *pb.Configuration) error {
+	members, err := membershipOf(cfg)
+	if err != nil {
+		return err
+	}
+	if n := len(c.signers(cert, cfg)); n < members.Quorum() {
+		return fmt.Errorf("checkpoint at sequence %d is signed by %d members of epoch %d, quorum is %d",
+			cert.Sequence, n, cfg.Epoch, members.Quorum())
+	}
+	return nil
+}
+
+// verifyCertified checks that cert certifies a checkpoint taken in cfg,
+// the configuration a peer claims was in force at its sequence: a quorum
+// of cfg's members signed it, and so did at least one honest member of the
+// epoch this node itself knows was in force there, which vouches for cfg.
+// That lets a node that missed a membership change, or joined through
+// one, catch up for as long as enough members it knows remain.
+func (c *Consensus) verifyCertified(cert *pb.StableCheckpoint, cfg *pb.Configuration, known []*pb.Configuration) error {
+	seq := cert.Sequence
+	if seq < cfg.Start || (cfg.Next != nil && seq >= cfg.Next.Start) {
+		return fmt.Errorf("checkpoint at sequence %d is outside epoch %d", seq, cfg.Epoch)
+	}
+	trusted := configIn(known, seq)
+	if trusted == nil {
+		return fmt.Errorf("no known epoch covers sequence %d", seq)
+	}
+	if err := c.verifyCheckpoint(cert, cfg); err != nil {
+		return err
+	}
+	members, err := membershipOf(trusted)
+	if err != nil {
+		return err
+	}
+	if n := len(c.signers(cert, trusted)); n < members.F()+1 {
+		return fmt.Errorf("checkpoint at sequence %d is signed by %d members of epoch %d, expected at least %d",
+			seq, n, trusted.Epoch, members.F()+1)
+	}
+	return nil
+}
+
+// CatchUp asks every peer for its latest stable checkpoint and, if the
+// best one it can verify is past this node's last applied slot, installs
+// it: the VM takes the certified state and the log continues from there.
+// It returns an error if no peer had such a checkpoint.
+func (c *Consensus) CatchUp(ctx context.Context) error {
+	c.mu.Lock()
+	if c.fetching {
+		c.mu.Unlock()
+		return fmt.Errorf("a fetch is already under way")
+	}
+	c.fetching = true
+	ids := c.membership.IDs()
+	known := c.knownConfigs()
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.fetching = false
+		c.mu.Unlock()
+	}()
+
+	var best *pb.StateSnapshot
+	for _, id := range ids {
+		if id == c.node.ID {
+			continue
+		}
+		snap, err := c.node.fetchState(ctx, id)
+		if err != nil {
+			c.node.logger.Warn("fetching state failed", "peer", id, "err", err)
+			continue
+		}
+		if err := c.verifySnapshot(snap, known); err != nil {
+			c.node.logger.Warn("rejected state", "peer", id, "err", err)
+			continue
+		}
+		if best == nil || snap.Checkpoint.Sequence > best.Checkpoint.Sequence {
+			best = snap
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if best == nil || best.Checkpoint.Sequence <= c.lastApplied {
+		return fmt.Errorf("no peer has a stable checkpoint past sequence %d", c.lastApplied)
+	}
+	c.install(best)
+	return nil
+}
+
+// verifySnapshot checks that snap's checkpoint is certified in the
+// configurations known, and that its state and configuration are the ones
+// certified.
+func (c *Consensus) verifySnapshot(snap *pb.StateSnapshot, known []*pb.Configuration) error {
+	if snap.GetCheckpoint() == nil || snap.State == nil || snap.Configuration == nil {
+		return fmt.Errorf("incomplete snapshot")
+	}
+	if _, err := membershipOf(snap.Configuration); err != nil {
+		return fmt.Errorf("snapshot configuration: %w", err)
+	}
+	if len(snap.State.Memory) != vm.MemorySize {
+		return fmt.Errorf("snapshot memory is %d bytes, expected %d", len(snap.State.Memory), vm.MemorySize)
+	}
+	if err := c.verifyCertified(snap.Checkpoint, snap.Configuration, known); err != nil {
+		return err
+	}
+	// The root the peer sent is not to be trusted.
+	setRoot(snap.State)
+	if d := checkpointDigest(snap.State, snap.Configuration); d != snap.Checkpoint.Digest {
+		return fmt.Errorf("state digest %.12s does not match the checkpoint digest %.12s", d, snap.Checkpoint.Digest)
+	}
+	return nil
+}
+
+// install moves this node forward to the verified snapshot snap, in the
+// membership it certifies. c.mu must be held.
+func (c *Consensus) install(snap *pb.StateSnapshot) {
+	seq := snap.Checkpoint.Sequence
+	c.node.logger.Info("installing stable checkpoint", "seq", seq, "epoch", snap.Configuration.Epoch)
+	state := proto.Clone(snap.State).(*pb.VMState)
+	cfg := proto.Clone(snap.Configuration).(*pb.Configuration)
+	c.node.VM.UpdateState(state)
+	c.lastApplied = seq
+	c.skipTo(seq)
+	c.nextSeq = max(c.nextSeq, seq+1)
+	prev := c.membership
+	c.setConfiguration(cfg)
+	c.connectMembers(prev)
+	c.stabilize(snap.Checkpoint, state, cfg)
+	c.compact()
+	c.maybeEnterEpoch()
+	c.applyDecided()
+	c.resetTimer()
+}
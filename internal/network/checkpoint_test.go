@@ -0,0 +1,268 @@
+package network_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+// checkpointEvery sets the checkpoint interval of every instance in cs.
+func checkpointEvery(n int64, cs ...*network.Consensus) {
+	for _, c := range cs {
+		c.SetCheckpointInterval(n)
+	}
+}
+
+// propose proposes acc values from..to at c, one slot each.
+func propose(t *testing.T, c *network.Consensus, from, to int8) {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		if err := c.StartConsensus(proposal(i)); err != nil {
+			t.Fatalf("StartConsensus %d: %v", i, err)
+		}
+	}
+}
+
+// stableAt returns a condition that holds once every instance in cs has a
+// stable checkpoint at seq or later.
+func stableAt(seq int64, cs ...*network.Consensus) func() bool {
+	return func() bool {
+		for _, c := range cs {
+			if c.StableCheckpoint().GetSequence() < seq {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// sameState fails t unless got's VM is in the same state as want's.
+func sameState(t *testing.T, name string, got, want *network.Consensus) {
+	t.Helper()
+	g, w := got.NodeState().State, want.NodeState().State
+	if !bytes.Equal(g.Memory, w.Memory) || g.Acc != w.Acc || g.Pc != w.Pc {
+		t.Errorf("%s: VM state differs (acc %d, expected %d)", name, g.Acc, w.Acc)
+	}
+}
+
+func TestCheckpoint_BecomesStableAndCollectsLog(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	checkpointEvery(4, cs...)
+
+	propose(t, cs[0], 1, 9)
+	if !net.RunUntil(func() bool { return allApplied(9, cs...)() && stableAt(8, cs...)() }, 20000) {
+		t.Fatal("the cluster did not reach a stable checkpoint at 8")
+	}
+	for i, c := range cs {
+		cp := c.StableCheckpoint()
+		if cp.Sequence != 8 || len(cp.Proof) < c.Membership().Quorum() {
+			t.Errorf("%s: expected a checkpoint at 8 signed by a quorum, got %d with %d signatures",
+				nodes[i].ID, cp.Sequence, len(cp.Proof))
+		}
+		if c.Decided(8) != nil {
+			t.Errorf("%s: slot 8 should have been collected", nodes[i].ID)
+		}
+		if got := proposedAcc(c.Decided(9)); got != 9 {
+			t.Errorf("%s: expected slot 9 to be kept with acc 9, got %d", nodes[i].ID, got)
+		}
+	}
+}
+
+func TestCheckpoint_LaggingReplicaFetchesState(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	net.SetFaults(network.Faults{Delay: time.Millisecond})
+	checkpointEvery(4, cs...)
+
+	// node4 misses slots 1 to 5 entirely.
+	net.Partition([]string{"node4"})
+	propose(t, cs[0], 1, 5)
+	if !net.RunUntil(allApplied(5, cs[:3]...), 10000) {
+		t.Fatal("the majority did not decide")
+	}
+	net.Heal()
+
+	// The checkpoint at 8 tells node4 it is behind; it fetches the state
+	// and takes part in the log from there.
+	propose(t, cs[0], 6, 8)
+	if !net.RunUntil(allApplied(8, cs...), 10000) {
+		t.Fatalf("node4 did not catch up: applied up to %d", cs[3].LastApplied())
+	}
+	sameState(t, nodes[3].ID, cs[3], cs[0])
+
+	propose(t, cs[0], 9, 9)
+	if !net.RunUntil(allApplied(9, cs...), 10000) {
+		t.Fatal("node4 did not decide slot 9")
+	}
+	if got := proposedAcc(cs[3].Decided(9)); got != 9 {
+		t.Errorf("node4: expected acc 9 in slot 9, got %d", got)
+	}
+	sameState(t, nodes[3].ID, cs[3], cs[0])
+}
+
+func TestCheckpoint_ForgedSnapshotsAreRejected(t *testing.T) {
+	net, _, cs := startMemoryCluster(t, 4, 1)
+	checkpointEvery(4, cs...)
+	net.Partition([]string{"node4"})
+	propose(t, cs[0], 1, 8)
+	if !net.RunUntil(stableAt(8, cs[:3]...), 10000) {
+		t.Fatal("the majority did not reach a stable checkpoint")
+	}
+	net.Heal()
+
+	// node1 serves a state that does not match its checkpoint, node2 a
+	// checkpoint signed only by itself, and node3 one whose signatures do
+	// not cover the digest it claims.
+	net.InterceptState("node1", func(s *pb.StateSnapshot) *pb.StateSnapshot {
+		s.State.Acc++
+		return s
+	})
+	net.InterceptState("node2", func(s *pb.StateSnapshot) *pb.StateSnapshot {
+		s.Checkpoint.Proof = s.Checkpoint.Proof[:1]
+		return s
+	})
+	net.InterceptState("node3", func(s *pb.StateSnapshot) *pb.StateSnapshot {
+		s.State.Acc++
+		s.Checkpoint.Digest = "forged"
+		return s
+	})
+	if err := cs[3].CatchUp(context.Background()); err == nil {
+		t.Fatal("node4 installed a forged snapshot")
+	}
+	if got := cs[3].LastApplied(); got != 0 {
+		t.Fatalf("node4 moved to sequence %d on a forged snapshot", got)
+	}
+
+	net.InterceptState("node2", nil)
+	if err := cs[3].CatchUp(context.Background()); err != nil {
+		t.Fatalf("CatchUp: %v", err)
+	}
+	if got := cs[3].LastApplied(); got != 8 {
+		t.Errorf("expected node4 at sequence 8, got %d", got)
+	}
+	sameState(t, "node4", cs[3], cs[1])
+}
+
+func TestCheckpoint_SnapshotsInMadeUpEpochsAreRejected(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	checkpointEvery(4, cs...)
+	net.Partition([]string{"node4"})
+	propose(t, cs[0], 1, 8)
+	if !net.RunUntil(stableAt(8, cs[:3]...), 10000) {
+		t.Fatal("the majority did not reach a stable checkpoint")
+	}
+	net.Partition([]string{"node1", "node4"})
+
+	// node1 claims that node2 to node4 were replaced by members it runs,
+	// who all certify the state it serves.
+	fakes := make([]*network.Node, 3)
+	cfg := &pb.Configuration{Epoch: 1, Start: 1, Members: []*pb.Member{{Id: "node1"}}}
+	for i := range fakes {
+		fakes[i] = network.NewNode(fmt.Sprintf("fake%d", i+1), "", vm.NewVM(nil, io.Discard), nil)
+		cfg.Members = append(cfg.Members, &pb.Member{Id: fakes[i].ID, PublicKey: fakes[i].PublicKey()})
+	}
+	signers := append(fakes, nodes[0])
+	net.InterceptState("node1", func(s *pb.StateSnapshot) *pb.StateSnapshot {
+		s.State.Acc++
+		s.Configuration = cfg
+		state := &pb.VMState{Pc: s.State.Pc, Acc: s.State.Acc, MemoryRoot: vm.NewMerkleTree(s.State.Memory).Root()}
+		b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(&pb.StateSnapshot{State: state, Configuration: cfg})
+		sum := sha256.Sum256(b)
+		s.Checkpoint.Digest = hex.EncodeToString(sum[:])
+		s.Checkpoint.Proof = nil
+		for _, n := range signers {
+			msg := &pb.ConsensusMessage{Type: pb.ConsensusMessage_CHECKPOINT, Sequence: 8, Digest: s.Checkpoint.Digest}
+			n.Sign(msg)
+			s.Checkpoint.Proof = append(s.Checkpoint.Proof, msg)
+		}
+		return s
+	})
+	if err := cs[3].CatchUp(context.Background()); err == nil {
+		t.Fatal("node4 installed a snapshot certified by members it does not know")
+	}
+	if got := cs[3].LastApplied(); got != 0 {
+		t.Fatalf("node4 moved to sequence %d on a forged snapshot", got)
+	}
+}
+
+func TestCheckpoint_CompactsTheWAL(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	checkpointEvery(4, cs...)
+	path := filepath.Join(t.TempDir(), "node2.wal")
+	if err := cs[1].Recover(openWAL(t, path)); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+
+	propose(t, cs[0], 1, 9)
+	if !net.RunUntil(func() bool { return allApplied(9, cs...)() && stableAt(8, cs...)() }, 20000) {
+		t.Fatal("the cluster did not reach a stable checkpoint at 8")
+	}
+
+	records := openWAL(t, path).Records()
+	if len(records) == 0 || records[0].Type != pb.WALRecord_CHECKPOINT || records[0].Stable == nil {
+		t.Fatal("expected the WAL to start with the stable checkpoint")
+	}
+	for _, rec := range records[1:] {
+		seq := rec.Message.GetSequence()
+		if rec.Message == nil {
+			seq = rec.Sequence
+		}
+		if seq <= 8 && rec.Type != pb.WALRecord_VIEW {
+			t.Errorf("%v record for sequence %d survived compaction", rec.Type, seq)
+		}
+	}
+
+	nodes[1], cs[1] = restart(t, net, nodes[1], path)
+	if got := cs[1].LastApplied(); got != 9 {
+		t.Errorf("expected the restarted node at sequence 9, got %d", got)
+	}
+	if got := cs[1].StableCheckpoint().GetSequence(); got != 8 {
+		t.Errorf("expected the restarted node to keep its checkpoint at 8, got %d", got)
+	}
+	sameState(t, nodes[1].ID, cs[1], cs[0])
+}
+
+func TestCheckpoint_FetchStateOverGRPC(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+	checkpointEvery(2, cs...)
+	propose(t, cs[0], 1, 2)
+	waitApplied(t, 2, under(nodes, cs)...)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !stableAt(2, cs...)() {
+		if time.Now().After(deadline) {
+			t.Fatal("the checkpoint at 2 did not become stable")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	conn, err := grpc.NewClient(nodes[1].Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	snap, err := pb.NewNodeServiceClient(conn).FetchState(context.Background(), &pb.FetchStateRequest{})
+	if err != nil {
+		t.Fatalf("FetchState: %v", err)
+	}
+	if snap.Checkpoint.Sequence != 2 || snap.State.Acc != 2 {
+		t.Errorf("expected the state after slot 2, got sequence %d with acc %d", snap.Checkpoint.Sequence, snap.State.Acc)
+	}
+
+	// Nobody is ahead of a replica that is up to date.
+	if err := cs[3].CatchUp(context.Background()); err == nil {
+		t.Error("expected CatchUp to find nothing to install")
+	}
+}
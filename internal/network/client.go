@@ -0,0 +1,159 @@
+package network
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/atlaspl"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ClientService serves the client API of a node: programs are submitted to
+// any node, which hands them to the leader, and results can be read from
+// any node once it has executed them.
+type ClientService struct {
+	pb.UnimplementedClientServiceServer
+	node *Node
+}
+
+// forwardedKey is the metadata key a node marks the programs it forwards
+// to the leader with. Its value is the node's ID.
+const forwardedKey = "atlas-forwarded-by"
+
+// forwardedBy returns the ID of the node that forwarded the call ctx
+// belongs to, or "" if it came straight from a client.
+func forwardedBy(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(forwardedKey); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// newRequestID returns a random ID for a submitted program.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// executionFor turns a submission into the request the cluster orders,
+// compiling it first if it is source.
+func executionFor(req *pb.SubmitRequest) (*pb.Execution, error) {
+	if req.MaxSteps < 0 || req.MaxSteps > MaxSteps {
+		return nil, status.Errorf(codes.InvalidArgument, "max steps %d is not between 0 and %d", req.MaxSteps, MaxSteps)
+	}
+	exec := &pb.Execution{
+		Input:    req.Input,
+		MaxSteps: req.MaxSteps,
+	}
+	switch p := req.Program.(type) {
+	case *pb.SubmitRequest_Source:
+		compiled, err := atlaspl.Compile(strings.NewReader(p.Source))
+		var parseErr *atlaspl.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, status.Errorf(codes.InvalidArgument, "parse errors: %s", strings.Join(parseErr.Errors, "; "))
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "compilation failed: %v", err)
+		}
+		exec = NewExecution(compiled.Bytecode, compiled.InitialData, req.Input)
+		exec.MaxSteps = req.MaxSteps
+	case *pb.SubmitRequest_Bytecode:
+		exec.Bytecode = p.Bytecode
+		exec.InitialData = req.InitialData
+	default:
+		return nil, status.Error(codes.InvalidArgument, "no program given")
+	}
+	return exec, nil
+}
+
+func (s *ClientService) SubmitProgram(ctx context.Context, req *pb.SubmitRequest) (*pb.SubmitResponse, error) {
+	r := s.node.replicator
+	if r == nil {
+		return nil, status.Errorf(codes.Unavailable, "node %s has no replicator", s.node.ID)
+	}
+
+	// Only the leader may order requests; anyone else passes the program
+	// on, once. Two nodes that disagree about the leader during a view
+	// change would otherwise pass it back and forth until the caller gives
+	// up.
+	leader := r.Leader()
+	if leader == "" {
+		return nil, status.Errorf(codes.Unavailable, "node %s knows of no leader", s.node.ID)
+	}
+	if leader != s.node.ID {
+		peer, ok := s.node.peer(leader)
+		if !ok || peer.conn == nil {
+			return nil, status.Errorf(codes.Unavailable, "leader %s is not connected to node %s", leader, s.node.ID)
+		}
+		if from := forwardedBy(ctx); from != "" {
+			return nil, status.Errorf(codes.Unavailable, "node %s, to which %s forwarded the program, does not lead either: send it to %s at %s",
+				s.node.ID, from, leader, peer.conn.Target())
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, forwardedKey, s.node.ID)
+		return pb.NewClientServiceClient(peer.conn).SubmitProgram(ctx, req)
+	}
+
+	exec, err := executionFor(req)
+	if err != nil {
+		return nil, err
+	}
+	exec.Id = newRequestID()
+	seq, err := r.Submit(ctx, exec)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	s.node.logger.Info("request submitted", "request", exec.Id, "seq", seq)
+	return &pb.SubmitResponse{RequestId: exec.Id, Sequence: seq}, nil
+}
+
+func (s *ClientService) GetResult(ctx context.Context, req *pb.GetResultRequest) (*pb.ExecutionReport, error) {
+	r := s.node.replicator
+	if r == nil {
+		return nil, status.Errorf(codes.Unavailable, "node %s has no replicator", s.node.ID)
+	}
+	report := r.ResultByID(req.RequestId)
+	if report == nil {
+		return nil, status.Errorf(codes.NotFound, "request %s has not been executed on node %s", req.RequestId, s.node.ID)
+	}
+	return report, nil
+}
+
+func (s *ClientService) GetState(ctx context.Context, req *pb.GetStateRequest) (*pb.NodeState, error) {
+	r := s.node.replicator
+	if r == nil {
+		return nil, status.Errorf(codes.Unavailable, "node %s has no replicator", s.node.ID)
+	}
+	return r.NodeState(), nil
+}
+
+func (s *ClientService) WatchExecutions(req *pb.WatchRequest, stream pb.ClientService_WatchExecutionsServer) error {
+	r := s.node.replicator
+	if r == nil {
+		return status.Errorf(codes.Unavailable, "node %s has no replicator", s.node.ID)
+	}
+	next := max(req.FromSequence, 1)
+	for {
+		reports, settled := r.Reports(next)
+		for _, rep := range reports {
+			if err := stream.Send(rep); err != nil {
+				return err
+			}
+			next = rep.Sequence + 1
+		}
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-settled:
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package network_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const sumSource = `
+var a: int;
+var b: int;
+a = 3;
+b = 4;
+return (a + b);
+`
+
+// dialClient returns a client for the ClientService of node.
+func dialClient(t *testing.T, node *network.Node) pb.ClientServiceClient {
+	t.Helper()
+	conn, err := grpc.NewClient(node.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial %s: %v", node.ID, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewClientServiceClient(conn)
+}
+
+// waitResult polls GetResult until the request is certified.
+func waitResult(t *testing.T, client pb.ClientServiceClient, id string) *pb.ExecutionReport {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		r, err := client.GetResult(context.Background(), &pb.GetResultRequest{RequestId: id})
+		if err == nil && r.Certified {
+			return r
+		}
+		if err != nil && status.Code(err) != codes.NotFound {
+			t.Fatalf("GetResult: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("request %s was not certified", id)
+	return nil
+}
+
+func TestClient_SubmitToBackupIsForwardedToPrimary(t *testing.T) {
+	nodes, _ := startCluster(t, 4)
+
+	resp, err := dialClient(t, nodes[2]).SubmitProgram(context.Background(), &pb.SubmitRequest{
+		Program: &pb.SubmitRequest_Source{Source: sumSource},
+	})
+	if err != nil {
+		t.Fatalf("SubmitProgram: %v", err)
+	}
+	if resp.RequestId == "" || resp.Sequence != 1 {
+		t.Errorf("expected a request ID in sequence 1, got %+v", resp)
+	}
+
+	for _, n := range nodes {
+		r := waitResult(t, dialClient(t, n), resp.RequestId)
+		if string(r.Result.Output) != "7\n" || r.Sequence != 1 {
+			t.Errorf("%s: expected output 7 in sequence 1, got %q in %d", n.ID, r.Result.Output, r.Sequence)
+		}
+	}
+}
+
+func TestClient_ForwardedSubmitIsNotForwardedAgain(t *testing.T) {
+	nodes, _ := startCluster(t, 4)
+
+	// As if node4 had thought node3 led, while node3 knows it is node1.
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "atlas-forwarded-by", "node4")
+	_, err := dialClient(t, nodes[2]).SubmitProgram(ctx, &pb.SubmitRequest{
+		Program: &pb.SubmitRequest_Source{Source: sumSource},
+	})
+	if status.Code(err) != codes.Unavailable || !strings.Contains(err.Error(), nodes[0].Address) {
+		t.Errorf("expected Unavailable naming the leader's address %s, got %v", nodes[0].Address, err)
+	}
+}
+
+func TestClient_SubmitWithoutAConnectionToThePrimary(t *testing.T) {
+	// On a memory network, peers have no gRPC connection to forward over.
+	_, nodes, _ := startMemoryCluster(t, 4, 1)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	nodes[1].Address = lis.Addr().String()
+	go nodes[1].Serve(lis)
+	t.Cleanup(nodes[1].Stop)
+
+	_, err = dialClient(t, nodes[1]).SubmitProgram(context.Background(), &pb.SubmitRequest{
+		Program: &pb.SubmitRequest_Source{Source: sumSource},
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable, got %v", err)
+	}
+}
+
+func TestClient_SubmitBytecodeWithInput(t *testing.T) {
+	nodes, _ := startCluster(t, 4)
+	client := dialClient(t, nodes[0])
+
+	program := []byte{byte(vm.IN) << 4, byte(vm.OUT) << 4, byte(vm.HALT) << 4}
+	resp, err := client.SubmitProgram(context.Background(), &pb.SubmitRequest{
+		Program: &pb.SubmitRequest_Bytecode{Bytecode: program},
+		Input:   []byte("12\n"),
+	})
+	if err != nil {
+		t.Fatalf("SubmitProgram: %v", err)
+	}
+	if r := waitResult(t, client, resp.RequestId); string(r.Result.Output) != "12\n" {
+		t.Errorf("expected output 12, got %q", r.Result.Output)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	nodes, _ := startCluster(t, 4)
+	client := dialClient(t, nodes[0])
+
+	_, err := client.SubmitProgram(context.Background(), &pb.SubmitRequest{
+		Program: &pb.SubmitRequest_Source{Source: "var x: int = ;"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a parse error, got %v", err)
+	}
+
+	_, err = client.SubmitProgram(context.Background(), &pb.SubmitRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an empty submission, got %v", err)
+	}
+
+	// JUMP 0 never halts, and would hold up every replica for as long as
+	// it is allowed to run.
+	_, err = client.SubmitProgram(context.Background(), &pb.SubmitRequest{
+		Program:  &pb.SubmitRequest_Bytecode{Bytecode: []byte{byte(vm.JUMP) << 4}},
+		MaxSteps: 1 << 62,
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a step limit above the maximum, got %v", err)
+	}
+
+	_, err = client.GetResult(context.Background(), &pb.GetResultRequest{RequestId: "nope"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for an unknown request, got %v", err)
+	}
+}
+
+func TestClient_GetState(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+	if err := cs[0].StartConsensus(proposal(9)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	waitApplied(t, 1, under(nodes, cs)...)
+
+	st, err := dialClient(t, nodes[3]).GetState(context.Background(), &pb.GetStateRequest{})
+	if err != nil {
+		t.Fatalf("GetState: %v", err)
+	}
+	if st.NodeId != "node4" || st.Primary != "node1" || st.LastApplied != 1 || st.State.Acc != 9 {
+		t.Errorf("unexpected state: node=%s primary=%s applied=%d acc=%d",
+			st.NodeId, st.Primary, st.LastApplied, st.State.Acc)
+	}
+}
+
+func TestClient_WatchExecutionsStreamsInLogOrder(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := dialClient(t, nodes[1]).WatchExecutions(ctx, &pb.WatchRequest{})
+	if err != nil {
+		t.Fatalf("WatchExecutions: %v", err)
+	}
+
+	for i := int8(1); i <= 3; i++ {
+		if err := cs[0].StartConsensus(proposal(i)); err != nil {
+			t.Fatalf("StartConsensus %d: %v", i, err)
+		}
+	}
+	for seq := int64(1); seq <= 3; seq++ {
+		r, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if r.Sequence != seq || !r.Certified || r.Result.State.Acc != int32(seq) {
+			t.Errorf("expected certified slot %d with acc %d, got slot %d certified=%v acc=%d",
+				seq, seq, r.Sequence, r.Certified, r.Result.State.Acc)
+		}
+	}
+}
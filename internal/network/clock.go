@@ -0,0 +1,28 @@
+package network
+
+import "time"
+
+// Clock schedules the consensus phase timers. Nodes use the system clock;
+// a MemoryNetwork provides a virtual one so that timeouts fire in a
+// reproducible order with the messages it delivers.
+type Clock interface {
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a call scheduled on a Clock.
+type Timer interface {
+	// Stop cancels the call, reporting false if it already happened.
+	Stop() bool
+}
+
+type systemClock struct{}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+// SetClock replaces the clock the phase timers run on. It takes effect
+// from the next phase change.
+func (c *Consensus) SetClock(clock Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clock
+}
@@ -0,0 +1,109 @@
+package network_test
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+// testTimeouts keeps view changes fast enough for tests.
+var testTimeouts = network.Timeouts{
+	PrePrepare: 200 * time.Millisecond,
+	Prepare:    200 * time.Millisecond,
+	Commit:     200 * time.Millisecond,
+	ViewChange: time.Second,
+}
+
+// startCluster runs n fully connected nodes named node1..nodeN on loopback
+// ports and returns them with their consensus instances.
+func startCluster(t testing.TB, n int) ([]*network.Node, []*network.Consensus) {
+	t.Helper()
+	nodes := make([]*network.Node, n)
+	for i := range nodes {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		nodes[i] = network.NewNode(fmt.Sprintf("node%d", i+1), lis.Addr().String(), vm.NewVM(nil, io.Discard), nil)
+		go func(node *network.Node) { _ = node.Serve(lis) }(nodes[i])
+		t.Cleanup(nodes[i].Stop)
+	}
+	for _, from := range nodes {
+		for _, to := range nodes {
+			if from == to {
+				continue
+			}
+			if err := from.ConnectToPeer(to.ID, to.Address, to.PublicKey()); err != nil {
+				t.Fatalf("connect %s → %s: %v", from.ID, to.ID, err)
+			}
+		}
+	}
+	ids := make([]string, n)
+	for i, node := range nodes {
+		ids[i] = node.ID
+	}
+	members, err := network.NewMembership(ids...)
+	if err != nil {
+		t.Fatalf("NewMembership: %v", err)
+	}
+	consensus := make([]*network.Consensus, n)
+	for i, node := range nodes {
+		if consensus[i], err = network.NewConsensus(node, members); err != nil {
+			t.Fatalf("NewConsensus %s: %v", node.ID, err)
+		}
+		consensus[i].SetTimeouts(testTimeouts)
+		consensus[i].SetAdminKey(adminKey.Public().(ed25519.PublicKey))
+		node.SetReplicator(consensus[i])
+	}
+	return nodes, consensus
+}
+
+// proposal proposes a program that loads acc from the data segment,
+// prints it and halts.
+func proposal(acc int8) *pb.ConsensusMessage {
+	program := []byte{byte(vm.LOAD) << 4, byte(vm.OUT) << 4, byte(vm.HALT) << 4}
+	return &pb.ConsensusMessage{
+		Type:    pb.ConsensusMessage_PRE_PREPARE,
+		Request: network.NewExecution(program, map[uint8]byte{0: byte(acc)}, nil),
+	}
+}
+
+// proposedAcc returns the acc a message built by proposal carries.
+func proposedAcc(msg *pb.ConsensusMessage) int32 {
+	return int32(int8(msg.Request.GetInitialData()[0]))
+}
+
+// consensusUnderTest names a consensus instance for failure messages.
+type consensusUnderTest struct {
+	*network.Consensus
+	name string
+}
+
+func under(nodes []*network.Node, cs []*network.Consensus) []*consensusUnderTest {
+	out := make([]*consensusUnderTest, len(cs))
+	for i := range cs {
+		out[i] = &consensusUnderTest{Consensus: cs[i], name: nodes[i].ID}
+	}
+	return out
+}
+
+// waitDecided polls c until it decides or the deadline passes.
+func waitDecided(t *testing.T, c *network.Consensus, name string) *pb.ConsensusMessage {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if v := c.GetDecidedValue(); v != nil {
+			return v
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("%s did not decide", name)
+	return nil
+}
@@ -0,0 +1,203 @@
+package network
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ClusterConfig lists every node of a cluster. Each replica reads the same
+// file, so they all agree on the membership and on each other's keys.
+type ClusterConfig struct {
+	Nodes []NodeConfig `json:"nodes"`
+
+	// TLS, if set, makes nodes connect to each other over mutual TLS.
+	TLS *TLSConfig `json:"tls,omitempty"`
+
+	// AdminKey is the public key the operator signs membership changes
+	// with, encoded like the nodes' keys. A PBFT cluster without one
+	// refuses every change.
+	AdminKey string `json:"admin_key,omitempty"`
+
+	dir string // relative paths in the config are relative to it
+}
+
+// NodeConfig is one cluster member: where to reach it, and the key its
+// messages are signed with (base64 of the raw 32-byte ed25519 public key).
+// With TLS, Cert is the PEM certificate the cluster CA issued for that key
+// and the node's ID.
+type NodeConfig struct {
+	ID        string `json:"id"`
+	Address   string `json:"address"`
+	PublicKey string `json:"public_key"`
+	Cert      string `json:"cert,omitempty"`
+}
+
+// TLSConfig names the PEM certificate of the CA that issues node
+// certificates, and the common names of the certificates it issued to
+// operators: only those may make changes over NodeAdmin.
+type TLSConfig struct {
+	CA        string   `json:"ca"`
+	Operators []string `json:"operators,omitempty"`
+}
+
+// LoadClusterConfig reads and validates the cluster config at path.
+func LoadClusterConfig(path string) (*ClusterConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cfg, err := ParseClusterConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.dir = filepath.Dir(path)
+	return cfg, nil
+}
+
+// SaveClusterConfig writes cfg to path as indented JSON.
+func SaveClusterConfig(path string, cfg *ClusterConfig) error {
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// path resolves a file named in the config.
+func (c *ClusterConfig) path(name string) string {
+	if filepath.IsAbs(name) || c.dir == "" {
+		return name
+	}
+	return filepath.Join(c.dir, name)
+}
+
+// ParseClusterConfig decodes a JSON cluster config and validates it.
+func ParseClusterConfig(r io.Reader) (*ClusterConfig, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var cfg ClusterConfig
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid cluster config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks that every node has an ID, an address and a well-formed
+// key, and a certificate if the cluster uses TLS, and that no ID or
+// address is used twice.
+func (c *ClusterConfig) Validate() error {
+	ids := make([]string, len(c.Nodes))
+	addrs := make(map[string]string)
+	for i, n := range c.Nodes {
+		ids[i] = n.ID
+		if n.Address == "" {
+			return fmt.Errorf("node %q has no address", n.ID)
+		}
+		if other, ok := addrs[n.Address]; ok {
+			return fmt.Errorf("nodes %s and %s share address %s", other, n.ID, n.Address)
+		}
+		addrs[n.Address] = n.ID
+		if _, err := DecodePublicKey(n.PublicKey); err != nil {
+			return fmt.Errorf("node %q: %w", n.ID, err)
+		}
+		if c.TLS != nil && n.Cert == "" {
+			return fmt.Errorf("node %q has no TLS certificate", n.ID)
+		}
+	}
+	if c.TLS != nil && c.TLS.CA == "" {
+		return fmt.Errorf("TLS is configured without a CA certificate")
+	}
+	if c.AdminKey != "" {
+		if _, err := DecodePublicKey(c.AdminKey); err != nil {
+			return fmt.Errorf("admin key: %w", err)
+		}
+	}
+	if c.TLS != nil {
+		for _, op := range c.TLS.Operators {
+			if _, ok := c.Node(op); ok {
+				return fmt.Errorf("operator %q has the ID of a node", op)
+			}
+		}
+	}
+	_, err := NewMembership(ids...)
+	return err
+}
+
+// Membership returns the cluster the config describes.
+func (c *ClusterConfig) Membership() (Membership, error) {
+	ids := make([]string, len(c.Nodes))
+	for i, n := range c.Nodes {
+		ids[i] = n.ID
+	}
+	return NewMembership(ids...)
+}
+
+// Node returns the entry for id.
+func (c *ClusterConfig) Node(id string) (NodeConfig, bool) {
+	for _, n := range c.Nodes {
+		if n.ID == id {
+			return n, true
+		}
+	}
+	return NodeConfig{}, false
+}
+
+// EncodePublicKey formats key the way cluster configs list it.
+func EncodePublicKey(key ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// DecodePublicKey parses a key as listed in a cluster config.
+func DecodePublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key: %d bytes, expected %d", len(b), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// WritePrivateKey stores key at path as a PKCS #8 PEM file only the owner
+// can read.
+func WritePrivateKey(path string, key ed25519.PrivateKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+}
+
+// LoadPrivateKey reads an ed25519 key written by WritePrivateKey (or by
+// `openssl genpkey -algorithm ed25519`).
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%s: no PEM private key found", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: %T is not an ed25519 key", path, parsed)
+	}
+	return key, nil
+}
@@ -0,0 +1,142 @@
+package network_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+)
+
+func testKey(t *testing.T) string {
+	t.Helper()
+	pub, _ := network.GenerateKey()
+	return network.EncodePublicKey(pub)
+}
+
+func TestClusterConfig_Parse(t *testing.T) {
+	src := fmt.Sprintf(`{"nodes": [
+		{"id": "node1", "address": "localhost:50051", "public_key": %q},
+		{"id": "node2", "address": "localhost:50052", "public_key": %q},
+		{"id": "node3", "address": "localhost:50053", "public_key": %q},
+		{"id": "node4", "address": "localhost:50054", "public_key": %q}
+	]}`, testKey(t), testKey(t), testKey(t), testKey(t))
+
+	cfg, err := network.ParseClusterConfig(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ParseClusterConfig: %v", err)
+	}
+	m, err := cfg.Membership()
+	if err != nil {
+		t.Fatalf("Membership: %v", err)
+	}
+	if m.N() != 4 || m.Quorum() != 3 {
+		t.Errorf("expected 4 members with quorum 3, got %v", m)
+	}
+	if n, ok := cfg.Node("node3"); !ok || n.Address != "localhost:50053" {
+		t.Errorf("expected node3 at localhost:50053, got %+v", n)
+	}
+}
+
+func TestClusterConfig_RejectsInvalid(t *testing.T) {
+	key := testKey(t)
+	tests := []struct {
+		name, src, want string
+	}{
+		{"duplicate id",
+			fmt.Sprintf(`{"nodes": [{"id": "a", "address": "h:1", "public_key": %q}, {"id": "a", "address": "h:2", "public_key": %q}]}`, key, key),
+			"listed twice"},
+		{"duplicate address",
+			fmt.Sprintf(`{"nodes": [{"id": "a", "address": "h:1", "public_key": %q}, {"id": "b", "address": "h:1", "public_key": %q}]}`, key, key),
+			"share address"},
+		{"missing address",
+			fmt.Sprintf(`{"nodes": [{"id": "a", "public_key": %q}]}`, key),
+			"no address"},
+		{"short key",
+			`{"nodes": [{"id": "a", "address": "h:1", "public_key": "AAAA"}]}`,
+			"invalid public key"},
+		{"unknown field",
+			`{"nodes": [], "leader": "a"}`,
+			"unknown field"},
+		{"no nodes",
+			`{"nodes": []}`,
+			"no members"},
+		{"tls without a certificate",
+			fmt.Sprintf(`{"nodes": [{"id": "a", "address": "h:1", "public_key": %q}], "tls": {"ca": "ca.crt"}}`, key),
+			"no TLS certificate"},
+		{"tls without a CA",
+			fmt.Sprintf(`{"nodes": [{"id": "a", "address": "h:1", "public_key": %q, "cert": "a.crt"}], "tls": {}}`, key),
+			"without a CA"},
+		{"node as an operator",
+			fmt.Sprintf(`{"nodes": [{"id": "a", "address": "h:1", "public_key": %q, "cert": "a.crt"}], "tls": {"ca": "ca.crt", "operators": ["a"]}}`, key),
+			"ID of a node"},
+		{"short admin key",
+			fmt.Sprintf(`{"nodes": [{"id": "a", "address": "h:1", "public_key": %q}], "admin_key": "AAAA"}`, key),
+			"admin key"},
+	}
+	for _, tt := range tests {
+		_, err := network.ParseClusterConfig(strings.NewReader(tt.src))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestPrivateKey_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node1.key")
+	_, key := network.GenerateKey()
+	if err := network.WritePrivateKey(path, key); err != nil {
+		t.Fatalf("WritePrivateKey: %v", err)
+	}
+	got, err := network.LoadPrivateKey(path)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey: %v", err)
+	}
+	if !got.Equal(key) {
+		t.Error("loaded key differs from the one written")
+	}
+}
+
+func TestNode_ConnectRetriesUntilPeerIsUp(t *testing.T) {
+	// Reserve an address, then free it so the peer is down at first.
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	peer := network.NewNode("node2", addr, vm.NewVM(nil, io.Discard), nil)
+	node := network.NewNode("node1", "", vm.NewVM(nil, io.Discard), nil)
+	node.SetBackoff(network.Backoff{Initial: 20 * time.Millisecond, Max: 100 * time.Millisecond})
+	t.Cleanup(node.Stop)
+	if err := node.ConnectToPeer(peer.ID, addr, peer.PublicKey()); err != nil {
+		t.Fatalf("ConnectToPeer: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	err = node.WaitForPeers(ctx, 1)
+	cancel()
+	if err == nil {
+		t.Fatal("expected WaitForPeers to time out while the peer is down")
+	}
+
+	lis, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("could not listen on %s again: %v", addr, err)
+	}
+	go func() { _ = peer.Serve(lis) }()
+	t.Cleanup(peer.Stop)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := node.WaitForPeers(ctx, 1); err != nil {
+		t.Errorf("expected the peer to become reachable once up: %v", err)
+	}
+}
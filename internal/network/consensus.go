@@ -1,12 +1,11 @@
 package network
 
 import (
-	"crypto/sha256"
-	"encoding/binary"
-	"encoding/hex"
+	"crypto/ed25519"
 	"fmt"
-	"log"
+	"sort"
 	"sync"
+	"time"
 
 	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
 )
@@ -19,55 +18,257 @@ const (
 	Prepare
 	Commit
 	Finalize
+	ViewChange
 )
 
+var consensusStateNames = [...]string{
+	INITIAL:    "INITIAL",
+	PrePrepare: "PrePrepare",
+	Prepare:    "Prepare",
+	Commit:     "Commit",
+	Finalize:   "Finalize",
+	ViewChange: "ViewChange",
+}
+
+func (s ConsensusState) String() string {
+	if s < 0 || int(s) >= len(consensusStateNames) {
+		return fmt.Sprintf("ConsensusState(%d)", int(s))
+	}
+	return consensusStateNames[s]
+}
+
+// DefaultLogWindow is the distance between the low and high watermarks: how
+// many slots past the last applied one may be in progress at once.
+const DefaultLogWindow = 64
+
+// Consensus runs PBFT over a log of numbered slots. The primary of the
+// current view assigns each proposal the next sequence number; every slot
+// goes through its own prepare and commit phases, and decided slots are
+// applied to the node's VM strictly in sequence order.
 type Consensus struct {
+	*executions
+
 	node           *Node
-	state          ConsensusState
 	currentView    int64
-	prepareCount   map[string]int
-	commitCount    map[string]int
+	prepares       votes // PREPAREs seen, by voteKey(view, seq, hash)
+	commits        votes // COMMITs seen, by voteKey(view, seq, hash)
 	mu             sync.Mutex
-	decidedValue   *pb.ConsensusMessage
+	decidedValue   *pb.ConsensusMessage // the last slot applied
 	decisionQuorum int
+	membership     Membership        // the members of config
+	config         *pb.Configuration // the current epoch; see reconfig.go
+	prevConfig     *pb.Configuration // the epoch before it, if any
+	adminKey       ed25519.PublicKey // checks membership changes; nil refuses them
+
+	slots       map[int64]*slot
+	nextSeq     int64 // sequence number the next proposal gets
+	lastApplied int64 // low watermark: every slot up to here is applied
+	window      int64 // high watermark = lastApplied + window, within the epoch
+
+	// lastDecided is the highest slot decided so far, and
+	// lastDecidedDigest the digest of the request decided in it.
+	lastDecided       int64
+	lastDecidedDigest string
+
+	timeouts    Timeouts
+	clock       Clock
+	timer       Timer
+	timerGen    uint64    // invalidates timers that fire after being replaced
+	timerFor    timerSlot // what the running timer is waiting on
+	changing    bool      // a view change is in progress
+	pendingView int64     // view this node is trying to move to while changing
+	viewChanges map[int64]map[string]*pb.ConsensusMessage
+
+	// backoff doubles the phase timeouts once for every view in a row that
+	// ended without this node deciding a slot, so a slow but correct
+	// primary eventually gets the time it needs. progress records whether
+	// the current view has decided one.
+	backoff  uint
+	progress bool
+
+	wal                *WAL  // nil if this node keeps nothing on disk
+	replaying          bool  // Recover is rebuilding state from the WAL
+	checkpointInterval int64 // slots between checkpoints
+
+	checkpoints  map[int64]map[string]*pb.ConsensusMessage // seq → sender → CHECKPOINT
+	snapshots    map[int64]*pb.StateSnapshot               // this node's state at checkpoints not yet stable
+	stable       *pb.StableCheckpoint                      // latest stable checkpoint; nil if none
+	stableState  *pb.VMState                               // the state stable certifies
+	stableConfig *pb.Configuration                         // and the configuration
+	fetching     bool                                      // CatchUp is running
+
+	batching   Batching
+	queue      []*queuedRequest       // requests waiting in Submit
+	batchTimer Timer                  // proposes the queue when its delay is up
+	outbox     []*pb.ConsensusMessage // messages bundle holds back; nil if not bundling
 }
 
-func stateHash(state *pb.VMState) string {
-	// Create a compact deterministic key for the VM state.
-	// This avoids using fmt on the full `memory` byte slice in quorum maps.
-	if state == nil {
-		return "nil"
-	}
+// voteID scopes a vote to the view and slot it was cast in, so votes that
+// arrive before the node has entered that view are kept rather than lost.
+type voteID struct {
+	view, seq int64
+	request   string // digest of the request voted for
+}
+
+func voteKey(view, seq int64, req *pb.Execution) voteID {
+	return voteID{view: view, seq: seq, request: requestDigest(req)}
+}
+
+// votes records the signed message each node cast a vote with, by sender,
+// so a node that sends the same vote twice is still only counted once
+// toward a quorum, and a quorum can be shown to other nodes.
+type votes map[voteID]map[string]*pb.ConsensusMessage
 
-	h := sha256.New()
-	_, _ = h.Write(state.Memory)
+func (v votes) add(key voteID, msg *pb.ConsensusMessage) {
+	if v[key] == nil {
+		v[key] = make(map[string]*pb.ConsensusMessage)
+	}
+	v[key][msg.Sender] = msg
+}
 
-	var b [8]byte
-	binary.LittleEndian.PutUint32(b[0:4], state.Pc)
-	h.Write(b[0:4])
+func (v votes) count(key voteID) int { return len(v[key]) }
 
-	binary.LittleEndian.PutUint32(b[0:4], uint32(state.Acc))
-	h.Write(b[0:4])
+// proof returns the messages cast for key, ordered by sender.
+func (v votes) proof(key voteID) []*pb.ConsensusMessage {
+	msgs := make([]*pb.ConsensusMessage, 0, len(v[key]))
+	for _, msg := range v[key] {
+		msgs = append(msgs, msg)
+	}
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].Sender < msgs[j].Sender })
+	return msgs
+}
 
-	return hex.EncodeToString(h.Sum(nil))
+// collect forgets the votes for slots up to seq.
+func (v votes) collect(seq int64) {
+	for key := range v {
+		if key.seq <= seq {
+			delete(v, key)
+		}
+	}
 }
 
-func NewConsensus(node *Node) *Consensus {
+// NewConsensus returns the consensus instance node runs as one of members.
+func NewConsensus(node *Node, members Membership) (*Consensus, error) {
 	if node == nil {
-		log.Println("Error: Cannot create Consensus with nil Node")
-		return nil
+		return nil, fmt.Errorf("cannot create consensus with a nil node")
+	}
+	if !members.Contains(node.ID) {
+		return nil, fmt.Errorf("node %s is not a member of the cluster %v", node.ID, members.IDs())
 	}
 
-	return &Consensus{
+	c := &Consensus{
+		executions:     newExecutions(node, members.Quorum()),
 		node:           node,
-		state:          INITIAL,
 		currentView:    0,
-		prepareCount:   make(map[string]int),
-		commitCount:    make(map[string]int),
-		decisionQuorum: (len(node.Peers) * 2 / 3) + 1,
+		prepares:       make(votes),
+		commits:        make(votes),
+		decisionQuorum: members.Quorum(),
+		membership:     members,
+		config:         genesis(members),
+		slots:          make(map[int64]*slot),
+		nextSeq:        1,
+		window:         DefaultLogWindow,
+		timeouts:       DefaultTimeouts,
+		clock:          systemClock{},
+		viewChanges:    make(map[int64]map[string]*pb.ConsensusMessage),
+
+		checkpointInterval: DefaultCheckpointInterval,
+		checkpoints:        make(map[int64]map[string]*pb.ConsensusMessage),
+		snapshots:          make(map[int64]*pb.StateSnapshot),
+		batching:           DefaultBatching,
 	}
+	c.executions.reconfigure = c.reconfigure
+	return c, nil
+}
+
+// Membership returns the cluster this node is a member of in the current
+// epoch.
+func (c *Consensus) Membership() Membership {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.membership
 }
 
+// Primary returns the ID of the node that leads view: the cluster members
+// take turns in ID order.
+func (c *Consensus) Primary(view int64) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.primary(view)
+}
+
+// primary is Primary with c.mu held.
+func (c *Consensus) primary(view int64) string {
+	ids := c.membership.ids
+	return ids[view%int64(len(ids))]
+}
+
+// View returns the view this node is currently in.
+func (c *Consensus) View() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.currentView
+}
+
+// Leader returns the primary of the current view.
+func (c *Consensus) Leader() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.primary(c.currentView)
+}
+
+// Handle passes msg to the handler for its type.
+func (c *Consensus) Handle(msg *pb.ConsensusMessage) error {
+	if len(msg.Batch) > 0 {
+		_, err := c.HandleBatch(msg)
+		return err
+	}
+	var err error
+	switch msg.Type {
+	case pb.ConsensusMessage_PRE_PREPARE:
+		_, err = c.HandlePrePrepare(msg)
+	case pb.ConsensusMessage_PREPARE:
+		_, err = c.HandlePrepare(msg)
+	case pb.ConsensusMessage_COMMIT:
+		_, err = c.HandleCommit(msg)
+	case pb.ConsensusMessage_VIEW_CHANGE:
+		_, err = c.HandleViewChange(msg)
+	case pb.ConsensusMessage_NEW_VIEW:
+		_, err = c.HandleNewView(msg)
+	case pb.ConsensusMessage_RESULT:
+		_, err = c.HandleResult(msg)
+	case pb.ConsensusMessage_CHECKPOINT:
+		_, err = c.HandleCheckpoint(msg)
+	default:
+		err = fmt.Errorf("unknown message type %v", msg.Type)
+	}
+	return err
+}
+
+// SetLogWindow sets the distance between the low and high watermarks.
+// Every member of the cluster must use the same window, since membership
+// changes take over a window after they are applied.
+func (c *Consensus) SetLogWindow(n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.window = n
+}
+
+// Watermarks returns the sequence numbers this node currently accepts
+// messages for: those above low and up to high.
+func (c *Consensus) Watermarks() (low, high int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastApplied, c.highWatermark()
+}
+
+func (c *Consensus) inWindow(seq int64) bool {
+	return seq > c.lastApplied && seq <= c.highWatermark()
+}
+
+// StartConsensus proposes msg.Request for the next free slot and records the
+// slot's sequence number in msg.Sequence. Only the primary of the current
+// view may call it; earlier slots do not have to be decided first.
 func (c *Consensus) StartConsensus(msg *pb.ConsensusMessage) error {
 	if c == nil {
 		return fmt.Errorf("consensus object is nil")
@@ -75,131 +276,310 @@ func (c *Consensus) StartConsensus(msg *pb.ConsensusMessage) error {
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if err := c.canPropose(1); err != nil {
+		return err
+	}
+	return c.propose(msg)
+}
 
-	if c.state != INITIAL {
-		return fmt.Errorf("consensus already in progress, current state: %v", c.state)
+// canPropose checks that this node may propose n requests now: it is the
+// primary of the current view, which it is not trying to leave, and there
+// is room for them below the high watermark. c.mu must be held.
+func (c *Consensus) canPropose(n int64) error {
+	if c.changing {
+		return fmt.Errorf("view change to view %d in progress", c.pendingView)
+	}
+	if primary := c.primary(c.currentView); primary != c.node.ID {
+		return fmt.Errorf("node %s is not the primary for view %d (primary is %s)",
+			c.node.ID, c.currentView, primary)
+	}
+	if last := c.nextSeq + n - 1; n > 0 && !c.inWindow(last) {
+		return fmt.Errorf("sequence %d is above the high watermark %d", last, c.highWatermark())
 	}
+	return nil
+}
 
-	log.Printf("Starting consensus for view %d", c.currentView+1)
-	c.state = PrePrepare
-	c.currentView++
-	c.prepareCount = make(map[string]int)
-	c.commitCount = make(map[string]int)
+// propose gives msg.Request the next free slot and sends it out as a
+// PRE_PREPARE. c.mu must be held, and canPropose must have allowed it.
+func (c *Consensus) propose(msg *pb.ConsensusMessage) error {
+	seq := c.nextSeq
+	c.nextSeq++
+	c.node.metrics.roundsStarted.Inc()
 
+	c.node.logger.Debug("starting consensus", "view", c.currentView, "seq", seq, "request", msg.Request.GetId())
 	msg.View = c.currentView
+	msg.Sequence = seq
 	msg.Type = pb.ConsensusMessage_PRE_PREPARE
+	c.node.Sign(msg)
+
+	s := c.slot(seq)
+	s.accept(msg.Request, PrePrepare)
+	c.prepares.add(voteKey(c.currentView, seq, msg.Request), msg)
+	if err := c.persistAccept(msg); err != nil {
+		return err
+	}
 
-	return c.node.Broadcast(msg)
+	c.resetTimer()
+	if err := c.broadcast(msg); err != nil {
+		return err
+	}
+	return c.checkPrepared(s)
 }
 
 func (c *Consensus) HandlePrePrepare(msg *pb.ConsensusMessage) (*pb.Empty, error) {
 	if c == nil {
-		log.Println("Error: Consensus object is nil in HandlePrePrepare")
 		return nil, fmt.Errorf("consensus object is nil")
 	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	return &pb.Empty{}, c.handlePrePrepare(msg)
+}
+
+// handlePrePrepare accepts the primary's proposal in msg. c.mu must be
+// held.
+func (c *Consensus) handlePrePrepare(msg *pb.ConsensusMessage) error {
+	if msg.View != c.currentView || c.changing {
+		return fmt.Errorf("PrePrepare for view %d, current view is %d", msg.View, c.currentView)
+	}
+	if primary := c.primary(msg.View); msg.Sender != primary {
+		return fmt.Errorf("PrePrepare for view %d from %s, primary is %s", msg.View, msg.Sender, primary)
+	}
+	if !c.inWindow(msg.Sequence) {
+		return fmt.Errorf("PrePrepare for sequence %d outside the log window", msg.Sequence)
+	}
+	// A slot may only be opened once per view.
+	s := c.slot(msg.Sequence)
+	if s.accepted {
+		return fmt.Errorf("duplicate PrePrepare for sequence %d in view %d", msg.Sequence, msg.View)
+	}
 
-	// Replicas receive PRE_PREPARE when idle (INITIAL); leader is in PrePrepare when it sends.
-	if c.state != INITIAL && c.state != PrePrepare {
-		return nil, fmt.Errorf("incorrect state for PrePrepare: current state %v", c.state)
+	// Only the operator may change the membership, whatever the primary
+	// says. A primary that proposes a change the operator did not sign has
+	// signed the proof that it is faulty, so the view changes at once.
+	if change := msg.Request.GetReconfiguration(); change != nil {
+		if err := c.verifyChange(change); err != nil {
+			if c.adminKey != nil {
+				c.node.logger.Warn("primary proposed an unsigned membership change", "view", msg.View,
+					"seq", msg.Sequence, "primary", msg.Sender)
+				c.startViewChange(c.currentView + 1)
+			}
+			return fmt.Errorf("PrePrepare for sequence %d: %w", msg.Sequence, err)
+		}
 	}
 
-	log.Printf("Handling PrePrepare for view %d", msg.View)
-	c.state = Prepare
-	c.currentView = msg.View
+	c.node.logger.Debug("handling PrePrepare", "view", msg.View, "seq", msg.Sequence, "request", msg.Request.GetId())
+	// PRE_PREPARE counts as the primary's PREPARE.
+	c.prepares.add(voteKey(msg.View, msg.Sequence, msg.Request), msg)
+	return c.acceptProposal(s, msg)
+}
+
+// acceptProposal enters the prepare phase for the proposal in pp, the
+// primary's signed PRE_PREPARE for slot s, and casts this node's PREPARE
+// vote.
+func (c *Consensus) acceptProposal(s *slot, pp *pb.ConsensusMessage) error {
+	req := pp.Request
+	s.accept(req, Prepare)
+	c.nextSeq = max(c.nextSeq, s.seq+1)
+	if err := c.persistAccept(pp); err != nil {
+		return err
+	}
 
 	prepareMsg := &pb.ConsensusMessage{
-		Type:  pb.ConsensusMessage_PREPARE,
-		View:  c.currentView,
-		State: msg.State,
+		Type:     pb.ConsensusMessage_PREPARE,
+		View:     c.currentView,
+		Sequence: s.seq,
+		Request:  req,
+		Sender:   c.node.ID,
 	}
 
-	// Count our own PREPARE so replicas reach quorum (each has 2 peers; we need 2 PREPARE;
-	// we send one and receive one from the other replica).
-	valueHash := stateHash(msg.State)
-	c.prepareCount[valueHash] = 1
+	// Count our own PREPARE: the quorum includes this node.
+	c.prepares.add(voteKey(c.currentView, s.seq, req), prepareMsg)
 
-	err := c.node.Broadcast(prepareMsg)
-	if err != nil {
-		return &pb.Empty{}, err
+	c.resetTimer()
+	if err := c.broadcast(prepareMsg); err != nil {
+		return err
 	}
-	return &pb.Empty{}, nil
+	return c.checkPrepared(s)
 }
 
 func (c *Consensus) HandlePrepare(msg *pb.ConsensusMessage) (*pb.Empty, error) {
 	if c == nil {
-		log.Println("Error: Consensus object is nil in HandlePrepare")
 		return nil, fmt.Errorf("consensus object is nil")
 	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	return &pb.Empty{}, c.handlePrepare(msg)
+}
 
-	// Leader stays in PrePrepare when it receives PREPARE; replicas are in Prepare.
-	if c.state != Prepare && c.state != PrePrepare {
-		return nil, fmt.Errorf("incorrect state for Prepare: current state %v", c.state)
+// handlePrepare records the vote in msg. c.mu must be held.
+func (c *Consensus) handlePrepare(msg *pb.ConsensusMessage) error {
+	if msg.View < c.currentView {
+		return fmt.Errorf("Prepare for old view %d, current view is %d", msg.View, c.currentView)
+	}
+	if !c.inWindow(msg.Sequence) {
+		return fmt.Errorf("Prepare for sequence %d outside the log window", msg.Sequence)
 	}
 
-	log.Printf("Handling Prepare for view %d", msg.View)
-	valueHash := stateHash(msg.State)
-	c.prepareCount[valueHash]++
+	c.node.logger.Debug("handling Prepare", "view", msg.View, "seq", msg.Sequence, "from", msg.Sender)
+	c.prepares.add(voteKey(msg.View, msg.Sequence, msg.Request), msg)
+	if msg.View != c.currentView {
+		return nil
+	}
+	s := c.slot(msg.Sequence)
+	c.resetTimer()
+	return c.checkPrepared(s)
+}
 
-	if c.prepareCount[valueHash] >= c.decisionQuorum {
-		c.state = Commit
-		commitMsg := &pb.ConsensusMessage{
-			Type:  pb.ConsensusMessage_COMMIT,
-			View:  c.currentView,
-			State: msg.State,
-		}
-		err := c.node.Broadcast(commitMsg)
-		return &pb.Empty{}, err
+// checkPrepared moves slot s to the commit phase once its proposal has a
+// quorum of PREPAREs. Votes may arrive before the PRE_PREPARE they refer
+// to, so this runs after every change to either.
+func (c *Consensus) checkPrepared(s *slot) error {
+	// The primary stays in PrePrepare until its proposal is prepared.
+	if c.changing || (s.phase != PrePrepare && s.phase != Prepare) {
+		return nil
+	}
+	if c.prepares.count(voteKey(c.currentView, s.seq, s.proposal)) < c.decisionQuorum {
+		return nil
 	}
 
-	return &pb.Empty{}, nil
+	if !c.replaying {
+		c.node.metrics.observePhase("prepare", s.since)
+	}
+	s.phase = Commit
+	s.since = time.Now()
+	s.prepared = s.proposal
+	s.preparedView = c.currentView
+	s.proof = c.prepares.proof(voteKey(c.currentView, s.seq, s.proposal))
+	if err := c.persistPrepared(s); err != nil {
+		return err
+	}
+
+	commitMsg := &pb.ConsensusMessage{
+		Type:     pb.ConsensusMessage_COMMIT,
+		View:     c.currentView,
+		Sequence: s.seq,
+		Request:  s.proposal,
+		Sender:   c.node.ID,
+	}
+	c.commits.add(voteKey(c.currentView, s.seq, s.proposal), commitMsg)
+	c.resetTimer()
+	if err := c.broadcast(commitMsg); err != nil {
+		return err
+	}
+	c.checkCommitted(s)
+	return nil
 }
 
 func (c *Consensus) HandleCommit(msg *pb.ConsensusMessage) (*pb.Empty, error) {
 	if c == nil {
-		log.Println("Error: Consensus object is nil in HandleCommit")
 		return nil, fmt.Errorf("consensus object is nil")
 	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	return &pb.Empty{}, c.handleCommit(msg)
+}
+
+// handleCommit records the vote in msg. c.mu must be held.
+func (c *Consensus) handleCommit(msg *pb.ConsensusMessage) error {
+	if msg.View < c.currentView {
+		return fmt.Errorf("Commit for old view %d, current view is %d", msg.View, c.currentView)
+	}
+	if !c.inWindow(msg.Sequence) {
+		return fmt.Errorf("Commit for sequence %d outside the log window", msg.Sequence)
+	}
+
+	c.node.logger.Debug("handling Commit", "view", msg.View, "seq", msg.Sequence, "from", msg.Sender)
+	c.commits.add(voteKey(msg.View, msg.Sequence, msg.Request), msg)
+	if msg.View != c.currentView {
+		return nil
+	}
+	s := c.slot(msg.Sequence)
+	c.checkCommitted(s)
+	c.resetTimer()
+	return nil
+}
+
+// checkCommitted decides slot s once its proposal has a quorum of COMMITs.
+func (c *Consensus) checkCommitted(s *slot) {
+	if c.changing || s.phase != Commit {
+		return
+	}
+	if c.commits.count(voteKey(c.currentView, s.seq, s.proposal)) < c.decisionQuorum {
+		return
+	}
 
-	if c.state != Commit {
-		return nil, fmt.Errorf("incorrect state for Commit: current state %v", c.state)
-	}
-
-	log.Printf("Handling Commit for view %d", msg.View)
-	valueHash := stateHash(msg.State)
-	c.commitCount[valueHash]++
-
-	if c.commitCount[valueHash] >= c.decisionQuorum {
-		c.state = Finalize
-		c.decidedValue = msg
-		if msg.State != nil {
-			log.Printf(
-				"Consensus reached: view=%d pc=%d acc=%d",
-				msg.View,
-				msg.State.Pc,
-				msg.State.Acc,
-			)
-		} else {
-			log.Printf("Consensus reached: view=%d", msg.View)
+	if !c.replaying {
+		c.node.metrics.observePhase("commit", s.since)
+		c.node.metrics.roundsDecided.Inc()
+	}
+	s.phase = Finalize
+	c.progress = true
+	c.backoff = 0
+	s.decided = &pb.ConsensusMessage{
+		Type:     pb.ConsensusMessage_COMMIT,
+		View:     c.currentView,
+		Sequence: s.seq,
+		Request:  s.proposal,
+	}
+	if s.seq > c.lastDecided {
+		c.lastDecided, c.lastDecidedDigest = s.seq, requestDigest(s.proposal)
+	}
+	if err := c.persist(pb.WALRecord_DECIDE, s.decided); err != nil {
+		c.node.logger.Error("persisting decision failed", "seq", s.seq, "err", err)
+	}
+	if s.proposal != nil {
+		c.node.logger.Info("consensus reached", "view", c.currentView, "seq", s.seq,
+			"request", s.proposal.Id, "digest", fmt.Sprintf("%.12s", requestDigest(s.proposal)))
+	} else {
+		c.node.logger.Info("consensus reached on a no-op", "view", c.currentView, "seq", s.seq)
+	}
+	c.applyDecided()
+}
+
+// applyDecided executes every decided slot that directly follows the last
+// applied one on the node's VM, moving the low watermark up. A slot decided out of order
+// waits here until the gap before it is filled.
+func (c *Consensus) applyDecided() {
+	for {
+		s, ok := c.slots[c.lastApplied+1]
+		if !ok || s.decided == nil {
+			// Requests that waited for room in the log window may fit now.
+			if len(c.queue) > 0 && c.batchTimer == nil && !c.replaying {
+				c.flushQueue()
+			}
+			c.closeEpoch()
+			return
+		}
+		c.lastApplied = s.seq
+		c.decidedValue = s.decided
+		// Slots the view change filled with no-ops have nothing to run.
+		if msg := c.apply(s.seq, c.currentView, s.decided.Request); msg != nil {
+			if err := c.node.Broadcast(msg); err != nil {
+				c.node.logger.Warn("sending result failed", "seq", s.seq, "err", err)
+			}
 		}
-		// Update the node's VM state
-		c.node.VM.UpdateState(c.decidedValue.State)
+		if c.checkpointInterval > 0 && s.seq%c.checkpointInterval == 0 {
+			c.checkpoint()
+		}
+		c.maybeEnterEpoch()
 	}
+}
 
-	return &pb.Empty{}, nil
+// HandleResult records another replica's result digest for a slot.
+func (c *Consensus) HandleResult(msg *pb.ConsensusMessage) (*pb.Empty, error) {
+	c.mu.Lock()
+	floor := c.stable.GetSequence()
+	c.mu.Unlock()
+	return &pb.Empty{}, c.handleResult(msg, floor)
 }
 
+// GetDecidedValue returns the last slot applied to the VM, or nil if none
+// has been.
 func (c *Consensus) GetDecidedValue() *pb.ConsensusMessage {
 	if c == nil {
-		log.Println("Error: Consensus object is nil in GetDecidedValue")
 		return nil
 	}
 
@@ -207,3 +587,58 @@ func (c *Consensus) GetDecidedValue() *pb.ConsensusMessage {
 	defer c.mu.Unlock()
 	return c.decidedValue
 }
+
+// Decided returns the decision for slot seq, or nil if it is not decided.
+func (c *Consensus) Decided(seq int64) *pb.ConsensusMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.slots[seq]; ok {
+		return s.decided
+	}
+	return nil
+}
+
+// LastApplied returns the sequence number of the last slot applied to the
+// VM.
+func (c *Consensus) LastApplied() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastApplied
+}
+
+// NodeState returns how far this node has got through the log and the
+// state of its VM.
+func (c *Consensus) NodeState() *pb.NodeState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &pb.NodeState{
+		NodeId:      c.node.ID,
+		View:        c.currentView,
+		Primary:     c.primary(c.currentView),
+		LastApplied: c.lastApplied,
+		State:       vmState(c.node.VM),
+	}
+}
+
+// Status reports the view, its primary and the phase this replica is in.
+func (c *Consensus) Status() *pb.NodeStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := &pb.NodeStatus{
+		Replication:       string(ReplicationPBFT),
+		View:              c.currentView,
+		Leader:            c.primary(c.currentView),
+		Phase:             c.state().String(),
+		LastApplied:       c.lastApplied,
+		LastDecided:       c.lastDecided,
+		LastDecidedDigest: c.lastDecidedDigest,
+		Epoch:             c.config.Epoch,
+		Members:           c.membership.IDs(),
+	}
+	if next := c.config.Next; next != nil {
+		members, _ := membershipOf(next)
+		st.NextMembers = members.IDs()
+		st.NextEpochStart = next.Start
+	}
+	return st
+}
@@ -0,0 +1,429 @@
+package network
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultMaxSteps bounds an execution that does not set its own limit, so a
+// program that never halts cannot stall the log.
+const DefaultMaxSteps = 100000
+
+// MaxSteps is the most steps any execution may ask for. Replicas run
+// programs while holding the consensus lock, so a request for more is
+// refused rather than allowed to stall the log; every replica refuses it
+// the same way, so the result stays deterministic.
+const MaxSteps = 10 * DefaultMaxSteps
+
+// NewExecution packages a compiled program and the input tape it reads as a
+// request the cluster can order.
+func NewExecution(bytecode []byte, data map[uint8]byte, input []byte) *pb.Execution {
+	req := &pb.Execution{
+		Bytecode:    bytecode,
+		InitialData: make(map[uint32]uint32, len(data)),
+		Input:       input,
+	}
+	for addr, val := range data {
+		req.InitialData[uint32(addr)] = uint32(val)
+	}
+	return req
+}
+
+// Execute runs req on machine and returns what it produced. The data
+// segment carries over from earlier executions, so replicas that apply the
+// same log from the same starting state produce the same results.
+func Execute(machine *vm.VM, req *pb.Execution) *pb.ExecutionResult {
+	res := &pb.ExecutionResult{}
+	var out bytes.Buffer
+	if err := machine.LoadProgram(req.Bytecode); err != nil {
+		res.Fault = err.Error()
+	} else {
+		for addr, val := range req.InitialData {
+			if addr >= vm.DataSegmentSize || val > 0xFF {
+				res.Fault = fmt.Sprintf("initial data %d at address %d is out of range", val, addr)
+				break
+			}
+			machine.Memory.Write(uint16(addr), byte(val))
+		}
+	}
+	steps := req.MaxSteps
+	if steps <= 0 {
+		steps = DefaultMaxSteps
+	}
+	if res.Fault == "" && steps > MaxSteps {
+		res.Fault = fmt.Sprintf("step limit %d is above the maximum of %d", steps, MaxSteps)
+	}
+	if res.Fault == "" {
+		machine.SetIO(bytes.NewReader(req.Input), &out)
+		if err := machine.RunSteps(int(steps)); err != nil {
+			res.Fault = err.Error()
+		}
+	}
+	res.Output = out.Bytes()
+	res.State = vmState(machine)
+	return res
+}
+
+// vmState snapshots the memory and registers of machine, along with the
+// Merkle root of memory, which only rehashes the pages written since the
+// last snapshot.
+func vmState(machine *vm.VM) *pb.VMState {
+	return &pb.VMState{
+		Memory:     bytes.Clone(machine.Memory.Data[:]),
+		Pc:         uint32(machine.Registers.PC),
+		Acc:        machine.Registers.ACC,
+		MemoryRoot: machine.Memory.Root(),
+	}
+}
+
+// commitment returns what digests of state cover: its registers and the
+// Merkle root of its memory, which state.MemoryRoot must hold.
+func commitment(state *pb.VMState) *pb.VMState {
+	return &pb.VMState{
+		Pc:         state.GetPc(),
+		Acc:        state.GetAcc(),
+		MemoryRoot: state.GetMemoryRoot(),
+	}
+}
+
+// digest hashes the deterministic encoding of m. Messages built by this
+// package always marshal, so the error is ignored.
+func digest(m proto.Message) string {
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// requestDigest identifies a proposed execution in votes.
+func requestDigest(req *pb.Execution) string {
+	if req == nil {
+		return "nil"
+	}
+	return digest(req)
+}
+
+// resultDigest identifies what an execution produced in RESULT messages.
+// It covers the state through its commitment, so replicas do not hash all
+// of memory for every slot.
+func resultDigest(res *pb.ExecutionResult) string {
+	if res == nil {
+		return "nil"
+	}
+	return digest(&pb.ExecutionResult{
+		State:  commitment(res.State),
+		Output: res.Output,
+		Fault:  res.Fault,
+	})
+}
+
+// Divergence records a replica whose result for a slot disagreed with the
+// result this node expected.
+type Divergence struct {
+	Sequence int64
+	Node     string
+	Digest   string // what Node reported
+	Expected string // what this node, or the quorum, computed
+}
+
+func (d Divergence) String() string {
+	return fmt.Sprintf("%s diverged at sequence %d: result %.12s, expected %.12s",
+		d.Node, d.Sequence, d.Digest, d.Expected)
+}
+
+// executions is the VM apply path every replication mode shares. Decided
+// requests run on the node's VM strictly in log order, each replica tells
+// the others the digest of what it got, and a result is certified once a
+// quorum agrees with it or diverged once a quorum agrees on another. It
+// keeps what clients read about their requests, under its own lock; the
+// replicator that feeds it must serialize calls to apply.
+type executions struct {
+	node   *Node
+	quorum int
+
+	// reconfigure applies a membership change decided at seq in place of
+	// running a program, and returns its result. Replicators that cannot
+	// change their membership leave it nil.
+	reconfigure func(seq int64, change *pb.Reconfiguration) *pb.ExecutionResult
+
+	mu          sync.Mutex
+	applied     int64                       // the last sequence applied or skipped
+	entries     map[int64]*execution        // sequence → what ran there; no-ops have none
+	results     map[int64]map[string]string // sequence → replica → result digest
+	divergences []Divergence
+	requests    map[string]int64 // request ID → the sequence it ran at
+	settled     chan struct{}    // closed when a result settles
+}
+
+// execution is one request that ran on this node.
+type execution struct {
+	seq     int64
+	request *pb.Execution
+
+	// result is what running the request produced on this node. It is
+	// certified once a quorum of replicas reports the same digest, and
+	// diverged once a quorum reports a different one; reported holds the
+	// replicas already reported as divergent.
+	result       *pb.ExecutionResult
+	resultDigest string
+	certified    bool
+	diverged     bool
+	reported     map[string]bool
+}
+
+func newExecutions(node *Node, quorum int) *executions {
+	return &executions{
+		node:     node,
+		quorum:   quorum,
+		entries:  make(map[int64]*execution),
+		results:  make(map[int64]map[string]string),
+		requests: make(map[string]int64),
+		settled:  make(chan struct{}),
+	}
+}
+
+// apply runs req, decided at seq in view, on the node's VM and returns the
+// RESULT message that tells the other replicas what it got, for the caller
+// to broadcast. A nil req is a no-op: nothing runs and nil is returned.
+func (e *executions) apply(seq, view int64, req *pb.Execution) *pb.ConsensusMessage {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.applied = seq
+	if req == nil {
+		return nil
+	}
+
+	x := &execution{seq: seq, request: req}
+	if change := req.Reconfiguration; change != nil {
+		x.result = e.changeMembership(seq, change)
+	} else {
+		start := time.Now()
+		x.result = Execute(e.node.VM, req)
+		e.node.metrics.observePhase("execute", start)
+	}
+	e.node.logger.Debug("executed request", "seq", seq, "request", req.Id, "fault", x.result.Fault)
+	x.resultDigest = resultDigest(x.result)
+	e.entries[seq] = x
+	if req.Id != "" {
+		e.requests[req.Id] = seq
+	}
+	e.record(seq, e.node.ID, x.resultDigest)
+
+	return &pb.ConsensusMessage{
+		Type:     pb.ConsensusMessage_RESULT,
+		View:     view,
+		Sequence: seq,
+		Digest:   x.resultDigest,
+		Sender:   e.node.ID,
+	}
+}
+
+// changeMembership applies change, decided at seq. e.mu must be held.
+func (e *executions) changeMembership(seq int64, change *pb.Reconfiguration) *pb.ExecutionResult {
+	if e.reconfigure == nil {
+		return &pb.ExecutionResult{State: vmState(e.node.VM), Fault: "membership changes need PBFT replication"}
+	}
+	return e.reconfigure(seq, change)
+}
+
+// setQuorum changes how many matching results certify one, for the
+// membership of a new epoch.
+func (e *executions) setQuorum(quorum int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.quorum = quorum
+}
+
+// skipTo moves the apply point to seq without running anything, for a node
+// whose VM took a state from elsewhere.
+func (e *executions) skipTo(seq int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.applied = max(e.applied, seq)
+}
+
+// get returns what ran at seq, or nil.
+func (e *executions) get(seq int64) *execution {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.entries[seq]
+}
+
+// handleResult records another replica's result digest for a slot. Results
+// for slots up to floor are refused once the slot has been collected.
+func (e *executions) handleResult(msg *pb.ConsensusMessage, floor int64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.entries[msg.Sequence]; msg.Sequence <= floor && !ok {
+		return fmt.Errorf("Result for sequence %d, already settled up to %d", msg.Sequence, floor)
+	}
+	e.node.logger.Debug("handling Result", "seq", msg.Sequence, "from", msg.Sender)
+	e.record(msg.Sequence, msg.Sender, msg.Digest)
+	return nil
+}
+
+// record notes that node got digest for seq and checks the slot's results
+// against this node's own. Results may arrive before this node has
+// executed the slot; they are checked once it has. e.mu must be held.
+func (e *executions) record(seq int64, node, digest string) {
+	results, ok := e.results[seq]
+	if !ok {
+		results = make(map[string]string)
+		e.results[seq] = results
+	}
+	if _, seen := results[node]; seen {
+		return
+	}
+	results[node] = digest
+	e.check(seq)
+}
+
+// check reports every replica whose result for seq differs from this
+// node's, and reports this node itself if a quorum agrees on a different
+// result. e.mu must be held.
+func (e *executions) check(seq int64) {
+	x, ok := e.entries[seq]
+	if !ok {
+		return
+	}
+	votes := make(map[string]int)
+	for _, d := range e.results[seq] {
+		votes[d]++
+	}
+	if votes[x.resultDigest] >= e.quorum && !x.certified {
+		x.certified = true
+		e.node.metrics.results.Inc("certified")
+		e.node.logger.Debug("result certified", "seq", seq, "request", x.request.Id)
+		e.wake()
+	}
+	if x.reported == nil {
+		x.reported = make(map[string]bool)
+	}
+	for node, d := range e.results[seq] {
+		if d == x.resultDigest || x.reported[node] {
+			continue
+		}
+		x.reported[node] = true
+		e.diverged(Divergence{Sequence: seq, Node: node, Digest: d, Expected: x.resultDigest})
+	}
+	for d, n := range votes {
+		if d != x.resultDigest && n >= e.quorum && !x.diverged {
+			x.diverged = true
+			e.node.metrics.results.Inc("diverged")
+			e.diverged(Divergence{Sequence: seq, Node: e.node.ID, Digest: x.resultDigest, Expected: d})
+			e.wake()
+		}
+	}
+}
+
+func (e *executions) diverged(d Divergence) {
+	e.node.logger.Warn("result diverged", "seq", d.Sequence, "replica", d.Node, "digest", d.Digest, "expected", d.Expected)
+	e.divergences = append(e.divergences, d)
+}
+
+// wake tells everyone waiting in Reports that a result has settled. e.mu
+// must be held.
+func (e *executions) wake() {
+	close(e.settled)
+	e.settled = make(chan struct{})
+}
+
+// collect forgets the slots up to seq whose results have settled, and the
+// results reported for them. Unsettled ones are kept until a later call,
+// so clients still learn how their request ended.
+func (e *executions) collect(seq int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for n, x := range e.entries {
+		if n <= seq && (x.certified || x.diverged) {
+			delete(e.entries, n)
+		}
+	}
+	for n := range e.results {
+		if _, ok := e.entries[n]; n <= seq && !ok {
+			delete(e.results, n)
+		}
+	}
+	for id, n := range e.requests {
+		if _, ok := e.entries[n]; n <= seq && !ok {
+			delete(e.requests, id)
+		}
+	}
+}
+
+// Result returns what executing slot seq produced on this node, and whether
+// a quorum of replicas, this one included, reported the same result.
+func (e *executions) Result(seq int64) (*pb.ExecutionResult, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	x, ok := e.entries[seq]
+	if !ok {
+		return nil, false
+	}
+	return x.result, x.certified
+}
+
+// report describes x. e.mu must be held.
+func (x *execution) report() *pb.ExecutionReport {
+	return &pb.ExecutionReport{
+		RequestId: x.request.GetId(),
+		Sequence:  x.seq,
+		Result:    x.result,
+		Certified: x.certified,
+		Diverged:  x.diverged,
+	}
+}
+
+// ResultByID returns what this node knows about the request with the given
+// ID, or nil if it has not executed it.
+func (e *executions) ResultByID(id string) *pb.ExecutionReport {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	seq, ok := e.requests[id]
+	if !ok {
+		return nil
+	}
+	x, ok := e.entries[seq]
+	if !ok {
+		return nil
+	}
+	return x.report()
+}
+
+// Reports returns a report for every slot from seq on whose result has
+// settled, stopping at the first one that has not, so reports come out in
+// log order. No-op slots are skipped, as are settled slots already
+// garbage-collected. The returned channel is closed when another result
+// settles.
+func (e *executions) Reports(seq int64) ([]*pb.ExecutionReport, <-chan struct{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var reports []*pb.ExecutionReport
+	for seq = max(seq, 1); seq <= e.applied; seq++ {
+		x, ok := e.entries[seq]
+		if !ok {
+			continue
+		}
+		if !x.certified && !x.diverged {
+			break
+		}
+		reports = append(reports, x.report())
+	}
+	return reports, e.settled
+}
+
+// Divergences returns every replica this node has seen produce a result
+// that disagrees with its own, including itself when it is the one in the
+// minority.
+func (e *executions) Divergences() []Divergence {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Divergence(nil), e.divergences...)
+}
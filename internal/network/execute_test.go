@@ -0,0 +1,131 @@
+package network_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+// waitCertified polls until c has a certified result for seq.
+func waitCertified(t *testing.T, c *consensusUnderTest, seq int64) *pb.ExecutionResult {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if res, ok := c.Result(seq); ok {
+			return res
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("%s has no certified result for sequence %d", c.name, seq)
+	return nil
+}
+
+func TestExecute_StepLimitFaults(t *testing.T) {
+	// JUMP 0 never halts.
+	req := network.NewExecution([]byte{byte(vm.JUMP) << 4}, nil, nil)
+	req.MaxSteps = 10
+
+	res := network.Execute(vm.NewVM(nil, io.Discard), req)
+	if !strings.Contains(res.Fault, "step limit") {
+		t.Errorf("expected a step-limit fault, got %q", res.Fault)
+	}
+}
+
+func TestExecute_StepLimitAboveTheMaximumFaults(t *testing.T) {
+	req := network.NewExecution([]byte{byte(vm.JUMP) << 4}, nil, nil)
+	req.MaxSteps = 1 << 62
+
+	start := time.Now()
+	res := network.Execute(vm.NewVM(nil, io.Discard), req)
+	if !strings.Contains(res.Fault, "above the maximum") {
+		t.Errorf("expected the step limit to be refused, got %q", res.Fault)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("refusing the step limit took %v", time.Since(start))
+	}
+}
+
+func TestExecute_ReplicasRunTheOrderedInput(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+
+	// IN; OUT; HALT with 5 on the input tape.
+	program := []byte{byte(vm.IN) << 4, byte(vm.OUT) << 4, byte(vm.HALT) << 4}
+	msg := &pb.ConsensusMessage{
+		Type:    pb.ConsensusMessage_PRE_PREPARE,
+		Request: network.NewExecution(program, nil, []byte("5\n")),
+	}
+	if err := cs[0].StartConsensus(msg); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+
+	for i, c := range under(nodes, cs) {
+		res := waitCertified(t, c, 1)
+		if string(res.Output) != "5\n" || res.Fault != "" {
+			t.Errorf("%s: expected output %q, got %q (fault %q)", c.name, "5\n", res.Output, res.Fault)
+		}
+		if acc := nodes[i].VM.Registers.ACC; acc != 5 {
+			t.Errorf("%s: expected VM acc 5, got %d", c.name, acc)
+		}
+		if d := c.Divergences(); len(d) != 0 {
+			t.Errorf("%s: expected no divergences, got %v", c.name, d)
+		}
+	}
+}
+
+func TestExecute_DivergentReplicaIsReported(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+
+	// node4 starts from a different data segment, so the same program
+	// gives it a different result.
+	nodes[3].VM.Memory.Data[0x20] = 99
+	program := []byte{byte(vm.EXT)<<4 | byte(vm.LOAD), 0x20, byte(vm.OUT) << 4, byte(vm.HALT) << 4}
+	msg := &pb.ConsensusMessage{
+		Type:    pb.ConsensusMessage_PRE_PREPARE,
+		Request: network.NewExecution(program, nil, nil),
+	}
+	if err := cs[0].StartConsensus(msg); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+
+	all := under(nodes, cs)
+	for _, c := range all[:3] {
+		if res := waitCertified(t, c, 1); string(res.Output) != "0\n" {
+			t.Errorf("%s: expected output %q, got %q", c.name, "0\n", res.Output)
+		}
+	}
+	waitApplied(t, 1, all[3])
+
+	deadline := time.Now().Add(10 * time.Second)
+	for _, c := range all {
+		for !reported(c.Divergences(), "node4") {
+			if time.Now().After(deadline) {
+				t.Fatalf("%s did not report node4 as divergent: %v", c.name, c.Divergences())
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	if _, certified := cs[3].Result(1); certified {
+		t.Error("node4: expected its own result not to be certified")
+	}
+	for _, c := range all[:3] {
+		for _, d := range c.Divergences() {
+			if d.Node != "node4" {
+				t.Errorf("%s: unexpected divergence %v", c.name, d)
+			}
+		}
+	}
+}
+
+func reported(ds []network.Divergence, node string) bool {
+	for _, d := range ds {
+		if d.Node == node {
+			return true
+		}
+	}
+	return false
+}
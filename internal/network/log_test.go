@@ -0,0 +1,111 @@
+package network_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitApplied polls until every consensus instance in cs has applied seq.
+func waitApplied(t *testing.T, seq int64, cs ...*consensusUnderTest) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for _, c := range cs {
+		for c.LastApplied() < seq {
+			if time.Now().After(deadline) {
+				t.Fatalf("%s applied up to %d, expected %d", c.name, c.LastApplied(), seq)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+}
+
+func TestLog_DecidesStreamOfSlots(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+
+	// Several slots in flight at once, none waiting for the one before.
+	for i := int8(1); i <= 5; i++ {
+		msg := proposal(10 * i)
+		if err := cs[0].StartConsensus(msg); err != nil {
+			t.Fatalf("StartConsensus %d: %v", i, err)
+		}
+		if msg.Sequence != int64(i) {
+			t.Errorf("expected sequence %d, got %d", i, msg.Sequence)
+		}
+	}
+	waitApplied(t, 5, under(nodes, cs)...)
+
+	for i, c := range cs {
+		for seq := int64(1); seq <= 5; seq++ {
+			d := c.Decided(seq)
+			if d == nil || proposedAcc(d) != int32(10*seq) {
+				t.Errorf("%s: expected slot %d to hold acc %d, got %v", nodes[i].ID, seq, 10*seq, d)
+			}
+		}
+		// Slots are applied in order, so the VM holds the last one.
+		if acc := nodes[i].VM.Registers.ACC; acc != 50 {
+			t.Errorf("%s: expected VM acc 50 after applying the log, got %d", nodes[i].ID, acc)
+		}
+		if low, high := c.Watermarks(); low != 5 || high != 5+64 {
+			t.Errorf("%s: expected watermarks (5, 69), got (%d, %d)", nodes[i].ID, low, high)
+		}
+	}
+}
+
+func TestLog_HighWatermarkBoundsProposals(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+	cs[0].SetLogWindow(2)
+
+	// With every backup down nothing gets decided, so the window fills.
+	for _, n := range nodes[1:] {
+		n.Stop()
+	}
+	for i := int8(1); i <= 2; i++ {
+		if err := cs[0].StartConsensus(proposal(i)); err != nil {
+			t.Fatalf("StartConsensus %d: %v", i, err)
+		}
+	}
+	err := cs[0].StartConsensus(proposal(3))
+	if err == nil || !strings.Contains(err.Error(), "high watermark") {
+		t.Errorf("expected high-watermark error, got %v", err)
+	}
+}
+
+func TestLog_ViewChangeCarriesInFlightSlots(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+
+	// Slot 1 is decided normally; slots 2 and 3 only reach node2 before
+	// the primary crashes.
+	if err := cs[0].StartConsensus(proposal(1)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	waitApplied(t, 1, under(nodes, cs)...)
+	delete(nodes[0].Peers, "node3")
+	delete(nodes[0].Peers, "node4")
+	for i := int8(2); i <= 3; i++ {
+		if err := cs[0].StartConsensus(proposal(i)); err != nil {
+			t.Fatalf("StartConsensus %d: %v", i, err)
+		}
+	}
+	nodes[0].Stop()
+
+	survivors := under(nodes, cs)[1:]
+	waitApplied(t, 3, survivors...)
+	for _, c := range survivors {
+		for seq := int64(1); seq <= 3; seq++ {
+			if d := c.Decided(seq); d == nil || proposedAcc(d) != int32(seq) {
+				t.Errorf("%s: expected slot %d to hold acc %d, got %v", c.name, seq, seq, d)
+			}
+		}
+	}
+
+	// The new primary keeps numbering after the carried-over slots.
+	msg := proposal(4)
+	if err := cs[1].StartConsensus(msg); err != nil {
+		t.Fatalf("StartConsensus on new primary: %v", err)
+	}
+	if msg.Sequence != 4 {
+		t.Errorf("expected sequence 4, got %d", msg.Sequence)
+	}
+	waitApplied(t, 4, survivors...)
+}
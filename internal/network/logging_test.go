@@ -0,0 +1,71 @@
+package network_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+func TestLogging_RecordsCarryNodeSlotAndRequest(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	ids := []string{"node1", "node2", "node3", "node4"}
+	members, err := network.NewMembership(ids...)
+	if err != nil {
+		t.Fatalf("NewMembership: %v", err)
+	}
+	nodes := make([]*network.Node, len(ids))
+	cs := make([]*network.Consensus, len(ids))
+	for i, id := range ids {
+		nodes[i] = network.NewNode(id, "", vm.NewVM(nil, io.Discard), logger)
+		nodes[i].SetKey(keyFor(id))
+		if cs[i], err = network.NewConsensus(nodes[i], members); err != nil {
+			t.Fatalf("NewConsensus: %v", err)
+		}
+		nodes[i].SetReplicator(cs[i])
+	}
+	net := network.NewMemoryNetwork(1)
+	net.Join(nodes...)
+
+	req := requests(1, 1)[0]
+	req.Id = "req-1"
+	if _, err := cs[0].ProposeBatch([]*pb.Execution{req}); err != nil {
+		t.Fatalf("ProposeBatch: %v", err)
+	}
+	if !net.RunUntil(allApplied(1, cs...), 10000) {
+		t.Fatal("the cluster did not apply the request")
+	}
+
+	decided := make(map[string]bool)
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var rec struct {
+			Msg     string
+			Node    string
+			View    *int64
+			Seq     int64
+			Request string
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("log record %q is not JSON: %v", scanner.Text(), err)
+		}
+		if rec.Msg != "consensus reached" {
+			continue
+		}
+		if rec.View == nil || *rec.View != 0 || rec.Seq != 1 || rec.Request != "req-1" {
+			t.Errorf("unexpected decision record %s", scanner.Text())
+		}
+		decided[rec.Node] = true
+	}
+	if len(decided) != 4 {
+		t.Errorf("expected a decision record from each of the 4 nodes, got %v", decided)
+	}
+}
@@ -0,0 +1,62 @@
+package network
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Membership is the fixed set of nodes that make up a cluster, this node
+// included. It decides how many faults the cluster tolerates and how many
+// matching votes make a quorum, so neither depends on which peers happen
+// to be connected.
+type Membership struct {
+	ids []string // sorted: the order primaries take turns in
+}
+
+// NewMembership returns the membership of a cluster made of ids.
+func NewMembership(ids ...string) (Membership, error) {
+	if len(ids) == 0 {
+		return Membership{}, fmt.Errorf("cluster has no members")
+	}
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	for i, id := range sorted {
+		if id == "" {
+			return Membership{}, fmt.Errorf("cluster member with an empty ID")
+		}
+		if i > 0 && sorted[i-1] == id {
+			return Membership{}, fmt.Errorf("cluster member %s listed twice", id)
+		}
+	}
+	return Membership{ids: sorted}, nil
+}
+
+// IDs returns the members in the order used for primary rotation.
+func (m Membership) IDs() []string { return append([]string(nil), m.ids...) }
+
+// N returns the number of members.
+func (m Membership) N() int { return len(m.ids) }
+
+// F returns the number of Byzantine members the cluster tolerates.
+func (m Membership) F() int { return (m.N() - 1) / 3 }
+
+// Quorum returns how many members, this node included, must agree before a
+// value is prepared or committed. Any two quorums share at least f+1
+// members, so at least one honest one. That is 2f+1 when n = 3f+1; a
+// cluster with spare members needs proportionally more.
+func (m Membership) Quorum() int { return (m.N()+m.F())/2 + 1 }
+
+// Majority returns how many members, this node included, make a majority.
+// Any two majorities share a member, which is all a cluster that only
+// tolerates crashes needs.
+func (m Membership) Majority() int { return m.N()/2 + 1 }
+
+// Contains reports whether id is a member.
+func (m Membership) Contains(id string) bool {
+	i := sort.SearchStrings(m.ids, id)
+	return i < len(m.ids) && m.ids[i] == id
+}
+
+func (m Membership) String() string {
+	return fmt.Sprintf("n=%d f=%d quorum=%d", m.N(), m.F(), m.Quorum())
+}
@@ -0,0 +1,152 @@
+package network_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+)
+
+func memberIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("node%d", i+1)
+	}
+	return ids
+}
+
+func TestMembership_QuorumMath(t *testing.T) {
+	tests := []struct {
+		n, f, quorum int
+	}{
+		{1, 0, 1},
+		{3, 0, 2},
+		{4, 1, 3},
+		{5, 1, 4},
+		{7, 2, 5},
+		{10, 3, 7},
+	}
+	for _, tt := range tests {
+		m, err := network.NewMembership(memberIDs(tt.n)...)
+		if err != nil {
+			t.Fatalf("n=%d: %v", tt.n, err)
+		}
+		if m.N() != tt.n || m.F() != tt.f || m.Quorum() != tt.quorum {
+			t.Errorf("n=%d: expected f=%d quorum=%d, got %v", tt.n, tt.f, tt.quorum, m)
+		}
+		// Two quorums must overlap in at least one honest member.
+		if overlap := 2*m.Quorum() - m.N(); overlap < m.F()+1 {
+			t.Errorf("n=%d: quorums overlap in %d members, need %d", tt.n, overlap, m.F()+1)
+		}
+	}
+}
+
+func TestMembership_RejectsInvalid(t *testing.T) {
+	tests := []struct {
+		ids  []string
+		want string
+	}{
+		{nil, "no members"},
+		{[]string{"node1", ""}, "empty ID"},
+		{[]string{"node1", "node2", "node1"}, "listed twice"},
+	}
+	for _, tt := range tests {
+		_, err := network.NewMembership(tt.ids...)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: expected error containing %q, got %v", tt.ids, tt.want, err)
+		}
+	}
+}
+
+func TestNewConsensus_RejectsNonMember(t *testing.T) {
+	m, err := network.NewMembership("node1", "node2", "node3", "node4")
+	if err != nil {
+		t.Fatalf("NewMembership: %v", err)
+	}
+	node := network.NewNode("node5", "", vm.NewVM(nil, io.Discard), nil)
+	if _, err := network.NewConsensus(node, m); err == nil || !strings.Contains(err.Error(), "not a member") {
+		t.Errorf("expected not-a-member error, got %v", err)
+	}
+}
+
+// The quorum does not depend on how many peers a node has connected to.
+func TestNewConsensus_QuorumIgnoresConnectedPeers(t *testing.T) {
+	m, err := network.NewMembership(memberIDs(7)...)
+	if err != nil {
+		t.Fatalf("NewMembership: %v", err)
+	}
+	node := network.NewNode("node1", "", vm.NewVM(nil, io.Discard), nil)
+	c, err := network.NewConsensus(node, m)
+	if err != nil {
+		t.Fatalf("NewConsensus: %v", err)
+	}
+	if got := c.Membership().Quorum(); got != 5 {
+		t.Errorf("expected quorum 5 with no peers connected yet, got %d", got)
+	}
+}
+
+// A cluster of n nodes decides with f of them down, and not with f+1.
+func TestCluster_ToleratesFFaults(t *testing.T) {
+	for _, n := range []int{4, 7, 10} {
+		t.Run(fmt.Sprintf("n=%d", n), func(t *testing.T) {
+			nodes, cs := startCluster(t, n)
+			f := cs[0].Membership().F()
+
+			for _, node := range nodes[n-f:] {
+				node.Stop()
+			}
+			if err := cs[0].StartConsensus(proposal(int8(n))); err != nil {
+				t.Fatalf("StartConsensus: %v", err)
+			}
+			live := under(nodes, cs)[:n-f]
+			waitApplied(t, 1, live...)
+			for _, c := range live {
+				if d := c.Decided(1); proposedAcc(d) != int32(n) {
+					t.Errorf("%s: expected acc %d, got %d", c.name, n, proposedAcc(d))
+				}
+			}
+		})
+	}
+}
+
+func TestCluster_StallsBeyondFFaults(t *testing.T) {
+	nodes, cs := startCluster(t, 7)
+	f := cs[0].Membership().F()
+
+	for _, node := range nodes[7-f-1:] {
+		node.Stop()
+	}
+	if err := cs[0].StartConsensus(proposal(1)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	time.Sleep(500 * time.Millisecond)
+	for _, c := range under(nodes, cs)[:7-f-1] {
+		if c.LastApplied() != 0 {
+			t.Errorf("%s: decided with only %d of 7 nodes up", c.name, 7-f-1)
+		}
+	}
+}
+
+func TestCluster_ReconnectedPeerGetsMessages(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+
+	if err := cs[0].StartConsensus(proposal(1)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	waitApplied(t, 1, under(nodes, cs)...)
+
+	// node1 already has a queue for node2, on the connection it closes
+	// here. The next PRE_PREPARE must go out on the new one.
+	nodes[0].RemovePeer("node2")
+	if err := nodes[0].ConnectToPeer("node2", nodes[1].Address, nodes[1].PublicKey()); err != nil {
+		t.Fatalf("ConnectToPeer: %v", err)
+	}
+	if err := cs[0].StartConsensus(proposal(2)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	waitApplied(t, 2, under(nodes, cs)...)
+}
@@ -0,0 +1,465 @@
+package network
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// Faults describes how a MemoryNetwork mistreats the messages it carries.
+// Each message is treated independently.
+type Faults struct {
+	// Delay is how long every message takes to arrive. A random extra delay
+	// in [0, Jitter) is added, so messages may overtake each other.
+	Delay  time.Duration
+	Jitter time.Duration
+
+	// Latency, if set, gives each message's delay in place of Delay and
+	// Jitter.
+	Latency Distribution
+
+	// Bandwidth is how many bytes per second each node can send, zero
+	// meaning no limit. A node sends one message after the other, so a
+	// message waits for those it sent before to leave.
+	Bandwidth int64
+
+	// Drop is the probability that a message is lost, Duplicate that it
+	// arrives twice, and Reorder that it is held back until everything
+	// already in flight has arrived.
+	Drop      float64
+	Duplicate float64
+	Reorder   float64
+}
+
+// A Distribution draws message delays for a MemoryNetwork from its seeded
+// source.
+type Distribution interface {
+	Sample(rng *rand.Rand) time.Duration
+}
+
+// NetworkStats counts what a MemoryNetwork has done with the messages sent
+// through it.
+type NetworkStats struct {
+	Sent       int
+	Delivered  int
+	Dropped    int // lost to Faults.Drop or to a partition
+	Duplicated int
+	Bytes      int64 // size of the messages sent, in the protobuf encoding
+}
+
+// MemoryNetwork connects nodes within one process. Nothing happens until
+// the test calls Step: each step delivers the next message or fires the
+// next consensus timer, in the order of a virtual clock, and every random
+// choice comes from one seeded source. The same seed and the same calls
+// therefore give the same run.
+type MemoryNetwork struct {
+	mu     sync.Mutex
+	rng    *rand.Rand
+	now    time.Duration
+	latest time.Duration // latest time a message is due
+	events eventQueue
+	nextID uint64
+	nodes  map[string]*Node
+	faults Faults
+	group  map[string]int // partition each node is in; nil when healed
+	stats  NetworkStats
+	byType map[pb.ConsensusMessage_Type]int
+	busy   map[string]time.Duration // when each node's last message is sent
+
+	intercept map[string]Interceptor
+	forge     map[string]func(*pb.StateSnapshot) *pb.StateSnapshot
+}
+
+// An Interceptor stands between a node and a MemoryNetwork. It is given
+// every message the node sends, already signed, and returns what is sent
+// in its place: the message itself, altered copies the interceptor has
+// signed again with the node's key, or nothing at all. Interceptors let a
+// test make a node Byzantine while the rest of the cluster runs unchanged.
+type Interceptor func(to string, msg *pb.ConsensusMessage) []*pb.ConsensusMessage
+
+// NewMemoryNetwork returns an empty network whose scheduler is seeded with
+// seed.
+func NewMemoryNetwork(seed int64) *MemoryNetwork {
+	return &MemoryNetwork{
+		rng:       rand.New(rand.NewSource(seed)),
+		nodes:     make(map[string]*Node),
+		byType:    make(map[pb.ConsensusMessage_Type]int),
+		busy:      make(map[string]time.Duration),
+		intercept: make(map[string]Interceptor),
+		forge:     make(map[string]func(*pb.StateSnapshot) *pb.StateSnapshot),
+	}
+}
+
+// Join attaches nodes to the network and makes every node on it a peer of
+// every other. Their messages go through the network from then on, and
+// the timers of any replicator already attached to them run on its
+// virtual clock.
+func (m *MemoryNetwork) Join(nodes ...*Node) {
+	for _, n := range nodes {
+		m.mu.Lock()
+		for _, other := range m.nodes {
+			other.AddPeer(n.ID, n.PublicKey())
+			n.AddPeer(other.ID, other.PublicKey())
+		}
+		m.nodes[n.ID] = n
+		m.mu.Unlock()
+
+		n.SetTransport(memoryTransport{net: m, from: n.ID})
+		if r := n.Replicator(); r != nil {
+			r.SetClock(memoryClock{m})
+		}
+	}
+}
+
+// Intercept routes every message node id sends through f from now on. A
+// nil f removes the interceptor.
+func (m *MemoryNetwork) Intercept(id string, f Interceptor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if f == nil {
+		delete(m.intercept, id)
+		return
+	}
+	m.intercept[id] = f
+}
+
+// InterceptState makes node id serve whatever f returns in place of its
+// stable checkpoint when a peer fetches its state. A nil f removes the
+// interceptor.
+func (m *MemoryNetwork) InterceptState(id string, f func(*pb.StateSnapshot) *pb.StateSnapshot) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if f == nil {
+		delete(m.forge, id)
+		return
+	}
+	m.forge[id] = f
+}
+
+// Inject sends msg from one node to another as it is, bypassing any
+// interceptor, as a node replaying a message it once received would. The
+// message is subject to faults and partitions like any other.
+func (m *MemoryNetwork) Inject(from, to string, msg *pb.ConsensusMessage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.enqueue(from, to, msg)
+}
+
+// SetFaults changes how messages sent from now on are treated.
+func (m *MemoryNetwork) SetFaults(f Faults) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.faults = f
+}
+
+// Partition splits the network: nodes in different groups cannot reach
+// each other, and nodes left out of every group form one more group.
+// Messages already in flight across the cut are lost.
+func (m *MemoryNetwork) Partition(groups ...[]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.group = make(map[string]int)
+	for i, g := range groups {
+		for _, id := range g {
+			m.group[id] = i + 1
+		}
+	}
+}
+
+// Heal removes any partition.
+func (m *MemoryNetwork) Heal() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.group = nil
+}
+
+// connected reports whether from can currently reach to. m.mu must be
+// held.
+func (m *MemoryNetwork) connected(from, to string) bool {
+	return m.group == nil || m.group[from] == m.group[to]
+}
+
+// Now returns the virtual time: how far the network has run.
+func (m *MemoryNetwork) Now() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// Stats returns the message counts so far.
+func (m *MemoryNetwork) Stats() NetworkStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// SentByType returns how many messages of each type have been sent so far.
+// A batch counts once, as the type of the messages it carries.
+func (m *MemoryNetwork) SentByType() map[pb.ConsensusMessage_Type]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[pb.ConsensusMessage_Type]int, len(m.byType))
+	for t, n := range m.byType {
+		counts[t] = n
+	}
+	return counts
+}
+
+// At schedules f to run at virtual time at, or at once if that has
+// passed, in order with the messages and timers due then. f runs without
+// the network locked and may call any of its methods.
+func (m *MemoryNetwork) At(at time.Duration, f func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.schedule(&event{at: max(at, m.now), fire: f})
+}
+
+// send schedules the delivery of a copy of msg, or of whatever the
+// sender's interceptor replaces it with. m.mu must not be held.
+func (m *MemoryNetwork) send(from, to string, msg *pb.ConsensusMessage) {
+	m.mu.Lock()
+	f := m.intercept[from]
+	m.mu.Unlock()
+
+	msgs := []*pb.ConsensusMessage{msg}
+	if f != nil {
+		// The interceptor may sign, so it runs without m.mu held.
+		msgs = f(to, proto.Clone(msg).(*pb.ConsensusMessage))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, msg := range msgs {
+		m.enqueue(from, to, msg)
+	}
+}
+
+// enqueue applies the network's faults to msg and schedules what survives.
+// m.mu must be held.
+func (m *MemoryNetwork) enqueue(from, to string, msg *pb.ConsensusMessage) {
+	m.stats.Sent++
+	m.byType[msg.Type]++
+	size := proto.Size(msg)
+	m.stats.Bytes += int64(size)
+	// A message takes up the sender's bandwidth even if it is lost on the
+	// way.
+	sent := m.now
+	if m.faults.Bandwidth > 0 {
+		sent = max(sent, m.busy[from]) + time.Duration(int64(size)*int64(time.Second)/m.faults.Bandwidth)
+		m.busy[from] = sent
+	}
+	if !m.connected(from, to) || m.rng.Float64() < m.faults.Drop {
+		m.stats.Dropped++
+		return
+	}
+	copies := 1
+	if m.rng.Float64() < m.faults.Duplicate {
+		copies = 2
+		m.stats.Duplicated++
+	}
+	for i := 0; i < copies; i++ {
+		at := sent + m.faults.Delay
+		if m.faults.Latency != nil {
+			at = sent + max(m.faults.Latency.Sample(m.rng), 0)
+		} else if m.faults.Jitter > 0 {
+			at += time.Duration(m.rng.Int63n(int64(m.faults.Jitter)))
+		}
+		if m.rng.Float64() < m.faults.Reorder {
+			at = max(at, m.latest+1)
+		}
+		m.latest = max(m.latest, at)
+		m.schedule(&event{
+			at:   at,
+			from: from,
+			to:   to,
+			msg:  proto.Clone(msg).(*pb.ConsensusMessage),
+		})
+	}
+}
+
+// schedule queues e. m.mu must be held.
+func (m *MemoryNetwork) schedule(e *event) {
+	e.id = m.nextID
+	m.nextID++
+	heap.Push(&m.events, e)
+}
+
+// Step delivers the next message or fires the next timer, moving the
+// virtual clock forward to it. It reports false once nothing is left to
+// do.
+func (m *MemoryNetwork) Step() bool {
+	m.mu.Lock()
+	var e *event
+	for m.events.Len() > 0 {
+		e = heap.Pop(&m.events).(*event)
+		if !e.cancelled {
+			break
+		}
+		e = nil
+	}
+	if e == nil {
+		m.mu.Unlock()
+		return false
+	}
+	e.done = true
+	m.now = e.at
+	if e.fire != nil {
+		m.mu.Unlock()
+		e.fire()
+		return true
+	}
+	if !m.connected(e.from, e.to) {
+		m.stats.Dropped++
+		m.mu.Unlock()
+		return true
+	}
+	node := m.nodes[e.to]
+	m.stats.Delivered++
+	m.mu.Unlock()
+
+	if err := node.Deliver(e.msg); err != nil {
+		node.logger.Debug("dropped message", "type", e.msg.Type, "from", e.from, "err", err)
+	}
+	return true
+}
+
+// RunUntil steps the network until cond holds, giving up after maxSteps
+// steps or when nothing is left to do. It reports whether cond held.
+func (m *MemoryNetwork) RunUntil(cond func() bool, maxSteps int) bool {
+	for i := 0; i < maxSteps; i++ {
+		if cond() {
+			return true
+		}
+		if !m.Step() {
+			break
+		}
+	}
+	return cond()
+}
+
+// memoryTransport sends a node's messages into a MemoryNetwork.
+type memoryTransport struct {
+	net  *MemoryNetwork
+	from string
+}
+
+func (t memoryTransport) Send(to string, msg *pb.ConsensusMessage) error {
+	t.net.send(t.from, to, msg)
+	return nil
+}
+
+// reach returns the node with the given ID if t's node can reach it and
+// it is running.
+func (t memoryTransport) reach(id string) (*Node, error) {
+	m := t.net
+	m.mu.Lock()
+	peer, ok := m.nodes[id]
+	reachable := m.connected(t.from, id)
+	m.mu.Unlock()
+
+	if !ok || !reachable || peer.stopped.Load() {
+		return nil, fmt.Errorf("peer %s is unreachable", id)
+	}
+	return peer, nil
+}
+
+// FetchState answers at once from the peer's replicator, as long as
+// the peer is running and reachable. Nothing in the call is random, so it
+// does not disturb the schedule.
+func (t memoryTransport) FetchState(ctx context.Context, from string) (*pb.StateSnapshot, error) {
+	peer, err := t.reach(from)
+	if err != nil {
+		return nil, err
+	}
+	snap := peer.replicator.Snapshot()
+	if snap == nil {
+		return nil, fmt.Errorf("peer %s has no stable checkpoint", from)
+	}
+	snap = proto.Clone(snap).(*pb.StateSnapshot)
+	t.net.mu.Lock()
+	forge := t.net.forge[from]
+	t.net.mu.Unlock()
+	if forge != nil {
+		snap = forge(snap)
+	}
+	return snap, nil
+}
+
+// ProveMemory answers at once from the peer's replicator, like
+// FetchState.
+func (t memoryTransport) ProveMemory(ctx context.Context, from string, req *pb.ProveMemoryRequest) (*pb.MemoryProof, error) {
+	peer, err := t.reach(from)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := peer.replicator.ProveMemory(req)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Clone(proof).(*pb.MemoryProof), nil
+}
+
+// memoryClock schedules timers as events on a MemoryNetwork.
+type memoryClock struct {
+	net *MemoryNetwork
+}
+
+func (c memoryClock) AfterFunc(d time.Duration, f func()) Timer {
+	m := c.net
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := &event{at: m.now + d, fire: f}
+	m.schedule(e)
+	return memoryTimer{net: m, event: e}
+}
+
+type memoryTimer struct {
+	net   *MemoryNetwork
+	event *event
+}
+
+func (t memoryTimer) Stop() bool {
+	t.net.mu.Lock()
+	defer t.net.mu.Unlock()
+	if t.event.done || t.event.cancelled {
+		return false
+	}
+	t.event.cancelled = true
+	return true
+}
+
+// event is a message delivery or a timer, due at a virtual time. Events
+// due at the same time happen in the order they were scheduled.
+type event struct {
+	at        time.Duration
+	id        uint64
+	from, to  string
+	msg       *pb.ConsensusMessage
+	fire      func()
+	done      bool
+	cancelled bool
+}
+
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].id < q[j].id
+}
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x any)   { *q = append(*q, x.(*event)) }
+func (q *eventQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
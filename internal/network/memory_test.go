@@ -0,0 +1,254 @@
+package network_test
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+// keyFor returns the key of the node with the given ID in memory clusters,
+// derived from the ID so that a node can be restarted with the same key.
+func keyFor(id string) ed25519.PrivateKey {
+	seed := sha256.Sum256([]byte(id))
+	return ed25519.NewKeyFromSeed(seed[:])
+}
+
+// startMemoryCluster is startCluster on a MemoryNetwork seeded with seed.
+func startMemoryCluster(t testing.TB, n int, seed int64) (*network.MemoryNetwork, []*network.Node, []*network.Consensus) {
+	t.Helper()
+	nodes := make([]*network.Node, n)
+	ids := make([]string, n)
+	for i := range nodes {
+		nodes[i] = network.NewNode(fmt.Sprintf("node%d", i+1), "", vm.NewVM(nil, io.Discard), nil)
+		nodes[i].SetKey(keyFor(nodes[i].ID))
+		ids[i] = nodes[i].ID
+	}
+	members, err := network.NewMembership(ids...)
+	if err != nil {
+		t.Fatalf("NewMembership: %v", err)
+	}
+	cs := make([]*network.Consensus, n)
+	for i, node := range nodes {
+		if cs[i], err = network.NewConsensus(node, members); err != nil {
+			t.Fatalf("NewConsensus: %v", err)
+		}
+		cs[i].SetTimeouts(testTimeouts)
+		cs[i].SetAdminKey(adminKey.Public().(ed25519.PublicKey))
+		node.SetReplicator(cs[i])
+	}
+	net := network.NewMemoryNetwork(seed)
+	net.Join(nodes...)
+	return net, nodes, cs
+}
+
+// allApplied returns a condition that holds once every instance in cs has
+// applied seq.
+func allApplied(seq int64, cs ...*network.Consensus) func() bool {
+	return func() bool {
+		for _, c := range cs {
+			if c.LastApplied() < seq {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// sameDecisions fails t if two instances decided different values in any
+// of the first n slots.
+func sameDecisions(t *testing.T, n int64, nodes []*network.Node, cs []*network.Consensus) {
+	t.Helper()
+	for seq := int64(1); seq <= n; seq++ {
+		var want string
+		var from string
+		for i, c := range cs {
+			d := c.Decided(seq)
+			if d == nil {
+				continue
+			}
+			got := fmt.Sprint(d.Request.GetInitialData())
+			if from == "" {
+				want, from = got, nodes[i].ID
+			} else if got != want {
+				t.Errorf("slot %d: %s decided %s, %s decided %s", seq, from, want, nodes[i].ID, got)
+			}
+		}
+	}
+}
+
+var lossy = network.Faults{
+	Delay:     time.Millisecond,
+	Jitter:    5 * time.Millisecond,
+	Duplicate: 0.2,
+	Reorder:   0.2,
+}
+
+// runLossy proposes three slots on a network that delays, duplicates and
+// reorders messages, and returns when it finished in virtual time and how
+// many messages it delivered.
+func runLossy(t *testing.T, seed int64) (time.Duration, network.NetworkStats) {
+	t.Helper()
+	net, nodes, cs := startMemoryCluster(t, 4, seed)
+	net.SetFaults(lossy)
+	for i := int8(1); i <= 3; i++ {
+		if err := cs[0].StartConsensus(proposal(i)); err != nil {
+			t.Fatalf("StartConsensus %d: %v", i, err)
+		}
+	}
+	if !net.RunUntil(allApplied(3, cs...), 100000) {
+		t.Fatalf("seed %d: not every node applied 3 slots", seed)
+	}
+	sameDecisions(t, 3, nodes, cs)
+	for i, c := range cs {
+		for seq := int64(1); seq <= 3; seq++ {
+			if got := proposedAcc(c.Decided(seq)); got != int32(seq) {
+				t.Errorf("%s: slot %d decided acc %d", nodes[i].ID, seq, got)
+			}
+		}
+	}
+	return net.Now(), net.Stats()
+}
+
+func TestMemory_SameSeedSameRun(t *testing.T) {
+	now1, stats1 := runLossy(t, 7)
+	now2, stats2 := runLossy(t, 7)
+	if now1 != now2 || stats1 != stats2 {
+		t.Errorf("runs with the same seed differ: %v %+v vs %v %+v", now1, stats1, now2, stats2)
+	}
+	if stats1.Duplicated == 0 {
+		t.Error("expected the faults to duplicate some messages")
+	}
+}
+
+func TestMemory_ToleratesDuplicationAndReordering(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		runLossy(t, seed)
+	}
+}
+
+func TestMemory_DropsAreNeverUnsafe(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		net, nodes, cs := startMemoryCluster(t, 4, seed)
+		net.SetFaults(network.Faults{Delay: time.Millisecond, Jitter: time.Millisecond, Drop: 0.1})
+		for i := int8(1); i <= 3; i++ {
+			if err := cs[0].StartConsensus(proposal(i)); err != nil {
+				break // a view change may already be under way
+			}
+		}
+		net.RunUntil(allApplied(3, cs...), 20000)
+		sameDecisions(t, 3, nodes, cs)
+	}
+}
+
+func TestMemory_PartitionedPrimaryIsReplaced(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	net.SetFaults(network.Faults{Delay: time.Millisecond})
+
+	// node2 receives the PRE_PREPARE, then node1 is cut off before it
+	// reaches anybody else.
+	if err := cs[0].StartConsensus(proposal(42)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	net.Step()
+	net.Partition([]string{"node1"})
+
+	survivors := cs[1:]
+	if !net.RunUntil(allApplied(1, survivors...), 10000) {
+		t.Fatal("the survivors did not decide")
+	}
+	for i, c := range survivors {
+		if got := proposedAcc(c.Decided(1)); got != 42 {
+			t.Errorf("%s: expected acc 42, got %d", nodes[i+1].ID, got)
+		}
+		if v := c.View(); v != 1 {
+			t.Errorf("%s: expected view 1, got %d", nodes[i+1].ID, v)
+		}
+	}
+	if cs[0].LastApplied() != 0 {
+		t.Error("the isolated primary should not have decided")
+	}
+	// The view change waited out the prepare timeout, in virtual time.
+	if now := net.Now(); now < testTimeouts.Prepare {
+		t.Errorf("expected the run to take at least %v of virtual time, took %v", testTimeouts.Prepare, now)
+	}
+}
+
+func TestMemory_MinorityPartitionCannotDecide(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	net.Partition([]string{"node1", "node2"}, []string{"node3", "node4"})
+	if err := cs[0].StartConsensus(proposal(1)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+
+	net.RunUntil(func() bool { return false }, 2000)
+	for i, c := range cs {
+		if c.LastApplied() != 0 {
+			t.Errorf("%s decided without a quorum", nodes[i].ID)
+		}
+	}
+}
+
+// fixed is a Distribution that always draws d.
+type fixed time.Duration
+
+func (d fixed) Sample(*rand.Rand) time.Duration { return time.Duration(d) }
+
+func TestMemory_LatencyAndBandwidthDelayMessages(t *testing.T) {
+	decideOne := func(f network.Faults) (time.Duration, network.NetworkStats) {
+		net, _, cs := startMemoryCluster(t, 4, 1)
+		net.SetFaults(f)
+		if err := cs[0].StartConsensus(proposal(1)); err != nil {
+			t.Fatalf("StartConsensus: %v", err)
+		}
+		if !net.RunUntil(allApplied(1, cs...), 10000) {
+			t.Fatal("the cluster did not decide")
+		}
+		return net.Now(), net.Stats()
+	}
+
+	// PRE_PREPARE, PREPARE and COMMIT, one after the other.
+	if now, _ := decideOne(network.Faults{Delay: time.Hour, Latency: fixed(10 * time.Millisecond)}); now != 30*time.Millisecond {
+		t.Errorf("expected a decision after 30ms of latency, got %v", now)
+	}
+	// Each node sends three copies of each message, so the last copy of a
+	// round leaves three message sizes after the round starts.
+	fast, stats := decideOne(network.Faults{})
+	if stats.Bytes == 0 {
+		t.Fatal("no bytes counted")
+	}
+	perMessage := int64(time.Second) * stats.Bytes / int64(stats.Sent)
+	slow, _ := decideOne(network.Faults{Bandwidth: 100000})
+	if want := fast + 3*time.Duration(perMessage/100000); slow < want {
+		t.Errorf("expected 100000 bytes/s to take at least %v, took %v", want, slow)
+	}
+}
+
+func TestMemory_AtRunsInVirtualTime(t *testing.T) {
+	net, _, cs := startMemoryCluster(t, 4, 1)
+	net.SetFaults(network.Faults{Delay: time.Millisecond})
+	var started time.Duration
+	net.At(time.Second, func() {
+		started = net.Now()
+		if err := cs[0].StartConsensus(proposal(1)); err != nil {
+			t.Errorf("StartConsensus: %v", err)
+		}
+	})
+	if !net.RunUntil(allApplied(1, cs...), 10000) {
+		t.Fatal("the cluster did not decide")
+	}
+	if started != time.Second || net.Now() != time.Second+3*time.Millisecond {
+		t.Errorf("expected the round to run from 1s to 1.003s, ran from %v to %v", started, net.Now())
+	}
+	counts := net.SentByType()
+	if counts[pb.ConsensusMessage_PRE_PREPARE] != 3 || counts[pb.ConsensusMessage_PREPARE] != 9 {
+		t.Errorf("expected 3 PRE_PREPAREs and 9 PREPAREs, got %v", counts)
+	}
+}
@@ -0,0 +1,58 @@
+package network
+
+import (
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/metrics"
+)
+
+// nodeMetrics is what a node and its replicator count. Until SetMetrics is
+// called its fields are nil and count nothing.
+type nodeMetrics struct {
+	roundsStarted *metrics.Series
+	roundsDecided *metrics.Series
+	viewChanges   *metrics.Series
+	phases        *metrics.Histogram // phase
+	sent          *metrics.Counter   // peer, type
+	received      *metrics.Counter   // peer, type
+	rejected      *metrics.Counter   // peer
+	sendFailures  *metrics.Counter   // peer
+	results       *metrics.Counter   // outcome
+}
+
+func newNodeMetrics(reg *metrics.Registry) *nodeMetrics {
+	return &nodeMetrics{
+		roundsStarted: reg.Counter("atlasvm_consensus_rounds_started_total",
+			"Log slots this node proposed as primary or appended as leader.").With(),
+		roundsDecided: reg.Counter("atlasvm_consensus_rounds_decided_total",
+			"Log slots this node decided (PBFT) or applied once committed (Raft).").With(),
+		viewChanges: reg.Counter("atlasvm_view_changes_total",
+			"Views (PBFT) or terms (Raft) this node moved to after the first.").With(),
+		phases: reg.Histogram("atlasvm_consensus_phase_seconds",
+			"How long log slots spent in each phase: prepare and commit for PBFT, replicate for Raft, and execute for both.",
+			metrics.DefaultBuckets, "phase"),
+		sent: reg.Counter("atlasvm_messages_sent_total",
+			"Consensus messages handed to the transport, by peer and type.", "peer", "type"),
+		received: reg.Counter("atlasvm_messages_received_total",
+			"Consensus messages received with a valid signature, by sender and type.", "peer", "type"),
+		rejected: reg.Counter("atlasvm_messages_rejected_total",
+			"Consensus messages refused before reaching the replicator, by claimed sender.", "peer"),
+		sendFailures: reg.Counter("atlasvm_send_failures_total",
+			"Messages that could not be sent to a peer, by peer.", "peer"),
+		results: reg.Counter("atlasvm_results_total",
+			"Results of this node that settled, by outcome: certified or diverged.", "outcome"),
+	}
+}
+
+// observePhase records that a slot spent the time since start in phase.
+func (m *nodeMetrics) observePhase(phase string, start time.Time) {
+	m.phases.Observe(time.Since(start).Seconds(), phase)
+}
+
+// SetMetrics makes the node, its replicator and its VM count into reg,
+// which can then be served for Prometheus to scrape. Call it before the
+// node starts.
+func (n *Node) SetMetrics(reg *metrics.Registry) {
+	n.metrics = newNodeMetrics(reg)
+	n.VM.SetMetrics(reg)
+}
@@ -0,0 +1,73 @@
+package network_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/metrics"
+)
+
+func TestMetrics_CountConsensusAndExecution(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	regs := make([]*metrics.Registry, len(nodes))
+	for i, node := range nodes {
+		regs[i] = metrics.NewRegistry()
+		node.SetMetrics(regs[i])
+	}
+
+	if _, err := cs[0].ProposeBatch(requests(1, 3)); err != nil {
+		t.Fatalf("ProposeBatch: %v", err)
+	}
+	if !net.RunUntil(allApplied(3, cs...), 10000) {
+		t.Fatal("the cluster did not apply the requests")
+	}
+
+	counter := func(reg *metrics.Registry, name string, labels ...string) *metrics.Counter {
+		return reg.Counter(name, "", labels...)
+	}
+	primary := regs[0]
+	if got := counter(primary, "atlasvm_consensus_rounds_started_total").Value(); got != 3 {
+		t.Errorf("primary started %d rounds, expected 3", got)
+	}
+	for i, reg := range regs {
+		if got := counter(reg, "atlasvm_consensus_rounds_decided_total").Value(); got != 3 {
+			t.Errorf("%s decided %d rounds, expected 3", nodes[i].ID, got)
+		}
+		// Each request runs LOAD, OUT and HALT once.
+		if got := counter(reg, "atlasvm_vm_instructions_total", "opcode").Value("HALT"); got != 3 {
+			t.Errorf("%s ran %d HALTs, expected 3", nodes[i].ID, got)
+		}
+		phases := reg.Histogram("atlasvm_consensus_phase_seconds", "", nil, "phase")
+		for _, phase := range []string{"prepare", "commit", "execute"} {
+			if got := phases.Count(phase); got != 3 {
+				t.Errorf("%s timed the %s phase %d times, expected 3", nodes[i].ID, phase, got)
+			}
+		}
+	}
+	// The primary's PRE_PREPAREs reach every backup, and a backup hears
+	// PREPAREs from the others.
+	sent := counter(primary, "atlasvm_messages_sent_total", "peer", "type")
+	received := counter(regs[1], "atlasvm_messages_received_total", "peer", "type")
+	for _, node := range nodes[1:] {
+		if sent.Value(node.ID, "PRE_PREPARE") == 0 {
+			t.Errorf("primary sent no PRE_PREPARE to %s", node.ID)
+		}
+		if node != nodes[1] && received.Value(node.ID, "PREPARE") == 0 {
+			t.Errorf("%s received no PREPARE from %s", nodes[1].ID, node.ID)
+		}
+	}
+
+	var b strings.Builder
+	if err := primary.WriteText(&b); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	for _, line := range []string{
+		"atlasvm_consensus_rounds_decided_total 3\n",
+		`atlasvm_vm_instructions_total{opcode="LOAD"} 3` + "\n",
+		`atlasvm_consensus_phase_seconds_count{phase="commit"} 3` + "\n",
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("metrics are missing %q", line)
+		}
+	}
+}
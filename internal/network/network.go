@@ -2,14 +2,23 @@ package network
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
 	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
 )
 
 type NodeService struct {
@@ -18,31 +27,88 @@ type NodeService struct {
 }
 
 type Node struct {
-	ID        string
-	Address   string
-	Peers     map[string]*NodeClient
-	VM        *vm.VM
-	consensus *Consensus
-	mu        sync.Mutex
+	ID         string
+	Address    string
+	Peers      map[string]*NodeClient // guarded by mu: membership changes edit it
+	VM         *vm.VM
+	replicator Replicator
+	mu         sync.Mutex
+	server     *grpc.Server
+	conns      []*grpc.ClientConn
+	stopped    atomic.Bool
+
+	// key signs this node's messages; keys holds the public key of every
+	// node whose messages it accepts, its own included.
+	key    ed25519.PrivateKey
+	keys   map[string]ed25519.PublicKey
+	keysMu sync.RWMutex
+
+	logger   *slog.Logger
+	levelVar *slog.LevelVar // set if NodeAdmin may change the log level
+
+	// shutdown is closed once the node is asked to shut down.
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+
+	backoff     Backoff
+	tls         *NodeTLS
+	metrics     *nodeMetrics
+	transport   Transport
+	sendTimeout atomic.Int64 // nanoseconds
 }
 
 type NodeClient struct {
 	ID     string
 	Client pb.NodeServiceClient
+	conn   *grpc.ClientConn
 }
 
-func NewNode(id, address string, vm *vm.VM) *Node {
-	return &Node{
-		ID:      id,
-		Address: address,
-		Peers:   make(map[string]*NodeClient),
-		VM:      vm,
+// Backoff controls how soon a node redials a peer it cannot reach: the
+// delay starts at Initial and doubles after every failed attempt, up to
+// Max.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// DefaultBackoff is what NewNode starts with.
+var DefaultBackoff = Backoff{Initial: 100 * time.Millisecond, Max: 5 * time.Second}
+
+// NewNode returns a node with a freshly generated key. Use SetKey to give
+// it a key its peers already know. The node and its replicator log to
+// logger, with the node ID on every record; a nil logger discards
+// everything.
+func NewNode(id, address string, vm *vm.VM, logger *slog.Logger) *Node {
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	n := &Node{
+		ID:       id,
+		Address:  address,
+		Peers:    make(map[string]*NodeClient),
+		VM:       vm,
+		keys:     make(map[string]ed25519.PublicKey),
+		logger:   logger.With("node", id),
+		shutdown: make(chan struct{}),
+		backoff:  DefaultBackoff,
+		metrics:  newNodeMetrics(nil),
 	}
+	n.sendTimeout.Store(int64(DefaultSendTimeout))
+	n.transport = newGRPCTransport(n)
+	_, key := GenerateKey()
+	n.SetKey(key)
+	return n
 }
 
-// SetConsensus attaches a consensus instance to the node so it can participate in consensus when receiving messages.
-func (n *Node) SetConsensus(c *Consensus) {
-	n.consensus = c
+// SetReplicator attaches the replicator the node hands the messages it
+// receives to.
+func (n *Node) SetReplicator(r Replicator) {
+	n.replicator = r
+}
+
+// Replicator returns the replicator attached with SetReplicator.
+func (n *Node) Replicator() Replicator {
+	return n.replicator
 }
 
 func (n *Node) Start() error {
@@ -50,75 +116,388 @@ func (n *Node) Start() error {
 	if err != nil {
 		return fmt.Errorf("failed to listen: %v", err)
 	}
+	return n.Serve(lis)
+}
 
-	grpcServer := grpc.NewServer()
+// Serve is like Start but accepts connections on an existing listener.
+func (n *Node) Serve(lis net.Listener) error {
+	grpcServer := grpc.NewServer(n.serverOptions()...)
 	pb.RegisterNodeServiceServer(grpcServer, &NodeService{node: n})
+	pb.RegisterClientServiceServer(grpcServer, &ClientService{node: n})
+	pb.RegisterNodeAdminServer(grpcServer, &NodeAdminService{node: n})
 
-	log.Printf("Node %s starting on %s", n.ID, n.Address)
+	n.mu.Lock()
+	n.server = grpcServer
+	n.mu.Unlock()
+
+	n.logger.Info("node starting", "addr", lis.Addr().String())
 	return grpcServer.Serve(lis)
 }
 
-func (n *Node) ConnectToPeer(id, address string) error {
-	conn, err := grpc.Dial(address, grpc.WithInsecure())
+// Stop takes the node off the network, as if it had crashed: its server
+// stops accepting messages and it no longer sends any. Messages it already
+// broadcast still go out.
+func (n *Node) Stop() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.stopped.Store(true)
+	if n.server != nil {
+		n.server.Stop()
+	}
+	if t, ok := n.transport.(*grpcTransport); ok {
+		t.close()
+	}
+	for _, conn := range n.conns {
+		conn.Close()
+	}
+}
+
+// SetSendTimeout sets how long sending a message to one peer over gRPC
+// may take before it is given up.
+func (n *Node) SetSendTimeout(d time.Duration) {
+	n.sendTimeout.Store(int64(d))
+}
+
+// SetBackoff sets how peers that cannot be reached are redialed. It
+// applies to peers connected after the call.
+func (n *Node) SetBackoff(b Backoff) {
+	n.backoff = b
+}
+
+// ConnectToPeer adds id as a peer reachable at address whose messages are
+// signed with key. The peer does not have to be up yet: the connection is
+// retried in the background with the node's backoff.
+func (n *Node) ConnectToPeer(id, address string, key ed25519.PublicKey) error {
+	params := grpc.ConnectParams{
+		Backoff: backoff.Config{
+			BaseDelay:  n.backoff.Initial,
+			Multiplier: 2,
+			Jitter:     0.2,
+			MaxDelay:   n.backoff.Max,
+		},
+		MinConnectTimeout: time.Second,
+	}
+	conn, err := grpc.NewClient(address,
+		grpc.WithTransportCredentials(n.dialCredentials(id)),
+		grpc.WithConnectParams(params))
 	if err != nil {
 		return fmt.Errorf("failed to connect to peer %s: %v", id, err)
 	}
+	conn.Connect()
 
 	client := pb.NewNodeServiceClient(conn)
-	n.Peers[id] = &NodeClient{ID: id, Client: client}
+	n.mu.Lock()
+	n.Peers[id] = &NodeClient{ID: id, Client: client, conn: conn}
+	n.conns = append(n.conns, conn)
+	n.mu.Unlock()
+	n.addKey(id, key)
 	return nil
 }
 
-func (n *Node) Broadcast(msg *pb.ConsensusMessage) error {
+// AddPeer adds id as a peer whose messages are signed with key, without
+// connecting to it. Use it with a transport other than gRPC.
+func (n *Node) AddPeer(id string, key ed25519.PublicKey) {
+	n.mu.Lock()
+	n.Peers[id] = &NodeClient{ID: id}
+	n.mu.Unlock()
+	n.addKey(id, key)
+}
+
+// RemovePeer stops sending messages to id and accepting messages signed
+// by it, and closes the connection to it. Messages still queued for it
+// are dropped.
+func (n *Node) RemovePeer(id string) {
+	n.removePeer(id, false)
+}
+
+// retirePeer is RemovePeer for a member that left the cluster: the
+// messages already queued for it still go out before the connection is
+// closed, since it needs them to finish the epoch it leaves.
+func (n *Node) retirePeer(id string) {
+	n.removePeer(id, true)
+}
+
+func (n *Node) removePeer(id string, flush bool) {
+	n.mu.Lock()
+	peer, ok := n.Peers[id]
+	delete(n.Peers, id)
+	t, _ := n.transport.(*grpcTransport)
+	n.mu.Unlock()
+
+	n.keysMu.Lock()
+	delete(n.keys, id)
+	n.keysMu.Unlock()
+
+	var conn *grpc.ClientConn
+	if ok {
+		conn = peer.conn
+	}
+	if t != nil && flush {
+		t.retire(id, conn)
+		return
+	}
+	if t != nil {
+		t.drop(id)
+	}
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+// connectMember makes m a peer unless it already is one: over gRPC if it
+// has an address, otherwise on whatever transport the node uses. Members
+// without a key are expected to be peers from the cluster config already.
+func (n *Node) connectMember(m *pb.Member) error {
+	if _, ok := n.peer(m.Id); ok || m.Id == n.ID {
+		return nil
+	}
+	if len(m.PublicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("member %s is not a peer and has no key", m.Id)
+	}
+	key := ed25519.PublicKey(m.PublicKey)
+	if m.Address == "" {
+		n.AddPeer(m.Id, key)
+		return nil
+	}
+	return n.ConnectToPeer(m.Id, m.Address, key)
+}
+
+// peer returns the peer with the given ID.
+func (n *Node) peer(id string) (*NodeClient, bool) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
+	peer, ok := n.Peers[id]
+	return peer, ok
+}
 
+// peerList returns the node's peers in ID order.
+func (n *Node) peerList() []*NodeClient {
+	n.mu.Lock()
+	peers := make([]*NodeClient, 0, len(n.Peers))
 	for _, peer := range n.Peers {
-		_, err := peer.Client.ReceiveMessage(context.Background(), msg)
+		peers = append(peers, peer)
+	}
+	n.mu.Unlock()
+	sort.Slice(peers, func(i, j int) bool { return peers[i].ID < peers[j].ID })
+	return peers
+}
+
+func (n *Node) addKey(id string, key ed25519.PublicKey) {
+	n.keysMu.Lock()
+	defer n.keysMu.Unlock()
+	n.keys[id] = key
+}
+
+// ConnectToCluster connects to every node in cfg other than this one.
+func (n *Node) ConnectToCluster(cfg *ClusterConfig) error {
+	for _, peer := range cfg.Nodes {
+		if peer.ID == n.ID {
+			continue
+		}
+		key, err := DecodePublicKey(peer.PublicKey)
 		if err != nil {
-			log.Printf("Failed to send message to peer %s: %v", peer.ID, err)
+			return fmt.Errorf("peer %s: %w", peer.ID, err)
+		}
+		if err := n.ConnectToPeer(peer.ID, peer.Address, key); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func (s *NodeService) ReceiveMessage(ctx context.Context, msg *pb.ConsensusMessage) (*pb.Empty, error) {
+// WaitForPeers blocks until at least want peers are reachable, or ctx is
+// done.
+func (n *Node) WaitForPeers(ctx context.Context, want int) error {
+	tick := time.NewTicker(20 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		ready := 0
+		for _, peer := range n.peerList() {
+			if peer.conn == nil {
+				ready++ // not reached over gRPC
+				continue
+			}
+			switch peer.conn.GetState() {
+			case connectivity.Ready:
+				ready++
+			case connectivity.Idle:
+				peer.conn.Connect()
+			}
+		}
+		if ready >= want {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d of %d peers reachable: %w", ready, want, ctx.Err())
+		case <-tick.C:
+		}
+	}
+}
+
+// Broadcast signs msg as this node and passes it to every peer, in ID
+// order. The transport decides whether the sends wait for each other: over
+// gRPC they go out in parallel and Broadcast does not wait for any.
+func (n *Node) Broadcast(msg *pb.ConsensusMessage) error {
+	n.Sign(msg)
+	return n.broadcastSigned(msg)
+}
+
+// broadcastSigned is Broadcast for a message this node has already signed.
+func (n *Node) broadcastSigned(msg *pb.ConsensusMessage) error {
+	n.mu.Lock()
+	if n.stopped.Load() {
+		n.mu.Unlock()
+		return fmt.Errorf("node %s is stopped", n.ID)
+	}
+	ids := make([]string, 0, len(n.Peers))
+	for id := range n.Peers {
+		ids = append(ids, id)
+	}
+	t := n.transport
+	n.mu.Unlock()
+
+	sort.Strings(ids)
+	for _, id := range ids {
+		n.send(t, id, msg)
+	}
+	return nil
+}
+
+// Send signs msg and sends it to the peer with the given ID.
+func (n *Node) Send(to string, msg *pb.ConsensusMessage) error {
+	n.Sign(msg)
+
+	n.mu.Lock()
+	if n.stopped.Load() {
+		n.mu.Unlock()
+		return fmt.Errorf("node %s is stopped", n.ID)
+	}
+	t := n.transport
+	n.mu.Unlock()
+	return n.send(t, to, msg)
+}
+
+// send passes msg to peer to through t and counts it.
+func (n *Node) send(t Transport, to string, msg *pb.ConsensusMessage) error {
+	if err := t.Send(to, msg); err != nil {
+		n.metrics.sendFailures.Inc(to)
+		n.logger.Warn("send failed", "peer", to, "type", msg.Type, "err", err)
+		return err
+	}
+	n.metrics.sent.Inc(to, msg.Type.String())
+	return nil
+}
+
+// admit checks that msg may be handed to consensus: it is signed by a
+// cluster member and this node is running.
+func (n *Node) admit(msg *pb.ConsensusMessage) error {
 	if msg == nil {
-		log.Printf("Received nil consensus message (node=%s)", s.node.ID)
-		return &pb.Empty{}, nil
+		return fmt.Errorf("nil consensus message")
+	}
+	n.logger.Debug("received message", "type", msg.Type, "view", msg.View, "seq", msg.Sequence, "from", msg.Sender)
+
+	if n.stopped.Load() {
+		return fmt.Errorf("node %s is stopped", n.ID)
+	}
+	if err := n.Verify(msg); err != nil {
+		n.metrics.rejected.Inc(n.knownAs(msg.Sender))
+		n.logger.Warn("rejected message", "from", msg.Sender, "type", msg.Type, "err", err)
+		return err
 	}
-	if msg.State != nil {
-		log.Printf(
-			"Received consensus msg: type=%v view=%d pc=%d acc=%d (node=%s)",
-			msg.Type,
-			msg.View,
-			msg.State.Pc,
-			msg.State.Acc,
-			s.node.ID,
-		)
-	} else {
-		log.Printf("Received consensus msg: type=%v view=%d (node=%s)", msg.Type, msg.View, s.node.ID)
+	n.metrics.received.Inc(msg.Sender, msg.Type.String())
+	if n.replicator == nil {
+		return fmt.Errorf("node %s has no replicator", n.ID)
 	}
+	if !n.replicator.Membership().Contains(msg.Sender) {
+		return fmt.Errorf("%v from %s, which is not a cluster member", msg.Type, msg.Sender)
+	}
+	return nil
+}
+
+// dispatch hands an admitted message to the replicator.
+func (n *Node) dispatch(msg *pb.ConsensusMessage) error {
+	return n.replicator.Handle(msg)
+}
+
+// knownAs returns id if it is a node this node knows, and "unknown"
+// otherwise, so that forged sender IDs cannot flood the metrics with
+// labels.
+func (n *Node) knownAs(id string) string {
+	n.keysMu.RLock()
+	defer n.keysMu.RUnlock()
+	if _, ok := n.keys[id]; !ok {
+		return "unknown"
+	}
+	return id
+}
 
-	if s.node.consensus == nil {
-		log.Printf("Node %s has no consensus instance, ignoring message", s.node.ID)
-		return &pb.Empty{}, nil
+// Deliver hands msg to the node and processes it before returning. It is
+// how transports that do not go through gRPC feed the node.
+func (n *Node) Deliver(msg *pb.ConsensusMessage) error {
+	if err := n.admit(msg); err != nil {
+		return err
+	}
+	return n.dispatch(msg)
+}
+
+func (s *NodeService) ReceiveMessage(ctx context.Context, msg *pb.ConsensusMessage) (*pb.Empty, error) {
+	from, err := s.node.peerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Over TLS, a peer only passes on messages it signed itself.
+	if from != "" && msg.GetSender() != from {
+		return nil, status.Errorf(codes.PermissionDenied, "%s sent a message from %s", from, msg.GetSender())
+	}
+	if err := s.node.admit(msg); err != nil {
+		return nil, err
 	}
 
 	// Handle consensus asynchronously to avoid deadlock: the sender (e.g. node1) is
 	// blocked in Broadcast until this RPC returns. If we call Broadcast from here
 	// (e.g. replica broadcasting PREPARE back to node1), node1 cannot accept the
 	// new RPC until its Broadcast returns. So process in a goroutine and return immediately.
-	go func() {
-		switch msg.Type {
-		case pb.ConsensusMessage_PRE_PREPARE:
-			s.node.consensus.HandlePrePrepare(msg)
-		case pb.ConsensusMessage_PREPARE:
-			s.node.consensus.HandlePrepare(msg)
-		case pb.ConsensusMessage_COMMIT:
-			s.node.consensus.HandleCommit(msg)
-		}
-	}()
+	go s.node.dispatch(msg)
 
 	return &pb.Empty{}, nil
 }
+
+// FetchState serves this node's latest stable checkpoint to a replica that
+// has fallen behind.
+func (s *NodeService) FetchState(ctx context.Context, req *pb.FetchStateRequest) (*pb.StateSnapshot, error) {
+	if _, err := s.node.peerID(ctx); err != nil {
+		return nil, err
+	}
+	if s.node.stopped.Load() {
+		return nil, status.Errorf(codes.Unavailable, "node %s is stopped", s.node.ID)
+	}
+	if s.node.replicator == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "node %s has no replicator", s.node.ID)
+	}
+	snap := s.node.replicator.Snapshot()
+	if snap == nil {
+		return nil, status.Errorf(codes.NotFound, "node %s has no stable checkpoint yet", s.node.ID)
+	}
+	return snap, nil
+}
+
+// ProveMemory proves what this node's memory held after a slot, or at its
+// latest stable checkpoint.
+func (s *NodeService) ProveMemory(ctx context.Context, req *pb.ProveMemoryRequest) (*pb.MemoryProof, error) {
+	if _, err := s.node.peerID(ctx); err != nil {
+		return nil, err
+	}
+	if s.node.stopped.Load() {
+		return nil, status.Errorf(codes.Unavailable, "node %s is stopped", s.node.ID)
+	}
+	if s.node.replicator == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "node %s has no replicator", s.node.ID)
+	}
+	proof, err := s.node.replicator.ProveMemory(req)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "node %s: %v", s.node.ID, err)
+	}
+	return proof, nil
+}
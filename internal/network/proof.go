@@ -0,0 +1,164 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+// ProveMemory proves what memory held after slot req.Sequence on this
+// node, or at its latest stable checkpoint if the sequence is 0: it
+// returns the state's commitment and an inclusion proof for each page
+// holding one of req.Addresses.
+func (c *Consensus) ProveMemory(req *pb.ProveMemoryRequest) (*pb.MemoryProof, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	proof := &pb.MemoryProof{Sequence: req.Sequence}
+	var state *pb.VMState
+	if req.Sequence == 0 {
+		if c.stable == nil {
+			return nil, fmt.Errorf("no stable checkpoint yet")
+		}
+		proof.Sequence = c.stable.Sequence
+		proof.Checkpoint = c.stable
+		proof.Configuration = c.stableConfig
+		state = c.stableState
+	} else {
+		x := c.get(req.Sequence)
+		if x == nil {
+			return nil, fmt.Errorf("no result for sequence %d", req.Sequence)
+		}
+		state = x.result.State
+	}
+	return proveMemory(proof, state, req)
+}
+
+// proveMemory fills in proof for state as req asks.
+func proveMemory(proof *pb.MemoryProof, state *pb.VMState, req *pb.ProveMemoryRequest) (*pb.MemoryProof, error) {
+	proof.State = commitment(state)
+
+	tree := vm.NewMerkleTree(state.Memory)
+	seen := make(map[int]bool)
+	for _, addr := range req.Addresses {
+		if addr >= vm.MemorySize {
+			return nil, fmt.Errorf("address %d is out of range", addr)
+		}
+		p := vm.PageOf(uint16(addr))
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		pp := tree.Prove(p, state.Memory[p*vm.PageSize:(p+1)*vm.PageSize])
+		proof.Pages = append(proof.Pages, &pb.PageProof{Page: uint32(pp.Page), Data: pp.Data, Siblings: pp.Siblings})
+	}
+	if req.PageHashes {
+		proof.PageHashes = tree.Pages()
+	}
+	return proof, nil
+}
+
+// VerifyMemoryProof checks every page in proof against the memory root it
+// commits to. A proof for a stable checkpoint must carry a certificate
+// from a quorum whose digest covers the proved state, and the
+// configuration it also covers. A proof for a slot
+// is checked against this node's own certified result for the slot, if it
+// has one; otherwise it is only as good as the replica that sent it.
+func (c *Consensus) VerifyMemoryProof(proof *pb.MemoryProof) error {
+	if proof.GetState() == nil {
+		return fmt.Errorf("proof without a state")
+	}
+	root := proof.State.MemoryRoot
+	if cert := proof.Checkpoint; cert != nil {
+		if cert.Sequence != proof.Sequence {
+			return fmt.Errorf("checkpoint at sequence %d proves sequence %d", cert.Sequence, proof.Sequence)
+		}
+		if proof.Configuration == nil {
+			return fmt.Errorf("checkpoint at sequence %d without its configuration", cert.Sequence)
+		}
+		c.mu.Lock()
+		known := c.knownConfigs()
+		c.mu.Unlock()
+		if err := c.verifyCertified(cert, proof.Configuration, known); err != nil {
+			return err
+		}
+		if checkpointDigest(proof.State, proof.Configuration) != cert.Digest {
+			return fmt.Errorf("state does not match the checkpoint at sequence %d", cert.Sequence)
+		}
+	} else if own, certified := c.Result(proof.Sequence); certified &&
+		!bytes.Equal(own.State.GetMemoryRoot(), root) {
+		return fmt.Errorf("memory root differs from the certified result at sequence %d", proof.Sequence)
+	}
+
+	for _, p := range proof.Pages {
+		pp := vm.Proof{Page: int(p.Page), Data: p.Data, Siblings: p.Siblings}
+		if err := pp.Verify(root); err != nil {
+			return err
+		}
+	}
+	if proof.PageHashes != nil {
+		tree, err := vm.TreeFromPages(proof.PageHashes)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(tree.Root(), root) {
+			return fmt.Errorf("page hashes do not lead to the memory root")
+		}
+	}
+	return nil
+}
+
+// ProvedByte returns the byte at address in a verified proof, and false if
+// the proof does not cover the page holding it.
+func ProvedByte(proof *pb.MemoryProof, address uint16) (byte, bool) {
+	p := vm.PageOf(address)
+	for _, pp := range proof.GetPages() {
+		if int(pp.Page) == p && len(pp.Data) == vm.PageSize {
+			return pp.Data[int(address)%vm.PageSize], true
+		}
+	}
+	return 0, false
+}
+
+// FetchProof asks peer to prove memory as req describes and verifies the
+// answer.
+func (c *Consensus) FetchProof(ctx context.Context, peer string, req *pb.ProveMemoryRequest) (*pb.MemoryProof, error) {
+	proof, err := c.node.proveMemory(ctx, peer, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.VerifyMemoryProof(proof); err != nil {
+		return nil, fmt.Errorf("proof from %s: %w", peer, err)
+	}
+	return proof, nil
+}
+
+// DivergentPages narrows down where peer's state after slot seq differs
+// from this node's: it fetches the hash of each of peer's pages and walks
+// the two Merkle trees down from the root, only into subtrees that differ.
+// It returns the differing pages in order, which is empty if the states
+// differ only in their registers.
+func (c *Consensus) DivergentPages(ctx context.Context, seq int64, peer string) ([]int, error) {
+	own, _ := c.Result(seq)
+	if own == nil {
+		return nil, fmt.Errorf("no result for sequence %d", seq)
+	}
+	proof, err := c.node.proveMemory(ctx, peer, &pb.ProveMemoryRequest{Sequence: seq, PageHashes: true})
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := vm.TreeFromPages(proof.PageHashes)
+	if err != nil {
+		return nil, fmt.Errorf("page hashes from %s: %w", peer, err)
+	}
+	if !bytes.Equal(theirs.Root(), proof.GetState().GetMemoryRoot()) {
+		return nil, fmt.Errorf("page hashes from %s do not lead to its memory root", peer)
+	}
+	pages := vm.NewMerkleTree(own.State.Memory).Diff(theirs)
+	sort.Ints(pages)
+	return pages, nil
+}
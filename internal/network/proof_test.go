@@ -0,0 +1,86 @@
+package network_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestProof_StableCheckpointProvesAddresses(t *testing.T) {
+	net, _, cs := startMemoryCluster(t, 4, 1)
+	checkpointEvery(4, cs...)
+	propose(t, cs[0], 1, 4)
+	if !net.RunUntil(stableAt(4, cs...), 10000) {
+		t.Fatal("the cluster did not reach a stable checkpoint at 4")
+	}
+
+	req := &pb.ProveMemoryRequest{Addresses: []uint32{0, 1, 600}}
+	proof, err := cs[3].FetchProof(context.Background(), "node1", req)
+	if err != nil {
+		t.Fatalf("FetchProof: %v", err)
+	}
+	if len(proof.Pages) != 2 {
+		t.Errorf("expected proofs for 2 pages, got %d", len(proof.Pages))
+	}
+	if b, ok := network.ProvedByte(proof, 0); !ok || b != 4 {
+		t.Errorf("expected address 0 to hold 4, got %d (%v)", b, ok)
+	}
+	if _, ok := network.ProvedByte(proof, 100); ok {
+		t.Error("expected no proof for address 100")
+	}
+
+	forged := proto.Clone(proof).(*pb.MemoryProof)
+	forged.Pages[0].Data[0] = 5
+	if err := cs[3].VerifyMemoryProof(forged); err == nil {
+		t.Error("a proof with forged page data verified")
+	}
+	forged = proto.Clone(proof).(*pb.MemoryProof)
+	forged.State.Acc++
+	if err := cs[3].VerifyMemoryProof(forged); err == nil {
+		t.Error("a proof for a state other than the checkpointed one verified")
+	}
+	forged = proto.Clone(proof).(*pb.MemoryProof)
+	forged.Checkpoint.Proof = forged.Checkpoint.Proof[:1]
+	if err := cs[3].VerifyMemoryProof(forged); err == nil {
+		t.Error("a proof with a checkpoint signed by one replica verified")
+	}
+}
+
+func TestProof_DivergenceIsNarrowedToPages(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	// node4 has a stray byte in page 5 that the program never reads, so
+	// only its memory differs.
+	nodes[3].VM.Memory.Write(5*64+3, 1)
+
+	propose(t, cs[0], 1, 1)
+	if !net.RunUntil(allApplied(1, cs...), 10000) {
+		t.Fatal("the cluster did not decide")
+	}
+	if !net.RunUntil(func() bool { return len(cs[0].Divergences()) > 0 }, 10000) {
+		t.Fatal("node1 did not notice node4 diverge")
+	}
+
+	pages, err := cs[0].DivergentPages(context.Background(), 1, "node4")
+	if err != nil {
+		t.Fatalf("DivergentPages: %v", err)
+	}
+	if !slices.Equal(pages, []int{5}) {
+		t.Errorf("expected node4 to differ in page 5, got %v", pages)
+	}
+	if pages, err := cs[0].DivergentPages(context.Background(), 1, "node2"); err != nil || len(pages) != 0 {
+		t.Errorf("expected node2 to agree, got %v (%v)", pages, err)
+	}
+
+	// Slots are also proved against the prover's own result, which node1
+	// checks against the one a quorum certified.
+	if _, err := cs[0].FetchProof(context.Background(), "node4", &pb.ProveMemoryRequest{Sequence: 1}); err == nil {
+		t.Error("node1 accepted node4's divergent state")
+	}
+	if _, err := cs[0].FetchProof(context.Background(), "node3", &pb.ProveMemoryRequest{Sequence: 1}); err != nil {
+		t.Errorf("FetchProof from node3: %v", err)
+	}
+}
@@ -0,0 +1,724 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+// RaftTimeouts sets the pace of a Raft replica.
+type RaftTimeouts struct {
+	// Heartbeat is how often the leader sends every follower an
+	// APPEND_ENTRIES, whether or not it has new entries for it.
+	Heartbeat time.Duration
+
+	// Election is the least a follower waits to hear from a leader before
+	// it stands for election itself. Each wait is drawn at random from
+	// [Election, 2*Election), so replicas rarely stand at the same time.
+	Election time.Duration
+}
+
+// DefaultRaftTimeouts is what NewRaft starts with.
+var DefaultRaftTimeouts = RaftTimeouts{
+	Heartbeat: 100 * time.Millisecond,
+	Election:  time.Second,
+}
+
+// maxAppend bounds how many entries one APPEND_ENTRIES carries.
+const maxAppend = 64
+
+type raftRole int
+
+const (
+	follower raftRole = iota
+	candidate
+	leader
+)
+
+var raftRoleNames = [...]string{
+	follower:  "follower",
+	candidate: "candidate",
+	leader:    "leader",
+}
+
+func (r raftRole) String() string { return raftRoleNames[r] }
+
+// Raft replicates the log with the Raft protocol. It tolerates crashes but
+// not Byzantine replicas: a majority of the cluster orders requests, and
+// fewer messages go round for each than with PBFT. Messages are still
+// signed, so only members take part.
+//
+// A Raft replica keeps its whole log, in memory and in its WAL; there is no
+// compaction, so no stable checkpoints to serve and no state transfer. A
+// replica that was down catches up from the leader's log instead.
+type Raft struct {
+	*executions
+
+	node       *Node
+	membership Membership
+
+	mu        sync.Mutex
+	clock     Clock
+	timeouts  RaftTimeouts
+	batching  Batching
+	rng       *rand.Rand
+	wal       *WAL
+	replaying bool
+
+	// What a replica must not forget across a restart.
+	term int64
+	vote string          // whom this replica voted for in term, if anyone
+	log  []*pb.RaftEntry // log[i] is the entry at index i+1
+
+	commit      int64 // the highest index known to be committed
+	lastApplied int64
+
+	role   raftRole
+	leader string          // the leader of term, once known
+	votes  map[string]bool // candidate: who voted for it
+
+	// Leader: the next index to send each follower, and the highest index
+	// known to match its log. next runs ahead of match while appends are
+	// in flight.
+	next    map[string]int64
+	match   map[string]int64
+	pending int // entries appended since the last round of appends
+
+	// appended holds when the leader appended each entry it has not yet
+	// committed, for the replicate phase latency.
+	appended map[int64]time.Time
+
+	timer      Timer
+	timerGen   int
+	batchTimer Timer
+}
+
+// NewRaft returns a Raft replica for node, which must be one of members.
+// Its election timer starts at once on the system clock.
+func NewRaft(node *Node, members Membership) (*Raft, error) {
+	if !members.Contains(node.ID) {
+		return nil, fmt.Errorf("node %s is not a member of the cluster", node.ID)
+	}
+	h := fnv.New64a()
+	h.Write([]byte(node.ID))
+	r := &Raft{
+		executions: newExecutions(node, members.Majority()),
+		node:       node,
+		membership: members,
+		clock:      systemClock{},
+		timeouts:   DefaultRaftTimeouts,
+		batching:   DefaultBatching,
+		rng:        rand.New(rand.NewSource(int64(h.Sum64()))),
+	}
+	r.mu.Lock()
+	r.armElection()
+	r.mu.Unlock()
+	return r, nil
+}
+
+// Membership returns the cluster this replica is a member of.
+func (r *Raft) Membership() Membership { return r.membership }
+
+// Leader returns the leader of the current term, or "" while there is an
+// election.
+func (r *Raft) Leader() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.leader
+}
+
+// Term returns the term this replica is in.
+func (r *Raft) Term() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.term
+}
+
+// SetTimeouts replaces the heartbeat and election timeouts. It takes
+// effect from the next time a timer is armed.
+func (r *Raft) SetTimeouts(t RaftTimeouts) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timeouts = t
+}
+
+// SetClock replaces the clock timers run on and restarts the current timer
+// on it.
+func (r *Raft) SetClock(clock Clock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clock = clock
+	if r.role == leader {
+		r.armHeartbeat()
+	} else {
+		r.armElection()
+	}
+}
+
+// SetBatching sets how many requests the leader appends before it sends
+// them to its followers, and how long it waits for that many.
+func (r *Raft) SetBatching(b Batching) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batching = b
+}
+
+// lastIndex returns the index of the last entry in the log. r.mu must be
+// held.
+func (r *Raft) lastIndex() int64 { return int64(len(r.log)) }
+
+// termAt returns the term of the entry at index, or 0 for index 0. r.mu
+// must be held.
+func (r *Raft) termAt(index int64) int64 {
+	if index == 0 {
+		return 0
+	}
+	return r.log[index-1].Term
+}
+
+// Submit appends req to the leader's log and returns the index it got. The
+// entry goes out to the followers with the next batch.
+func (r *Raft) Submit(ctx context.Context, req *pb.Execution) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.role != leader {
+		return 0, fmt.Errorf("node %s is not the leader of term %d", r.node.ID, r.term)
+	}
+	index, err := r.appendEntry(req)
+	if err != nil {
+		return 0, err
+	}
+	r.pending++
+	if r.pending >= r.batching.Size {
+		r.replicate()
+	} else if r.batchTimer == nil {
+		r.batchTimer = r.clock.AfterFunc(r.batching.Delay, func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.batchTimer = nil
+			if r.role == leader && r.pending > 0 {
+				r.replicate()
+			}
+		})
+	}
+	return index, nil
+}
+
+// appendEntry adds req to the leader's own log, logging it first, and
+// returns its index. r.mu must be held.
+func (r *Raft) appendEntry(req *pb.Execution) (int64, error) {
+	entry := &pb.RaftEntry{Term: r.term, Request: req}
+	prev := r.lastIndex()
+	if err := r.persistEntries(prev, []*pb.RaftEntry{entry}); err != nil {
+		return 0, err
+	}
+	r.log = append(r.log, entry)
+	r.appended[prev+1] = time.Now()
+	r.node.metrics.roundsStarted.Inc()
+	// A cluster of one commits on its own.
+	r.advanceCommit()
+	return prev + 1, nil
+}
+
+// Handle passes msg to the handler for its type.
+func (r *Raft) Handle(msg *pb.ConsensusMessage) error {
+	if msg.Type == pb.ConsensusMessage_RESULT {
+		return r.handleResult(msg, 0)
+	}
+	if msg.Raft == nil {
+		return fmt.Errorf("%v message is not a Raft message", msg.Type)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.observe(msg.View); err != nil {
+		return err
+	}
+	switch msg.Type {
+	case pb.ConsensusMessage_APPEND_ENTRIES:
+		return r.handleAppend(msg)
+	case pb.ConsensusMessage_APPEND_REPLY:
+		r.handleAppendReply(msg)
+	case pb.ConsensusMessage_REQUEST_VOTE:
+		return r.handleRequestVote(msg)
+	case pb.ConsensusMessage_VOTE:
+		r.handleVote(msg)
+	default:
+		return fmt.Errorf("unknown message type %v", msg.Type)
+	}
+	return nil
+}
+
+// observe moves this replica to term as a follower if term is later than
+// its own. r.mu must be held.
+func (r *Raft) observe(term int64) error {
+	if term <= r.term {
+		return nil
+	}
+	if r.role == leader {
+		r.node.logger.Info("stepping down as leader", "term", r.term, "next", term)
+	}
+	r.term = term
+	r.vote = ""
+	r.role = follower
+	r.leader = ""
+	r.appended = nil
+	if !r.replaying {
+		r.node.metrics.viewChanges.Inc()
+	}
+	if r.batchTimer != nil {
+		r.batchTimer.Stop()
+		r.batchTimer = nil
+	}
+	r.armElection()
+	return r.persistTerm()
+}
+
+// handleAppend appends the leader's entries to this replica's log if the
+// log matches the leader's up to them, and applies whatever the leader
+// has committed. r.mu must be held.
+func (r *Raft) handleAppend(msg *pb.ConsensusMessage) error {
+	if msg.View < r.term {
+		return r.reply(msg.Sender, false, r.lastIndex())
+	}
+	if r.role != follower || r.leader != msg.Sender {
+		r.role = follower
+		r.leader = msg.Sender
+		r.node.logger.Info("following leader", "leader", r.leader, "term", r.term)
+	}
+	r.armElection()
+
+	prev := msg.Sequence
+	if prev > r.lastIndex() || r.termAt(prev) != msg.Raft.PrevTerm {
+		return r.reply(msg.Sender, false, min(prev-1, r.lastIndex()))
+	}
+
+	// Skip the entries this replica already has, and drop the rest of its
+	// log from the first one that conflicts.
+	entries := msg.Raft.Entries
+	from := prev
+	for len(entries) > 0 && from < r.lastIndex() {
+		if r.termAt(from+1) != entries[0].Term {
+			if from < r.commit {
+				return fmt.Errorf("leader %s conflicts with committed index %d", msg.Sender, from+1)
+			}
+			r.log = r.log[:from]
+			break
+		}
+		entries = entries[1:]
+		from++
+	}
+	if len(entries) > 0 {
+		if err := r.persistEntries(from, entries); err != nil {
+			return err
+		}
+		r.log = append(r.log, entries...)
+	}
+
+	last := prev + int64(len(msg.Raft.Entries))
+	if c := min(msg.Raft.Commit, last); c > r.commit {
+		r.commit = c
+		r.applyCommitted()
+	}
+	return r.reply(msg.Sender, true, last)
+}
+
+// reply answers an APPEND_ENTRIES from to. On success, index is the last
+// index that matches the leader's log; otherwise the leader should retry
+// with the entries after it. r.mu must be held.
+func (r *Raft) reply(to string, success bool, index int64) error {
+	return r.node.Send(to, &pb.ConsensusMessage{
+		Type:     pb.ConsensusMessage_APPEND_REPLY,
+		View:     r.term,
+		Sequence: index,
+		Raft:     &pb.RaftMessage{Success: success},
+	})
+}
+
+// handleAppendReply moves the leader's view of a follower's log on and
+// sends it whatever it is still missing. r.mu must be held.
+func (r *Raft) handleAppendReply(msg *pb.ConsensusMessage) {
+	if r.role != leader || msg.View != r.term {
+		return
+	}
+	p := msg.Sender
+	if msg.Raft.Success {
+		r.match[p] = max(r.match[p], msg.Sequence)
+		r.next[p] = max(r.next[p], r.match[p]+1)
+		r.advanceCommit()
+		if r.next[p] > r.lastIndex() {
+			return
+		}
+	} else {
+		r.next[p] = max(r.match[p]+1, min(r.next[p]-1, msg.Sequence+1))
+	}
+	r.sendAppend(p)
+}
+
+// handleRequestVote grants the candidate this replica's vote for the term
+// if it has not voted for anyone else and the candidate's log is at least
+// as up to date as its own. r.mu must be held.
+func (r *Raft) handleRequestVote(msg *pb.ConsensusMessage) error {
+	lastTerm := r.termAt(r.lastIndex())
+	upToDate := msg.Raft.PrevTerm > lastTerm ||
+		msg.Raft.PrevTerm == lastTerm && msg.Sequence >= r.lastIndex()
+	grant := msg.View == r.term && (r.vote == "" || r.vote == msg.Sender) && upToDate
+	if grant {
+		r.vote = msg.Sender
+		if err := r.persistTerm(); err != nil {
+			return err
+		}
+		r.armElection()
+	}
+	return r.node.Send(msg.Sender, &pb.ConsensusMessage{
+		Type: pb.ConsensusMessage_VOTE,
+		View: r.term,
+		Raft: &pb.RaftMessage{Success: grant},
+	})
+}
+
+// handleVote counts a vote for this candidate. r.mu must be held.
+func (r *Raft) handleVote(msg *pb.ConsensusMessage) {
+	if r.role != candidate || msg.View != r.term || !msg.Raft.Success {
+		return
+	}
+	r.votes[msg.Sender] = true
+	if len(r.votes) >= r.membership.Majority() {
+		r.becomeLeader()
+	}
+}
+
+// startElection stands for election in the next term. r.mu must be held.
+func (r *Raft) startElection() {
+	r.term++
+	r.vote = r.node.ID
+	r.role = candidate
+	r.leader = ""
+	r.appended = nil
+	r.node.metrics.viewChanges.Inc()
+	r.votes = map[string]bool{r.node.ID: true}
+	if err := r.persistTerm(); err != nil {
+		r.node.logger.Error("standing for election failed", "term", r.term, "err", err)
+		return
+	}
+	r.node.logger.Info("standing for election", "term", r.term)
+	r.armElection()
+	if len(r.votes) >= r.membership.Majority() {
+		r.becomeLeader()
+		return
+	}
+	if err := r.node.Broadcast(&pb.ConsensusMessage{
+		Type:     pb.ConsensusMessage_REQUEST_VOTE,
+		View:     r.term,
+		Sequence: r.lastIndex(),
+		Raft:     &pb.RaftMessage{PrevTerm: r.termAt(r.lastIndex())},
+	}); err != nil {
+		r.node.logger.Warn("requesting votes failed", "term", r.term, "err", err)
+	}
+}
+
+// becomeLeader takes over the log. The leader starts its term with a
+// no-op entry: once that commits, so has everything before it. r.mu must
+// be held.
+func (r *Raft) becomeLeader() {
+	r.role = leader
+	r.leader = r.node.ID
+	r.next = make(map[string]int64)
+	r.match = make(map[string]int64)
+	r.appended = make(map[int64]time.Time)
+	for _, id := range r.membership.IDs() {
+		if id != r.node.ID {
+			r.next[id] = r.lastIndex() + 1
+		}
+	}
+	r.node.logger.Info("elected leader", "term", r.term)
+	if _, err := r.appendEntry(nil); err != nil {
+		r.node.logger.Error("appending no-op failed", "term", r.term, "err", err)
+	}
+	r.replicate()
+	r.armHeartbeat()
+}
+
+// replicate sends every follower the entries it has not been sent yet, or
+// a heartbeat if there are none. r.mu must be held.
+func (r *Raft) replicate() {
+	r.pending = 0
+	if r.batchTimer != nil {
+		r.batchTimer.Stop()
+		r.batchTimer = nil
+	}
+	for _, id := range r.membership.IDs() {
+		if id != r.node.ID {
+			r.sendAppend(id)
+		}
+	}
+}
+
+// sendAppend sends follower p the entries from the next one it needs,
+// assuming they arrive: later appends follow on without waiting for the
+// reply. A follower that misses one turns the next down, and the leader
+// backs up. r.mu must be held.
+func (r *Raft) sendAppend(p string) {
+	prev := r.next[p] - 1
+	entries := r.log[prev:min(r.lastIndex(), prev+maxAppend)]
+	msg := &pb.ConsensusMessage{
+		Type:     pb.ConsensusMessage_APPEND_ENTRIES,
+		View:     r.term,
+		Sequence: prev,
+		Raft: &pb.RaftMessage{
+			PrevTerm: r.termAt(prev),
+			Commit:   r.commit,
+			Entries:  entries,
+		},
+	}
+	r.next[p] = prev + int64(len(entries)) + 1
+	if err := r.node.Send(p, msg); err != nil {
+		r.node.logger.Warn("sending entries failed", "peer", p, "index", prev+1, "err", err)
+	}
+}
+
+// advanceCommit commits the highest entry of the current term that a
+// majority has, along with everything before it, and tells the followers
+// if it moved. r.mu must be held.
+func (r *Raft) advanceCommit() {
+	for n := r.lastIndex(); n > r.commit && r.termAt(n) == r.term; n-- {
+		count := 1
+		for _, m := range r.match {
+			if m >= n {
+				count++
+			}
+		}
+		if count >= r.membership.Majority() {
+			for i := r.commit + 1; i <= n; i++ {
+				if at, ok := r.appended[i]; ok {
+					r.node.metrics.observePhase("replicate", at)
+					delete(r.appended, i)
+				}
+			}
+			r.commit = n
+			r.applyCommitted()
+			if len(r.match) > 0 && !r.replaying {
+				r.replicate()
+			}
+			return
+		}
+	}
+}
+
+// applyCommitted applies the entries committed since the last call to the
+// VM through the shared apply path, and tells the other replicas what each
+// produced. r.mu must be held.
+func (r *Raft) applyCommitted() {
+	for r.lastApplied < r.commit {
+		r.lastApplied++
+		entry := r.log[r.lastApplied-1]
+		msg := r.apply(r.lastApplied, entry.Term, entry.Request)
+		if !r.replaying {
+			r.node.metrics.roundsDecided.Inc()
+		}
+		if msg == nil {
+			continue
+		}
+		if err := r.node.Broadcast(msg); err != nil {
+			r.node.logger.Warn("sending result failed", "index", r.lastApplied, "err", err)
+		}
+	}
+}
+
+// armElection (re)starts the election timer with a random timeout. r.mu
+// must be held.
+func (r *Raft) armElection() {
+	d := r.timeouts.Election
+	if d > 0 {
+		d += time.Duration(r.rng.Int63n(int64(d)))
+	}
+	r.arm(d)
+}
+
+// armHeartbeat (re)starts the leader's heartbeat timer. r.mu must be held.
+func (r *Raft) armHeartbeat() {
+	r.arm(r.timeouts.Heartbeat)
+}
+
+func (r *Raft) arm(d time.Duration) {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.timerGen++
+	gen := r.timerGen
+	r.timer = r.clock.AfterFunc(d, func() { r.onTimeout(gen) })
+}
+
+// onTimeout sends a heartbeat if this replica leads, and stands for
+// election if it does not. A timer armed before the latest call to arm is
+// ignored.
+func (r *Raft) onTimeout(gen int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if gen != r.timerGen || r.node.stopped.Load() {
+		return
+	}
+	if r.role == leader {
+		r.replicate()
+		r.armHeartbeat()
+		return
+	}
+	r.startElection()
+}
+
+// Recover rebuilds this replica's term, vote and log from what w recorded
+// before a restart and logs to w from then on. Entries are applied again
+// once the leader says they are committed. Call it before the replica
+// receives any message.
+func (r *Raft) Recover(w *WAL) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.replaying = true
+	defer func() { r.replaying = false }()
+	for i, rec := range w.Records() {
+		if err := r.replay(rec); err != nil {
+			return fmt.Errorf("WAL record %d: %w", i, err)
+		}
+	}
+	r.wal = w
+
+	r.node.logger.Info("recovered from WAL", "term", r.term, "entries", len(r.log))
+	r.armElection()
+	return nil
+}
+
+// replay applies one WAL record to the state being rebuilt. r.mu must be
+// held.
+func (r *Raft) replay(rec *pb.WALRecord) error {
+	msg := rec.Message
+	if msg == nil {
+		return fmt.Errorf("%v record without a message", rec.Type)
+	}
+	switch rec.Type {
+	case pb.WALRecord_TERM:
+		r.term = msg.View
+		r.vote = rec.Vote
+	case pb.WALRecord_ENTRIES:
+		if msg.Sequence > r.lastIndex() {
+			return fmt.Errorf("entries after index %d, log ends at %d", msg.Sequence, r.lastIndex())
+		}
+		r.log = append(r.log[:msg.Sequence], msg.GetRaft().GetEntries()...)
+	default:
+		return fmt.Errorf("record type %v in a Raft log", rec.Type)
+	}
+	return nil
+}
+
+// persistTerm records the current term and vote. It is a no-op while
+// Recover replays the WAL. r.mu must be held.
+func (r *Raft) persistTerm() error {
+	if r.wal == nil || r.replaying {
+		return nil
+	}
+	rec := &pb.WALRecord{
+		Type:    pb.WALRecord_TERM,
+		Message: &pb.ConsensusMessage{View: r.term},
+		Vote:    r.vote,
+	}
+	if err := r.wal.Append(rec); err != nil {
+		return fmt.Errorf("logging term %d: %w", r.term, err)
+	}
+	return nil
+}
+
+// persistEntries records that entries follow index prev in the log,
+// replacing whatever followed it before. r.mu must be held.
+func (r *Raft) persistEntries(prev int64, entries []*pb.RaftEntry) error {
+	if r.wal == nil || r.replaying {
+		return nil
+	}
+	rec := &pb.WALRecord{
+		Type: pb.WALRecord_ENTRIES,
+		Message: &pb.ConsensusMessage{
+			Type:     pb.ConsensusMessage_APPEND_ENTRIES,
+			View:     r.term,
+			Sequence: prev,
+			Raft:     &pb.RaftMessage{Entries: entries},
+		},
+	}
+	if err := r.wal.Append(rec); err != nil {
+		return fmt.Errorf("logging entries after index %d: %w", prev, err)
+	}
+	return nil
+}
+
+// CatchUp returns an error: a Raft replica has no state to fetch, since
+// the leader sends it every entry it is missing.
+func (r *Raft) CatchUp(ctx context.Context) error {
+	return fmt.Errorf("raft replicas catch up from the leader's log")
+}
+
+// LastApplied returns the index of the last entry applied to the VM.
+func (r *Raft) LastApplied() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastApplied
+}
+
+// Committed returns the highest index this replica knows to be committed.
+func (r *Raft) Committed() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.commit
+}
+
+// NodeState returns how far this replica has got through the log and the
+// state of its VM. The view is the term.
+func (r *Raft) NodeState() *pb.NodeState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &pb.NodeState{
+		NodeId:      r.node.ID,
+		View:        r.term,
+		Primary:     r.leader,
+		LastApplied: r.lastApplied,
+		State:       vmState(r.node.VM),
+	}
+}
+
+// Status reports the term, its leader and this replica's role.
+func (r *Raft) Status() *pb.NodeStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := &pb.NodeStatus{
+		Replication: string(ReplicationRaft),
+		View:        r.term,
+		Leader:      r.leader,
+		Phase:       r.role.String(),
+		LastApplied: r.lastApplied,
+		LastDecided: r.commit,
+		Members:     r.membership.IDs(),
+	}
+	if r.commit > 0 {
+		status.LastDecidedDigest = requestDigest(r.log[r.commit-1].Request)
+	}
+	return status
+}
+
+// Snapshot returns nil: Raft replicas take no stable checkpoints.
+func (r *Raft) Snapshot() *pb.StateSnapshot { return nil }
+
+// ProveMemory proves what memory held after entry req.Sequence on this
+// replica. There are no stable checkpoints to prove, so the sequence must
+// not be 0.
+func (r *Raft) ProveMemory(req *pb.ProveMemoryRequest) (*pb.MemoryProof, error) {
+	if req.Sequence == 0 {
+		return nil, fmt.Errorf("raft replicas have no stable checkpoint")
+	}
+	x := r.get(req.Sequence)
+	if x == nil {
+		return nil, fmt.Errorf("no result for sequence %d", req.Sequence)
+	}
+	return proveMemory(&pb.MemoryProof{Sequence: req.Sequence}, x.result.State, req)
+}
@@ -0,0 +1,286 @@
+package network_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+)
+
+// newRaftNode returns node id of members with a Raft replica attached.
+func newRaftNode(t *testing.T, id string, members network.Membership) (*network.Node, *network.Raft) {
+	t.Helper()
+	node := network.NewNode(id, "", vm.NewVM(nil, io.Discard), nil)
+	node.SetKey(keyFor(id))
+	r, err := network.NewRaft(node, members)
+	if err != nil {
+		t.Fatalf("NewRaft: %v", err)
+	}
+	node.SetReplicator(r)
+	return node, r
+}
+
+// startRaftCluster is startMemoryCluster with Raft replicas.
+func startRaftCluster(t *testing.T, n int, seed int64) (*network.MemoryNetwork, []*network.Node, []*network.Raft) {
+	t.Helper()
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("node%d", i+1)
+	}
+	members, err := network.NewMembership(ids...)
+	if err != nil {
+		t.Fatalf("NewMembership: %v", err)
+	}
+	nodes := make([]*network.Node, n)
+	rs := make([]*network.Raft, n)
+	for i, id := range ids {
+		nodes[i], rs[i] = newRaftNode(t, id, members)
+	}
+	net := network.NewMemoryNetwork(seed)
+	net.Join(nodes...)
+	return net, nodes, rs
+}
+
+// raftLeader returns the index of the replica among rs that leads the
+// latest term, or -1 if none does.
+func raftLeader(rs []*network.Raft, nodes []*network.Node) int {
+	found, term := -1, int64(-1)
+	for i, r := range rs {
+		if r.Leader() == nodes[i].ID && r.Term() > term {
+			found, term = i, r.Term()
+		}
+	}
+	return found
+}
+
+// electLeader runs net until one of the replicas at indexes among rs
+// leads, and returns its index.
+func electLeader(t *testing.T, net *network.MemoryNetwork, nodes []*network.Node, rs []*network.Raft, among ...int) int {
+	t.Helper()
+	leader := -1
+	if !net.RunUntil(func() bool {
+		leader = raftLeader(rs, nodes)
+		for _, i := range among {
+			if i == leader {
+				return true
+			}
+		}
+		return len(among) == 0 && leader >= 0
+	}, 100000) {
+		t.Fatal("no leader was elected")
+	}
+	return leader
+}
+
+// submit has r append acc from to to, one request each, and returns the
+// index of the last.
+func submit(t *testing.T, r *network.Raft, from, to int) int64 {
+	t.Helper()
+	var index int64
+	for _, req := range requests(from, to-from+1) {
+		var err error
+		if index, err = r.Submit(context.Background(), req); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	return index
+}
+
+// raftApplied returns a condition that holds once every replica in rs has
+// applied index.
+func raftApplied(index int64, rs ...*network.Raft) func() bool {
+	return func() bool {
+		for _, r := range rs {
+			if r.LastApplied() < index {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// sameResults fails t unless every replica in rs applied the same request
+// with the same result at each index up to n, and returns the accumulators
+// of the requests in log order, skipping no-ops.
+func sameResults(t *testing.T, n int64, nodes []*network.Node, rs []*network.Raft) []int32 {
+	t.Helper()
+	var accs []int32
+	for index := int64(1); index <= n; index++ {
+		want, _ := rs[0].Result(index)
+		for i, r := range rs {
+			got, _ := r.Result(index)
+			if (got == nil) != (want == nil) {
+				t.Fatalf("index %d: %s and %s disagree on whether it is a no-op", index, nodes[0].ID, nodes[i].ID)
+			}
+			if got == nil {
+				continue
+			}
+			if got.State.Acc != want.State.Acc {
+				t.Errorf("index %d: %s got acc %d, %s got %d", index, nodes[0].ID, want.State.Acc, nodes[i].ID, got.State.Acc)
+			}
+		}
+		if want != nil {
+			accs = append(accs, want.State.Acc)
+		}
+	}
+	return accs
+}
+
+func TestRaft_ElectsALeaderAndReplicates(t *testing.T) {
+	net, nodes, rs := startRaftCluster(t, 3, 1)
+	leader := electLeader(t, net, nodes, rs)
+	if !net.RunUntil(func() bool {
+		for _, r := range rs {
+			if r.Leader() != nodes[leader].ID {
+				return false
+			}
+		}
+		return true
+	}, 100000) {
+		t.Fatalf("not every replica follows %s", nodes[leader].ID)
+	}
+
+	submit(t, rs[leader], 1, 5)
+	// The leader's no-op, then the five requests.
+	if !net.RunUntil(raftApplied(6, rs...), 100000) {
+		t.Fatal("the cluster did not apply the requests")
+	}
+	accs := sameResults(t, 6, nodes, rs)
+	if fmt.Sprint(accs) != "[1 2 3 4 5]" {
+		t.Errorf("expected accumulators [1 2 3 4 5], got %v", accs)
+	}
+	// A majority of RESULTs certifies each result.
+	if !net.RunUntil(func() bool {
+		for _, r := range rs {
+			if _, certified := r.Result(6); !certified {
+				return false
+			}
+		}
+		return true
+	}, 100000) {
+		t.Error("the results were not certified")
+	}
+
+	follower := (leader + 1) % 3
+	if _, err := rs[follower].Submit(context.Background(), requests(6, 1)[0]); err == nil {
+		t.Error("a follower accepted a request")
+	}
+}
+
+func TestRaft_LeaderCrashIsReplaced(t *testing.T) {
+	net, nodes, rs := startRaftCluster(t, 3, 2)
+	old := electLeader(t, net, nodes, rs)
+	submit(t, rs[old], 1, 3)
+	if !net.RunUntil(raftApplied(4, rs...), 100000) {
+		t.Fatal("the cluster did not apply the first requests")
+	}
+
+	nodes[old].Stop()
+	var live []int
+	var liveRs []*network.Raft
+	for i := range rs {
+		if i != old {
+			live = append(live, i)
+			liveRs = append(liveRs, rs[i])
+		}
+	}
+	leader := electLeader(t, net, nodes, rs, live...)
+	submit(t, rs[leader], 4, 6)
+	// The new leader's no-op follows the first four entries.
+	if !net.RunUntil(raftApplied(8, liveRs...), 100000) {
+		t.Fatal("the surviving replicas did not apply the later requests")
+	}
+	liveNodes := []*network.Node{nodes[live[0]], nodes[live[1]]}
+	accs := sameResults(t, 8, liveNodes, liveRs)
+	if fmt.Sprint(accs) != "[1 2 3 4 5 6]" {
+		t.Errorf("expected accumulators [1 2 3 4 5 6], got %v", accs)
+	}
+}
+
+func TestRaft_MinorityPartitionCannotCommit(t *testing.T) {
+	net, nodes, rs := startRaftCluster(t, 5, 3)
+	old := electLeader(t, net, nodes, rs)
+
+	// Cut the leader off with one follower; the other three elect a new
+	// leader and carry on without them.
+	buddy := (old + 1) % 5
+	var majority []int
+	for i := range rs {
+		if i != old && i != buddy {
+			majority = append(majority, i)
+		}
+	}
+	net.Partition([]string{nodes[old].ID, nodes[buddy].ID})
+	submit(t, rs[old], 100, 100)
+	leader := electLeader(t, net, nodes, rs, majority...)
+	if rs[old].Committed() > 1 || rs[buddy].Committed() > 1 {
+		t.Fatalf("the minority committed index %d", max(rs[old].Committed(), rs[buddy].Committed()))
+	}
+	last := submit(t, rs[leader], 1, 2)
+
+	// Once healed, the old leader steps down and its uncommitted entry is
+	// replaced by the majority's log.
+	net.Heal()
+	if !net.RunUntil(raftApplied(last, rs...), 100000) {
+		t.Fatal("the cluster did not converge after the partition healed")
+	}
+	if accs := sameResults(t, last, nodes, rs); fmt.Sprint(accs) != "[1 2]" {
+		t.Errorf("expected accumulators [1 2], got %v", accs)
+	}
+	if got := rs[old].Leader(); got != nodes[leader].ID {
+		t.Errorf("old leader follows %q, expected %s", got, nodes[leader].ID)
+	}
+}
+
+func TestRaft_RestartRecoversFromWAL(t *testing.T) {
+	net, nodes, rs := startRaftCluster(t, 3, 4)
+	paths := make([]string, 3)
+	for i, r := range rs {
+		paths[i] = filepath.Join(t.TempDir(), nodes[i].ID+".wal")
+		if err := r.Recover(openWAL(t, paths[i])); err != nil {
+			t.Fatalf("Recover: %v", err)
+		}
+	}
+	leader := electLeader(t, net, nodes, rs)
+	submit(t, rs[leader], 1, 3)
+	if !net.RunUntil(raftApplied(4, rs...), 100000) {
+		t.Fatal("the cluster did not apply the requests")
+	}
+
+	// A follower restarts with nothing but its WAL: it keeps its term and
+	// log and applies the log again once the leader says it is committed.
+	f := (leader + 1) % 3
+	term := rs[f].Term()
+	nodes[f].Stop()
+	nodes[f], rs[f] = newRaftNode(t, nodes[f].ID, rs[f].Membership())
+	net.Join(nodes[f])
+	if err := rs[f].Recover(openWAL(t, paths[f])); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if got := rs[f].Term(); got != term {
+		t.Errorf("recovered term %d, expected %d", got, term)
+	}
+
+	submit(t, rs[leader], 4, 4)
+	if !net.RunUntil(raftApplied(5, rs...), 100000) {
+		t.Fatal("the restarted replica did not catch up")
+	}
+	if accs := sameResults(t, 5, nodes, rs); fmt.Sprint(accs) != "[1 2 3 4]" {
+		t.Errorf("expected accumulators [1 2 3 4], got %v", accs)
+	}
+}
+
+func TestReplicator_ParseReplication(t *testing.T) {
+	for _, s := range []string{"pbft", "raft"} {
+		if mode, err := network.ParseReplication(s); err != nil || string(mode) != s {
+			t.Errorf("ParseReplication(%q) = %q, %v", s, mode, err)
+		}
+	}
+	if _, err := network.ParseReplication("paxos"); err == nil {
+		t.Error("ParseReplication accepted paxos")
+	}
+}
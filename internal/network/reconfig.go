@@ -0,0 +1,307 @@
+package network
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"sort"
+
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// A PBFT cluster changes its membership in epochs. The operator signs a
+// Reconfiguration with the cluster's admin key and submits it, and it is
+// ordered like any request. Every replica checks the signature before it
+// votes for a proposal that carries one, and again when it applies it, so
+// that a Byzantine primary can neither order a change nobody asked for nor
+// replay one from an earlier epoch. Applying it at slot
+// s does not change anything yet: the new membership takes over from slot
+// s+window+1 on, where window is the log window. A replica that has not
+// applied s cannot have started a slot past s+window, and one that has
+// applied it no longer accepts messages for slots past the end of the
+// epoch until it gets there, so every replica runs each slot under the same
+// membership, and quorums change between two slots rather than in the
+// middle of one. So that nobody has to wait for a window's worth of
+// requests, the primary fills what is left of the epoch with no-ops.
+//
+// Every replica must therefore use the same log window. A node that joins
+// is started with the new membership and catches up through state
+// transfer: checkpoints carry the configuration, and their digest covers
+// it, so the membership it installs is certified by a quorum.
+
+// SetAdminKey sets the key the operator signs membership changes with.
+// Every member must use the same one. Without it, the node refuses every
+// change.
+func (c *Consensus) SetAdminKey(key ed25519.PublicKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.adminKey = key
+}
+
+// SignReconfiguration signs change with key, the private half of the
+// cluster's admin key.
+func SignReconfiguration(change *pb.Reconfiguration, key ed25519.PrivateKey) {
+	change.Signature = ed25519.Sign(key, changeBytes(change))
+}
+
+// changeBytes is what the operator's signature covers: change with its
+// signature cleared, in deterministic encoding.
+func changeBytes(change *pb.Reconfiguration) []byte {
+	unsigned := proto.Clone(change).(*pb.Reconfiguration)
+	unsigned.Signature = nil
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(unsigned)
+	return b
+}
+
+// VerifyReconfiguration checks that the operator signed change.
+func (c *Consensus) VerifyReconfiguration(change *pb.Reconfiguration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.verifyChange(change)
+}
+
+// verifyChange is VerifyReconfiguration with c.mu held.
+func (c *Consensus) verifyChange(change *pb.Reconfiguration) error {
+	if c.adminKey == nil {
+		return fmt.Errorf("node %s has no admin key to check membership changes with", c.node.ID)
+	}
+	if !ed25519.Verify(c.adminKey, changeBytes(change), change.GetSignature()) {
+		return fmt.Errorf("membership change is not signed with the admin key")
+	}
+	return nil
+}
+
+// genesis returns the configuration of epoch 0, made of the founding
+// members.
+func genesis(members Membership) *pb.Configuration {
+	cfg := &pb.Configuration{Start: 1}
+	for _, id := range members.ids {
+		cfg.Members = append(cfg.Members, &pb.Member{Id: id})
+	}
+	return cfg
+}
+
+// membershipOf returns the membership of cfg.
+func membershipOf(cfg *pb.Configuration) (Membership, error) {
+	ids := make([]string, len(cfg.GetMembers()))
+	for i, m := range cfg.GetMembers() {
+		ids[i] = m.Id
+	}
+	return NewMembership(ids...)
+}
+
+// checkpointDigest identifies what a replica holds after a checkpoint's
+// slot in CHECKPOINT messages: the commitment of its VM state and the
+// configuration in force.
+func checkpointDigest(state *pb.VMState, cfg *pb.Configuration) string {
+	return digest(&pb.StateSnapshot{State: commitment(state), Configuration: cfg})
+}
+
+// Configuration returns the membership this node is in and the change
+// already agreed on, if any.
+func (c *Consensus) Configuration() *pb.Configuration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return proto.Clone(c.config).(*pb.Configuration)
+}
+
+// setConfiguration makes cfg the configuration in force and updates the
+// quorums to its membership. Configurations are never modified once set,
+// so checkpoints may share them. c.mu must be held.
+func (c *Consensus) setConfiguration(cfg *pb.Configuration) {
+	members, err := membershipOf(cfg)
+	if err != nil {
+		c.node.logger.Error("ignoring invalid configuration", "epoch", cfg.GetEpoch(), "err", err)
+		return
+	}
+	if c.config != nil && cfg.Epoch != c.config.Epoch {
+		c.prevConfig = c.config
+	}
+	c.config = cfg
+	c.membership = members
+	c.decisionQuorum = members.Quorum()
+	c.setQuorum(members.Quorum())
+}
+
+// knownConfigs returns the configurations this node knows were agreed,
+// oldest first: the epoch before the current one, the current one and the
+// next. c.mu must be held.
+func (c *Consensus) knownConfigs() []*pb.Configuration {
+	var known []*pb.Configuration
+	if c.prevConfig != nil {
+		known = append(known, c.prevConfig)
+	}
+	known = append(known, c.config)
+	if next := c.config.GetNext(); next != nil {
+		known = append(known, next)
+	}
+	return known
+}
+
+// configIn returns the configuration among known that was in force at
+// seq, or nil if seq comes before all of them.
+func configIn(known []*pb.Configuration, seq int64) *pb.Configuration {
+	for i := len(known) - 1; i >= 0; i-- {
+		if known[i].Start <= seq {
+			return known[i]
+		}
+	}
+	return nil
+}
+
+// highWatermark returns the last slot this node accepts messages for: a
+// log window past the last applied slot, but not past the end of the
+// epoch once a membership change has been agreed. c.mu must be held.
+func (c *Consensus) highWatermark() int64 {
+	high := c.lastApplied + c.window
+	if next := c.config.GetNext(); next != nil {
+		high = min(high, next.Start-1)
+	}
+	return high
+}
+
+// reconfigure applies change, decided at seq, in place of running a
+// program: it schedules the next epoch. A change that does not fit the
+// current membership, such as adding a member twice, faults. c.mu must be
+// held.
+func (c *Consensus) reconfigure(seq int64, change *pb.Reconfiguration) *pb.ExecutionResult {
+	res := &pb.ExecutionResult{State: vmState(c.node.VM)}
+	next, err := c.nextConfiguration(change)
+	if err != nil {
+		c.node.logger.Warn("membership change refused", "seq", seq, "err", err)
+		res.Fault = err.Error()
+		return res
+	}
+	next.Epoch = c.config.Epoch + 1
+	next.Start = seq + c.window + 1
+
+	cfg := proto.Clone(c.config).(*pb.Configuration)
+	cfg.Next = next
+	c.config = cfg
+	members, _ := membershipOf(next)
+	c.node.logger.Info("membership change agreed", "seq", seq, "epoch", next.Epoch,
+		"start", next.Start, "members", members.IDs())
+	res.Output = fmt.Appendf(nil, "epoch %d starts at sequence %d with %v (%v)\n",
+		next.Epoch, next.Start, members.IDs(), members)
+	return res
+}
+
+// nextConfiguration returns the members of the configuration change leads
+// to. c.mu must be held.
+func (c *Consensus) nextConfiguration(change *pb.Reconfiguration) (*pb.Configuration, error) {
+	if err := c.verifyChange(change); err != nil {
+		return nil, err
+	}
+	if change.Epoch != c.config.Epoch {
+		return nil, fmt.Errorf("membership change is for epoch %d, the cluster is in epoch %d", change.Epoch, c.config.Epoch)
+	}
+	if next := c.config.GetNext(); next != nil {
+		return nil, fmt.Errorf("a membership change is already under way until sequence %d", next.Start)
+	}
+	m := change.GetMember()
+	if m.GetId() == "" {
+		return nil, fmt.Errorf("membership change without a member ID")
+	}
+
+	var members []*pb.Member
+	switch change.Action {
+	case pb.Reconfiguration_ADD:
+		if c.membership.Contains(m.Id) {
+			return nil, fmt.Errorf("%s is already a member", m.Id)
+		}
+		if len(m.PublicKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key of new member %s is %d bytes, expected %d", m.Id, len(m.PublicKey), ed25519.PublicKeySize)
+		}
+		members = append(members, c.config.Members...)
+		members = append(members, &pb.Member{Id: m.Id, Address: m.Address, PublicKey: m.PublicKey})
+		sort.Slice(members, func(i, j int) bool { return members[i].Id < members[j].Id })
+	case pb.Reconfiguration_REMOVE:
+		if !c.membership.Contains(m.Id) {
+			return nil, fmt.Errorf("%s is not a member", m.Id)
+		}
+		if c.membership.N() == 1 {
+			return nil, fmt.Errorf("cannot remove the last member")
+		}
+		for _, member := range c.config.Members {
+			if member.Id != m.Id {
+				members = append(members, member)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown membership change %v", change.Action)
+	}
+	return &pb.Configuration{Members: members}, nil
+}
+
+// maybeEnterEpoch moves to the next configuration once every slot of the
+// current epoch has been applied. A node that is no longer a member stops
+// taking part and asks whoever runs it to shut down. c.mu must be held.
+func (c *Consensus) maybeEnterEpoch() {
+	next := c.config.GetNext()
+	if next == nil || c.lastApplied < next.Start-1 {
+		return
+	}
+	// What the node has held back is for the members of the epoch that
+	// ends, some of whom may be about to leave.
+	c.flush()
+	prev := c.membership
+	c.setConfiguration(next)
+	c.node.logger.Info("entering epoch", "epoch", next.Epoch, "seq", next.Start,
+		"members", c.membership.IDs(), "quorum", c.decisionQuorum)
+	c.connectMembers(prev)
+	for _, vcs := range c.viewChanges {
+		for id := range vcs {
+			if !c.membership.Contains(id) {
+				delete(vcs, id)
+			}
+		}
+	}
+
+	if !c.membership.Contains(c.node.ID) {
+		c.node.logger.Info("removed from the cluster", "epoch", next.Epoch)
+		c.failQueue(fmt.Errorf("node %s is no longer a cluster member", c.node.ID))
+		c.stopTimer()
+		c.node.requestShutdown()
+		return
+	}
+	c.resetTimer()
+}
+
+// connectMembers brings the node's peers in line with the membership after
+// it changed from prev: it connects to the members that joined and drops
+// those that left, once they have been sent what was already queued for
+// them. c.mu must be held.
+func (c *Consensus) connectMembers(prev Membership) {
+	for _, m := range c.config.Members {
+		if err := c.node.connectMember(m); err != nil {
+			c.node.logger.Warn("connecting to new member failed", "peer", m.Id, "err", err)
+		}
+	}
+	for _, id := range prev.ids {
+		if id != c.node.ID && !c.membership.Contains(id) {
+			c.node.retirePeer(id)
+		}
+	}
+}
+
+// closeEpoch has the primary fill the slots left in the epoch with no-ops
+// once a membership change has been agreed, so that the change takes over
+// without waiting for requests. It keeps no more than a batch of slots in
+// flight, and tops them up as they are applied: a whole log window of
+// no-ops at once could not commit within a phase timeout. c.mu must be
+// held.
+func (c *Consensus) closeEpoch() {
+	next := c.config.GetNext()
+	if next == nil || c.replaying || c.canPropose(0) != nil {
+		return
+	}
+	inFlight := c.nextSeq - 1 - c.lastApplied
+	n := min(next.Start-c.nextSeq, int64(max(c.batching.Size, 1))-inFlight)
+	if n <= 0 {
+		return
+	}
+	c.node.logger.Info("closing epoch", "epoch", c.config.Epoch, "seq", c.nextSeq, "last", c.nextSeq+n-1, "start", next.Start)
+	if _, err := c.proposeBatch(make([]*pb.Execution, n)); err != nil {
+		c.node.logger.Warn("closing epoch failed", "epoch", c.config.Epoch, "err", err)
+	}
+}
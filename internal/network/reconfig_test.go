@@ -0,0 +1,325 @@
+package network_test
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+// adminKey is the operator key test clusters take membership changes
+// from.
+var adminKey = keyFor("admin")
+
+// change returns a request that adds or removes the node with the given
+// ID, whose key is keyFor(id), signed by the operator in epoch 0.
+func change(action pb.Reconfiguration_Action, id string) *pb.Execution {
+	exec := &pb.Execution{
+		Id: fmt.Sprintf("%v-%s", action, id),
+		Reconfiguration: &pb.Reconfiguration{
+			Action: action,
+			Member: &pb.Member{Id: id, PublicKey: keyFor(id).Public().(ed25519.PublicKey)},
+		},
+	}
+	network.SignReconfiguration(exec.Reconfiguration, adminKey)
+	return exec
+}
+
+// inEpoch returns a condition that holds once every instance in cs has
+// entered epoch.
+func inEpoch(epoch int64, cs ...*network.Consensus) func() bool {
+	return func() bool {
+		for _, c := range cs {
+			if c.Configuration().Epoch < epoch {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func TestReconfig_AddedNodeCatchesUpAndVotes(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	checkpointEvery(4, cs...)
+	propose(t, cs[0], 1, 3)
+	if !net.RunUntil(allApplied(3, cs...), 10000) {
+		t.Fatal("the cluster did not apply the first requests")
+	}
+
+	// node5 starts out with the membership it is joining.
+	members, err := network.NewMembership(memberIDs(5)...)
+	if err != nil {
+		t.Fatalf("NewMembership: %v", err)
+	}
+	node5 := network.NewNode("node5", "", vm.NewVM(nil, io.Discard), nil)
+	node5.SetKey(keyFor("node5"))
+	c5, err := network.NewConsensus(node5, members)
+	if err != nil {
+		t.Fatalf("NewConsensus: %v", err)
+	}
+	c5.SetTimeouts(testTimeouts)
+	c5.SetAdminKey(adminKey.Public().(ed25519.PublicKey))
+	c5.SetCheckpointInterval(4)
+	node5.SetReplicator(c5)
+	net.Join(node5)
+
+	add := change(pb.Reconfiguration_ADD, "node5")
+	seqs, err := cs[0].ProposeBatch([]*pb.Execution{add})
+	if err != nil {
+		t.Fatalf("ProposeBatch: %v", err)
+	}
+	if !net.RunUntil(allApplied(seqs[0], cs...), 10000) {
+		t.Fatal("the cluster did not apply the change")
+	}
+	if report := cs[1].ResultByID(add.Id); report == nil || report.Result.Fault != "" ||
+		!strings.Contains(string(report.Result.Output), "epoch 1 starts at sequence") {
+		t.Errorf("unexpected report for the change: %v", report)
+	}
+
+	all := append(cs, c5)
+	if !net.RunUntil(inEpoch(1, all...), 100000) {
+		t.Fatalf("the cluster did not enter epoch 1: node5 applied up to %d", c5.LastApplied())
+	}
+	start := seqs[0] + network.DefaultLogWindow + 1
+	for i, c := range all {
+		cfg := c.Configuration()
+		if cfg.Start != start || c.Membership().N() != 5 || c.Membership().Quorum() != 4 {
+			t.Errorf("node%d: epoch starts at %d with %v, expected %d with 5 members", i+1, cfg.Start, c.Membership(), start)
+		}
+	}
+	// node5 got there through a checkpoint, not by executing the log.
+	if c5.StableCheckpoint() == nil {
+		t.Error("node5 has no stable checkpoint")
+	}
+	sameState(t, "node5", c5, cs[0])
+
+	// With node4 cut off, the cluster needs node5's votes for a quorum.
+	net.Partition([]string{"node4"})
+	if err := cs[0].StartConsensus(proposal(9)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	if !net.RunUntil(allApplied(start, cs[0], cs[1], cs[2], c5), 10000) {
+		t.Fatal("the new membership did not decide without node4")
+	}
+	if got := proposedAcc(c5.Decided(start)); got != 9 {
+		t.Errorf("node5: expected acc 9 in slot %d, got %d", start, got)
+	}
+	sameDecisions(t, start, append(nodes[:3:3], node5), []*network.Consensus{cs[0], cs[1], cs[2], c5})
+}
+
+func TestReconfig_RemovedPrimaryHandsOver(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	seqs, err := cs[0].ProposeBatch([]*pb.Execution{change(pb.Reconfiguration_REMOVE, "node1")})
+	if err != nil {
+		t.Fatalf("ProposeBatch: %v", err)
+	}
+	if !net.RunUntil(inEpoch(1, cs[1:]...), 100000) {
+		t.Fatal("the remaining members did not enter epoch 1")
+	}
+	select {
+	case <-nodes[0].ShutdownRequested():
+	default:
+		t.Error("node1 was removed but did not ask to shut down")
+	}
+	for _, c := range cs[1:] {
+		if ids := c.Membership().IDs(); !slices.Equal(ids, []string{"node2", "node3", "node4"}) || c.Leader() != "node2" {
+			t.Errorf("expected node2 to lead node2, node3 and node4, got %s leading %v", c.Leader(), ids)
+		}
+	}
+
+	// The new primary takes over in the same view, from the first slot of
+	// the epoch.
+	start := seqs[0] + network.DefaultLogWindow + 1
+	if seqs, err = cs[1].ProposeBatch(requests(7, 1)); err != nil || seqs[0] != start {
+		t.Fatalf("ProposeBatch at node2: %v, %v", seqs, err)
+	}
+	if !net.RunUntil(allApplied(start, cs[1:]...), 10000) {
+		t.Fatal("the new membership did not decide")
+	}
+	if got := cs[0].LastApplied(); got != start-1 {
+		t.Errorf("node1 applied up to %d after leaving at %d", got, start-1)
+	}
+	if cs[1].View() != 0 {
+		t.Errorf("the primary's removal changed the view to %d", cs[1].View())
+	}
+}
+
+func TestReconfig_ClosesTheEpochABatchAtATime(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	const size = 4
+	cs[0].SetBatching(network.Batching{Size: size})
+
+	// A whole log window of no-ops at once could not commit within a
+	// phase timeout on a slow network.
+	var highest int64
+	net.Intercept("node1", func(to string, msg *pb.ConsensusMessage) []*pb.ConsensusMessage {
+		for _, m := range append([]*pb.ConsensusMessage{msg}, msg.Batch...) {
+			if m.Type == pb.ConsensusMessage_PRE_PREPARE {
+				highest = max(highest, m.Sequence)
+			}
+		}
+		return []*pb.ConsensusMessage{msg}
+	})
+	if _, err := cs[0].ProposeBatch([]*pb.Execution{change(pb.Reconfiguration_REMOVE, "node4")}); err != nil {
+		t.Fatalf("ProposeBatch: %v", err)
+	}
+	var inFlight int64
+	left := func() bool {
+		select {
+		case <-nodes[3].ShutdownRequested():
+			return true
+		default:
+			return false
+		}
+	}
+	done := net.RunUntil(func() bool {
+		inFlight = max(inFlight, highest-cs[0].LastApplied())
+		return inEpoch(1, cs[:3]...)() && left()
+	}, 100000)
+	if !done {
+		t.Fatalf("the cluster did not move on without node4: epoch %d, node4 shutting down: %v",
+			cs[0].Configuration().Epoch, left())
+	}
+	if inFlight > size {
+		t.Errorf("the primary had %d slots in flight, expected at most %d", inFlight, size)
+	}
+}
+
+func TestReconfig_InvalidChangesFault(t *testing.T) {
+	net, _, cs := startMemoryCluster(t, 4, 1)
+	later := change(pb.Reconfiguration_REMOVE, "node2")
+	later.Id += "-later"
+	later.Reconfiguration.Epoch = 3
+	network.SignReconfiguration(later.Reconfiguration, adminKey)
+	reqs := []*pb.Execution{
+		change(pb.Reconfiguration_ADD, "node2"),
+		change(pb.Reconfiguration_REMOVE, "node9"),
+		later,
+		change(pb.Reconfiguration_REMOVE, "node4"),
+		change(pb.Reconfiguration_REMOVE, "node3"),
+	}
+	if _, err := cs[0].ProposeBatch(reqs); err != nil {
+		t.Fatalf("ProposeBatch: %v", err)
+	}
+	if !net.RunUntil(allApplied(5, cs...), 10000) {
+		t.Fatal("the cluster did not apply the changes")
+	}
+	for i, want := range []string{"already a member", "not a member", "for epoch 3", "", "already under way"} {
+		report := cs[1].ResultByID(reqs[i].Id)
+		if report == nil {
+			t.Fatalf("no report for %s", reqs[i].Id)
+		}
+		if fault := report.Result.Fault; (want == "") != (fault == "") || !strings.Contains(fault, want) {
+			t.Errorf("%s: expected a fault containing %q, got %q", reqs[i].Id, want, fault)
+		}
+	}
+	next := cs[1].Configuration().Next
+	if next == nil || len(next.Members) != 3 {
+		t.Errorf("expected the removal of node4 to be pending, got %v", next)
+	}
+}
+
+func TestReconfig_PrimaryCannotChangeTheMembershipAlone(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+
+	// node1 proposes adding node5 without the operator's signature.
+	unsigned := change(pb.Reconfiguration_ADD, "node5")
+	unsigned.Reconfiguration.Signature = nil
+	net.Intercept("node1", equivocate(nodes[0], unsigned, "node2", "node3", "node4"))
+	if err := cs[0].StartConsensus(proposal(1)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+
+	// The backups refuse it and replace node1 at once, and the new primary
+	// orders requests as usual.
+	backups := cs[1:]
+	inView := func() bool {
+		for _, c := range backups {
+			if c.View() < 1 || c.Leader() != "node2" {
+				return false
+			}
+		}
+		return true
+	}
+	if !net.RunUntil(inView, 10000) {
+		t.Fatal("the backups did not replace the primary")
+	}
+	seqs, err := cs[1].ProposeBatch(requests(2, 1))
+	if err != nil {
+		t.Fatalf("ProposeBatch: %v", err)
+	}
+	if !net.RunUntil(allApplied(seqs[0], backups...), 10000) {
+		t.Fatal("the new primary did not decide")
+	}
+	for i, c := range backups {
+		for seq := int64(1); seq <= seqs[0]; seq++ {
+			if c.Decided(seq).GetRequest().GetReconfiguration() != nil {
+				t.Errorf("node%d decided the unsigned change in slot %d", i+2, seq)
+			}
+		}
+		if next := c.Configuration().Next; next != nil || c.Membership().N() != 4 {
+			t.Errorf("node%d changed its membership: %v, next %v", i+2, c.Membership(), next)
+		}
+	}
+}
+
+func TestReconfig_RestartedNodeRecoversTheMembership(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	checkpointEvery(4, cs...)
+	founding := cs[1].Membership()
+	path := filepath.Join(t.TempDir(), "node2.wal")
+	if err := cs[1].Recover(openWAL(t, path)); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+
+	seqs, err := cs[0].ProposeBatch([]*pb.Execution{change(pb.Reconfiguration_REMOVE, "node4")})
+	if err != nil {
+		t.Fatalf("ProposeBatch: %v", err)
+	}
+	if !net.RunUntil(inEpoch(1, cs[:3]...), 100000) {
+		t.Fatal("the remaining members did not enter epoch 1")
+	}
+	// A checkpoint in the new epoch compacts the WAL past the change.
+	start := seqs[0] + network.DefaultLogWindow + 1
+	if _, err := cs[0].ProposeBatch(requests(1, 4)); err != nil {
+		t.Fatalf("ProposeBatch: %v", err)
+	}
+	if !net.RunUntil(stableAt(start+2, cs[:3]...), 10000) {
+		t.Fatal("no checkpoint became stable in the new epoch")
+	}
+
+	// node2 comes back with the cluster config it was started with.
+	nodes[1].Stop()
+	node2 := network.NewNode("node2", "", vm.NewVM(nil, io.Discard), nil)
+	node2.SetKey(keyFor("node2"))
+	c2, err := network.NewConsensus(node2, founding)
+	if err != nil {
+		t.Fatalf("NewConsensus: %v", err)
+	}
+	c2.SetTimeouts(testTimeouts)
+	c2.SetAdminKey(adminKey.Public().(ed25519.PublicKey))
+	c2.SetCheckpointInterval(4)
+	node2.SetReplicator(c2)
+	net.Join(node2)
+	if err := c2.Recover(openWAL(t, path)); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if cfg := c2.Configuration(); cfg.Epoch != 1 || c2.Membership().N() != 3 {
+		t.Fatalf("node2 recovered epoch %d with %v, expected epoch 1 with 3 members", cfg.Epoch, c2.Membership())
+	}
+
+	if _, err := cs[0].ProposeBatch(requests(5, 1)); err != nil {
+		t.Fatalf("ProposeBatch: %v", err)
+	}
+	if !net.RunUntil(allApplied(start+4, cs[0], c2, cs[2]), 10000) {
+		t.Fatal("the recovered node did not decide with the others")
+	}
+	sameState(t, "node2", c2, cs[0])
+}
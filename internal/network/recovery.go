@@ -0,0 +1,171 @@
+package network
+
+import (
+	"fmt"
+
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+// Recover rebuilds this node's consensus state from what w recorded before
+// a restart and logs to w from then on. It puts the node back in the view
+// it was in, with the proposals it accepted and the votes it cast, so it
+// cannot be talked into voting differently; restores its VM from the last
+// checkpoint, in the membership it had then; and re-executes the slots
+// decided since. Call it before the node receives any message.
+func (c *Consensus) Recover(w *WAL) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.replaying = true
+	defer func() { c.replaying = false }()
+	prev := c.membership
+	for i, rec := range w.Records() {
+		if err := c.replay(rec); err != nil {
+			return fmt.Errorf("WAL record %d: %w", i, err)
+		}
+	}
+	c.applyDecided()
+	c.connectMembers(prev)
+	c.wal = w
+
+	c.node.logger.Info("recovered from WAL", "view", c.currentView, "applied", c.lastApplied)
+	if c.changing {
+		c.armTimer(c.timeouts.ViewChange)
+	} else {
+		c.resetTimer()
+	}
+	return nil
+}
+
+// replay applies one WAL record to the state being rebuilt. c.mu must be
+// held.
+func (c *Consensus) replay(rec *pb.WALRecord) error {
+	msg := rec.Message
+	if rec.Type != pb.WALRecord_CHECKPOINT && rec.Type != pb.WALRecord_PREPARED && msg == nil {
+		return fmt.Errorf("%v record without a message", rec.Type)
+	}
+
+	switch rec.Type {
+	case pb.WALRecord_ACCEPT:
+		phase := Prepare
+		if msg.Sender == c.node.ID {
+			phase = PrePrepare
+		}
+		s := c.slot(msg.Sequence)
+		s.accept(msg.Request, phase)
+		c.prepares.add(voteKey(msg.View, msg.Sequence, msg.Request), msg)
+		c.nextSeq = max(c.nextSeq, msg.Sequence+1)
+
+	case pb.WALRecord_SENT:
+		switch msg.Type {
+		case pb.ConsensusMessage_PRE_PREPARE, pb.ConsensusMessage_PREPARE:
+			c.prepares.add(voteKey(msg.View, msg.Sequence, msg.Request), msg)
+		case pb.ConsensusMessage_COMMIT:
+			c.commits.add(voteKey(msg.View, msg.Sequence, msg.Request), msg)
+			// Only a node that saw a prepare quorum sends COMMIT.
+			if s := c.slot(msg.Sequence); s.decided == nil {
+				s.phase = Commit
+			}
+		case pb.ConsensusMessage_VIEW_CHANGE:
+			c.recordViewChange(msg)
+			c.changing = true
+			c.pendingView = msg.View
+		}
+
+	case pb.WALRecord_PREPARED:
+		e := rec.Prepared
+		if e == nil {
+			return fmt.Errorf("prepared record for sequence %d without an entry", rec.Sequence)
+		}
+		if s := c.slot(e.Sequence); s.decided == nil {
+			s.prepared = e.Request
+			s.preparedView = e.View
+			s.proof = e.Proof
+		}
+
+	case pb.WALRecord_VIEW:
+		c.installView(msg)
+
+	case pb.WALRecord_DECIDE:
+		s := c.slot(msg.Sequence)
+		s.phase = Finalize
+		s.decided = msg
+
+	case pb.WALRecord_CHECKPOINT:
+		if rec.State == nil {
+			return fmt.Errorf("checkpoint at sequence %d without a state", rec.Sequence)
+		}
+		setRoot(rec.State)
+		c.node.VM.UpdateState(rec.State)
+		c.lastApplied = max(c.lastApplied, rec.Sequence)
+		c.skipTo(c.lastApplied)
+		c.nextSeq = max(c.nextSeq, rec.Sequence+1)
+		if s, ok := c.slots[rec.Sequence]; ok {
+			c.decidedValue = s.decided
+		}
+		cfg := rec.Configuration
+		if cfg == nil {
+			cfg = c.config
+		}
+		c.setConfiguration(cfg)
+		if rec.Stable != nil {
+			c.stabilize(rec.Stable, rec.State, cfg)
+		} else {
+			c.snapshots[rec.Sequence] = &pb.StateSnapshot{State: rec.State, Configuration: cfg}
+		}
+		c.maybeEnterEpoch()
+
+	default:
+		return fmt.Errorf("unknown record type %v", rec.Type)
+	}
+	return nil
+}
+
+// persist writes a record of msg to the WAL, if the node has one. It is a
+// no-op while Recover replays the WAL. c.mu must be held.
+func (c *Consensus) persist(typ pb.WALRecord_Type, msg *pb.ConsensusMessage) error {
+	if c.wal == nil || c.replaying {
+		return nil
+	}
+	if err := c.wal.Append(&pb.WALRecord{Type: typ, Message: msg}); err != nil {
+		return fmt.Errorf("logging %v for sequence %d: %w", typ, msg.Sequence, err)
+	}
+	return nil
+}
+
+// persistAccept records that this node accepted the proposal in pp, the
+// signed PRE_PREPARE of the primary of the current view. c.mu must be
+// held.
+func (c *Consensus) persistAccept(pp *pb.ConsensusMessage) error {
+	return c.persist(pb.WALRecord_ACCEPT, pp)
+}
+
+// persistPrepared records the value slot s has prepared and its proof.
+// c.mu must be held.
+func (c *Consensus) persistPrepared(s *slot) error {
+	if c.wal == nil || c.replaying {
+		return nil
+	}
+	rec := &pb.WALRecord{Type: pb.WALRecord_PREPARED, Sequence: s.seq, Prepared: s.preparedEntry()}
+	if err := c.wal.Append(rec); err != nil {
+		return fmt.Errorf("logging %v for sequence %d: %w", rec.Type, s.seq, err)
+	}
+	return nil
+}
+
+// broadcast logs msg as sent and then sends it, so that a node never sends
+// a vote it could forget. While bundle is collecting, the message joins
+// the next batch instead. msg is signed first: a vote is kept, logged and
+// batched with its own signature, so that it can be shown to other nodes
+// as part of a prepare certificate. c.mu must be held.
+func (c *Consensus) broadcast(msg *pb.ConsensusMessage) error {
+	c.node.Sign(msg)
+	if err := c.persist(pb.WALRecord_SENT, msg); err != nil {
+		return err
+	}
+	if c.outbox != nil && batchable(msg.Type) {
+		c.outbox = append(c.outbox, msg)
+		return nil
+	}
+	return c.node.broadcastSigned(msg)
+}
@@ -0,0 +1,91 @@
+package network
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+// Replicator orders client requests into a log that every replica of the
+// cluster applies to its VM in the same order. Consensus implements it
+// with PBFT, which tolerates Byzantine replicas; Raft with Raft, which only
+// tolerates crashes but needs fewer messages. Both apply the log the same
+// way, so results, reports and divergences mean the same in either mode.
+type Replicator interface {
+	// Handle processes a message from another replica, already checked to
+	// be signed by a member of the cluster.
+	Handle(msg *pb.ConsensusMessage) error
+
+	// Membership returns the cluster this replica is a member of.
+	Membership() Membership
+	// Leader returns the ID of the replica that orders requests at the
+	// moment, or "" if there is none.
+	Leader() string
+
+	// Submit queues req to be ordered and returns the slot it got. Only
+	// the leader may call it.
+	Submit(ctx context.Context, req *pb.Execution) (int64, error)
+	// SetBatching sets how Submit groups requests.
+	SetBatching(b Batching)
+	// SetClock replaces the clock timers run on.
+	SetClock(clock Clock)
+
+	// Recover rebuilds the replica's state from w and logs to w from then
+	// on. Call it before the replica receives any message.
+	Recover(w *WAL) error
+	// CatchUp brings a replica that was down up to date with the others,
+	// if its mode needs anything beyond the normal protocol for that.
+	CatchUp(ctx context.Context) error
+
+	// LastApplied returns the sequence number of the last slot applied to
+	// the VM.
+	LastApplied() int64
+	// NodeState returns how far the replica has got and the state of its
+	// VM.
+	NodeState() *pb.NodeState
+	// Status reports the replica's mode, view or term, leader and phase,
+	// and the last slot it decided, for NodeAdmin to add the node's own
+	// details to.
+	Status() *pb.NodeStatus
+	// Snapshot returns the replica's latest certified state, or nil.
+	Snapshot() *pb.StateSnapshot
+	// ProveMemory proves what the replica's memory held after a slot.
+	ProveMemory(req *pb.ProveMemoryRequest) (*pb.MemoryProof, error)
+
+	// Result, ResultByID, Reports and Divergences read what applying the
+	// log produced; see executions.
+	Result(seq int64) (*pb.ExecutionResult, bool)
+	ResultByID(id string) *pb.ExecutionReport
+	Reports(seq int64) ([]*pb.ExecutionReport, <-chan struct{})
+	Divergences() []Divergence
+}
+
+// Replication names a replication mode.
+type Replication string
+
+const (
+	ReplicationPBFT Replication = "pbft"
+	ReplicationRaft Replication = "raft"
+)
+
+// ParseReplication parses a mode given as "pbft" or "raft".
+func ParseReplication(s string) (Replication, error) {
+	switch r := Replication(s); r {
+	case ReplicationPBFT, ReplicationRaft:
+		return r, nil
+	}
+	return "", fmt.Errorf("unknown replication mode %q (want pbft or raft)", s)
+}
+
+// NewReplicator returns the replicator node runs as one of members in the
+// given mode.
+func NewReplicator(mode Replication, node *Node, members Membership) (Replicator, error) {
+	switch mode {
+	case ReplicationPBFT:
+		return NewConsensus(node, members)
+	case ReplicationRaft:
+		return NewRaft(node, members)
+	}
+	return nil, fmt.Errorf("unknown replication mode %q", mode)
+}
@@ -0,0 +1,82 @@
+package network
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// GenerateKey returns a fresh ed25519 key pair for a node.
+func GenerateKey() (ed25519.PublicKey, ed25519.PrivateKey) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(fmt.Sprintf("generating node key: %v", err))
+	}
+	return pub, priv
+}
+
+// SetKey replaces the key the node signs its messages with.
+func (n *Node) SetKey(key ed25519.PrivateKey) {
+	n.keysMu.Lock()
+	defer n.keysMu.Unlock()
+	n.key = key
+	n.keys[n.ID] = key.Public().(ed25519.PublicKey)
+}
+
+// PublicKey returns the key other nodes verify this node's messages with.
+func (n *Node) PublicKey() ed25519.PublicKey {
+	n.keysMu.RLock()
+	defer n.keysMu.RUnlock()
+	return n.keys[n.ID]
+}
+
+// signingBytes is what a signature covers: the message with its signature
+// cleared, in deterministic encoding. Messages built by this package always
+// marshal, so the error is ignored.
+func signingBytes(msg *pb.ConsensusMessage) []byte {
+	unsigned := proto.Clone(msg).(*pb.ConsensusMessage)
+	unsigned.Signature = nil
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(unsigned)
+	return b
+}
+
+// Sign marks msg as sent by this node and signs it.
+func (n *Node) Sign(msg *pb.ConsensusMessage) {
+	n.keysMu.RLock()
+	key := n.key
+	n.keysMu.RUnlock()
+
+	msg.Sender = n.ID
+	msg.Signature = ed25519.Sign(key, signingBytes(msg))
+}
+
+// verifyWith checks that msg is signed with key, or with the key this node
+// has for its sender if key is nil.
+func (n *Node) verifyWith(msg *pb.ConsensusMessage, key ed25519.PublicKey) error {
+	if key == nil {
+		return n.Verify(msg)
+	}
+	if !ed25519.Verify(key, signingBytes(msg), msg.Signature) {
+		return fmt.Errorf("%v from %s has a bad signature", msg.Type, msg.Sender)
+	}
+	return nil
+}
+
+// Verify checks that msg is signed by the node it names as its sender,
+// which must be this node or one of its peers.
+func (n *Node) Verify(msg *pb.ConsensusMessage) error {
+	n.keysMu.RLock()
+	key, ok := n.keys[msg.Sender]
+	n.keysMu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%v from unknown node %q", msg.Type, msg.Sender)
+	}
+	if !ed25519.Verify(key, signingBytes(msg), msg.Signature) {
+		return fmt.Errorf("%v from %s has a bad signature", msg.Type, msg.Sender)
+	}
+	return nil
+}
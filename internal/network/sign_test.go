@@ -0,0 +1,102 @@
+package network_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestSign_VerifiesOnlyTheSignedMessage(t *testing.T) {
+	alice := network.NewNode("alice", "", vm.NewVM(nil, io.Discard), nil)
+	bob := network.NewNode("bob", "", vm.NewVM(nil, io.Discard), nil)
+	if err := bob.ConnectToPeer("alice", "127.0.0.1:1", alice.PublicKey()); err != nil {
+		t.Fatalf("ConnectToPeer: %v", err)
+	}
+
+	msg := proposal(1)
+	alice.Sign(msg)
+	if msg.Sender != "alice" {
+		t.Errorf("expected Sign to set the sender, got %q", msg.Sender)
+	}
+	if err := bob.Verify(msg); err != nil {
+		t.Errorf("expected a valid signature, got %v", err)
+	}
+
+	msg.View = 3
+	if err := bob.Verify(msg); err == nil || !strings.Contains(err.Error(), "bad signature") {
+		t.Errorf("expected a tampered message to fail, got %v", err)
+	}
+
+	stranger := network.NewNode("carol", "", vm.NewVM(nil, io.Discard), nil)
+	msg = proposal(1)
+	stranger.Sign(msg)
+	if err := bob.Verify(msg); err == nil || !strings.Contains(err.Error(), "unknown node") {
+		t.Errorf("expected an unknown sender to fail, got %v", err)
+	}
+}
+
+func TestSign_ReceiveRejectsForgedSender(t *testing.T) {
+	nodes, _ := startCluster(t, 4)
+
+	// An outsider that claims to be node2 but does not hold its key.
+	impostor := network.NewNode("node2", "", vm.NewVM(nil, io.Discard), nil)
+	msg := proposal(1)
+	msg.Type = pb.ConsensusMessage_PREPARE
+	msg.Sequence = 1
+	impostor.Sign(msg)
+
+	conn, err := grpc.NewClient(nodes[0].Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_, err = pb.NewNodeServiceClient(conn).ReceiveMessage(context.Background(), msg)
+	if err == nil || !strings.Contains(err.Error(), "bad signature") {
+		t.Errorf("expected the forged message to be rejected, got %v", err)
+	}
+}
+
+func TestVotes_RepeatedVoteCountsOnce(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+	for i, n := range nodes {
+		if i != 1 {
+			n.Stop()
+		}
+	}
+
+	// node2 accepts node1's proposal: node1 and node2 have voted.
+	pp := proposal(5)
+	pp.Sequence = 1
+	pp.Sender = "node1"
+	if _, err := cs[1].HandlePrePrepare(pp); err != nil {
+		t.Fatalf("HandlePrePrepare: %v", err)
+	}
+
+	// node1 repeating its vote does not make up the third one.
+	for i := 0; i < 3; i++ {
+		prepare := proposal(5)
+		prepare.Type = pb.ConsensusMessage_PREPARE
+		prepare.Sequence = 1
+		prepare.Sender = "node1"
+		cs[1].HandlePrepare(prepare)
+	}
+	if got := cs[1].State(); got != network.Prepare {
+		t.Fatalf("expected node2 to still be in Prepare, got %v", got)
+	}
+
+	prepare := proposal(5)
+	prepare.Type = pb.ConsensusMessage_PREPARE
+	prepare.Sequence = 1
+	prepare.Sender = "node3"
+	cs[1].HandlePrepare(prepare)
+	if got := cs[1].State(); got != network.Commit {
+		t.Errorf("expected a third distinct vote to prepare the slot, got %v", got)
+	}
+}
@@ -0,0 +1,150 @@
+package network
+
+import (
+	"time"
+
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+// slot is one entry of the replicated log.
+type slot struct {
+	seq   int64
+	phase ConsensusState
+
+	// accepted is set once a PRE_PREPARE or NEW_VIEW has given the slot a
+	// proposal in the current view. The proposal itself may be nil: a
+	// no-op the view change used to fill a gap.
+	accepted bool
+	proposal *pb.Execution
+
+	// prepared is the last value this node saw reach a prepare quorum in
+	// this slot, preparedView the view it happened in (-1 if none), and
+	// proof the signed PRE_PREPARE and PREPAREs that made the quorum. They
+	// survive view changes so a new primary cannot drop the value.
+	prepared     *pb.Execution
+	preparedView int64
+	proof        []*pb.ConsensusMessage
+
+	decided *pb.ConsensusMessage
+
+	// since is when the slot entered its current phase, for the phase
+	// latency metrics.
+	since time.Time
+}
+
+// slot returns the log entry for seq, creating it if needed.
+func (c *Consensus) slot(seq int64) *slot {
+	s, ok := c.slots[seq]
+	if !ok {
+		s = &slot{seq: seq, preparedView: -1}
+		c.slots[seq] = s
+	}
+	return s
+}
+
+// accept gives the slot its proposal for the current view.
+func (s *slot) accept(req *pb.Execution, phase ConsensusState) {
+	s.accepted = true
+	s.proposal = req
+	s.phase = phase
+	s.since = time.Now()
+}
+
+// isPrepared reports whether the slot has reached a prepare quorum in some
+// view.
+func (s *slot) isPrepared() bool { return s.preparedView >= 0 }
+
+// preparedEntry returns what the slot has prepared, as a VIEW_CHANGE
+// reports it.
+func (s *slot) preparedEntry() *pb.PreparedEntry {
+	return &pb.PreparedEntry{
+		Sequence: s.seq,
+		View:     s.preparedView,
+		Request:  s.prepared,
+		Proof:    s.proof,
+	}
+}
+
+// State returns the phase this node is in: ViewChange while it is changing
+// views, otherwise the phase of the oldest slot not yet applied, or INITIAL
+// when there is none.
+func (c *Consensus) State() ConsensusState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state()
+}
+
+// state is State with c.mu held.
+func (c *Consensus) state() ConsensusState {
+	if c.changing {
+		return ViewChange
+	}
+	if s, ok := c.slots[c.lastApplied+1]; ok {
+		return s.phase
+	}
+	return INITIAL
+}
+
+// timerSlot identifies what the phase timer is waiting for.
+type timerSlot struct {
+	seq   int64
+	phase ConsensusState
+}
+
+// resetTimer keeps the phase timer running for as long as the log has a
+// slot that is not yet applied. It watches the slot right after the last
+// applied one, since nothing can be applied until that one is, even if this
+// node has not heard of it yet, and uses the timeout of the phase it is in.
+// The timer is only restarted when that slot or its phase changes, so a
+// stream of votes that makes no progress cannot keep a faulty primary in
+// place. A primary that does not close an epoch is suspected too. c.mu must
+// be held.
+func (c *Consensus) resetTimer() {
+	if c.changing {
+		return
+	}
+	if !c.membership.Contains(c.node.ID) {
+		// A node outside the cluster only follows it.
+		c.stopTimer()
+		return
+	}
+	// Once a membership change has been agreed, the primary owes the
+	// no-ops that close the epoch.
+	pending := c.config.GetNext() != nil
+	for seq := range c.slots {
+		if seq > c.lastApplied {
+			pending = true
+			break
+		}
+	}
+	if !pending {
+		c.stopTimer()
+		return
+	}
+
+	next := c.slot(c.lastApplied + 1)
+	want := timerSlot{seq: next.seq, phase: next.phase}
+	if c.timer != nil && c.timerFor == want {
+		return
+	}
+	c.timerFor = want
+	c.armTimer(c.timeouts.forPhase(next.phase, c.backoff))
+}
+
+// maxBackoff bounds how many times the phase timeouts are doubled.
+const maxBackoff = 6
+
+// forPhase returns how long a slot may stay in phase, doubled backoff
+// times.
+func (t Timeouts) forPhase(phase ConsensusState, backoff uint) time.Duration {
+	var d time.Duration
+	switch phase {
+	case PrePrepare, Prepare:
+		d = t.Prepare
+	case Commit:
+		d = t.Commit
+	default:
+		d = t.PrePrepare
+	}
+	return d << min(backoff, maxBackoff)
+}
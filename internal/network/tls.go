@@ -0,0 +1,319 @@
+package network
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// NodeTLS is what a node needs for mutual TLS: the certificate the cluster
+// CA issued it, the CA to check its peers' certificates against, and the
+// operators it takes changes from. The TLS key is the node's signing key,
+// so a certificate ties a node ID, its common name, to the public key the
+// cluster config lists for that ID.
+type NodeTLS struct {
+	cert      tls.Certificate
+	roots     *x509.CertPool
+	operators map[string]bool // common names of operator certificates
+}
+
+// LoadNodeTLS reads the CA and node id's certificate named in cfg, and
+// checks that the certificate is for id and key.
+func LoadNodeTLS(cfg *ClusterConfig, id string, key ed25519.PrivateKey) (*NodeTLS, error) {
+	if cfg.TLS == nil {
+		return nil, fmt.Errorf("the cluster config does not configure TLS")
+	}
+	self, ok := cfg.Node(id)
+	if !ok {
+		return nil, fmt.Errorf("node %q is not in the cluster config", id)
+	}
+	roots, err := LoadCertPool(cfg.path(cfg.TLS.CA))
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := loadCert(cfg.path(self.Cert))
+	if err != nil {
+		return nil, err
+	}
+	t := &NodeTLS{
+		cert:      tls.Certificate{Certificate: [][]byte{leaf.Raw}, PrivateKey: key, Leaf: leaf},
+		roots:     roots,
+		operators: make(map[string]bool),
+	}
+	for _, op := range cfg.TLS.Operators {
+		t.operators[op] = true
+	}
+	if err := t.verify(leaf, id, key.Public().(ed25519.PublicKey)); err != nil {
+		return nil, fmt.Errorf("%s: %w", self.Cert, err)
+	}
+	return t, nil
+}
+
+// LoadCertPool reads the PEM certificates at path into a pool, to trust
+// the CA of a cluster.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s: no PEM certificate found", path)
+	}
+	return pool, nil
+}
+
+// LoadClientCertificate reads a certificate and the node key it was issued
+// for, as written by GenerateTLS and "atlasvm keygen", for a client that
+// must authenticate to a node, such as an operator using NodeAdmin.
+func LoadClientCertificate(certPath, keyPath string) (tls.Certificate, error) {
+	leaf, err := loadCert(certPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	key, err := LoadPrivateKey(keyPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if pub, ok := leaf.PublicKey.(ed25519.PublicKey); !ok || !pub.Equal(key.Public()) {
+		return tls.Certificate{}, fmt.Errorf("%s is not for the key in %s", certPath, keyPath)
+	}
+	return tls.Certificate{Certificate: [][]byte{leaf.Raw}, PrivateKey: key, Leaf: leaf}, nil
+}
+
+// loadCert reads the first PEM certificate at path.
+func loadCert(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s: no PEM certificate found", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cert, nil
+}
+
+// verify checks that the cluster CA issued cert to node id for key.
+func (t *NodeTLS) verify(cert *x509.Certificate, id string, key ed25519.PublicKey) error {
+	opts := x509.VerifyOptions{Roots: t.roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}
+	if _, err := cert.Verify(opts); err != nil {
+		return err
+	}
+	if cert.Subject.CommonName != id {
+		return fmt.Errorf("certificate is for %q, not %s", cert.Subject.CommonName, id)
+	}
+	if pub, ok := cert.PublicKey.(ed25519.PublicKey); !ok || !pub.Equal(key) {
+		return fmt.Errorf("certificate of %s is not for its key in the cluster config", id)
+	}
+	return nil
+}
+
+// SetTLS makes the node serve and dial its peers over mutual TLS. Call it
+// before Serve and ConnectToPeer.
+func (n *Node) SetTLS(t *NodeTLS) {
+	n.tls = t
+}
+
+// serverOptions returns the options of the node's gRPC server. Clients of
+// ClientService need no certificate, so one is only checked if given;
+// NodeService refuses calls without one in peerID.
+func (n *Node) serverOptions() []grpc.ServerOption {
+	if n.tls == nil {
+		return nil
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{n.tls.cert},
+		ClientCAs:    n.tls.roots,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		MinVersion:   tls.VersionTLS13,
+	}))}
+}
+
+// dialCredentials returns the credentials the node connects to peer id
+// with: the connection only succeeds if the peer presents a certificate
+// for id and its key.
+func (n *Node) dialCredentials(id string) credentials.TransportCredentials {
+	if n.tls == nil {
+		return insecure.NewCredentials()
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{n.tls.cert},
+		RootCAs:      n.tls.roots,
+		ServerName:   id,
+		MinVersion:   tls.VersionTLS13,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return n.verifyPeer(cs.PeerCertificates[0], id)
+		},
+	})
+}
+
+// verifyPeer checks that cert belongs to peer id.
+func (n *Node) verifyPeer(cert *x509.Certificate, id string) error {
+	n.keysMu.RLock()
+	key, ok := n.keys[id]
+	n.keysMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown peer %q", id)
+	}
+	return n.tls.verify(cert, id, key)
+}
+
+// peerID returns the node a NodeService call comes from, as its client
+// certificate says. Without TLS there is nothing to check, and it returns
+// "".
+func (n *Node) peerID(ctx context.Context) (string, error) {
+	if n.tls == nil {
+		return "", nil
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "no peer information")
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.PeerCertificates) == 0 {
+		return "", status.Errorf(codes.Unauthenticated, "node %s only serves peers with a client certificate", n.ID)
+	}
+	cert := info.State.PeerCertificates[0]
+	id := cert.Subject.CommonName
+	if err := n.verifyPeer(cert, id); err != nil {
+		return "", status.Errorf(codes.PermissionDenied, "node %s: %v", n.ID, err)
+	}
+	return id, nil
+}
+
+// Operator is the common name of the operator certificate GenerateTLS
+// issues.
+const Operator = "operator"
+
+// GenerateTLS creates a throwaway CA, issues every node in cfg a
+// certificate for its ID and public key, and writes them to dir as ca.crt
+// and <id>.crt. It also issues a certificate to an operator, for NodeAdmin
+// changes, and writes it with a fresh key as operator.crt and
+// operator.key. It records the files and the operator in cfg, relative to
+// dir, where the config is expected to be saved. The CA key is not kept:
+// to add a node, generate everything again. It is meant for local
+// clusters and tests.
+func GenerateTLS(cfg *ClusterConfig, dir string) error {
+	if _, ok := cfg.Node(Operator); ok {
+		return fmt.Errorf("a node is called %q, the name of the operator certificate", Operator)
+	}
+	caPub, caKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	caTemplate := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{CommonName: "AtlasVM cluster CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caPub, caKey)
+	if err != nil {
+		return err
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return err
+	}
+	if err := writeCert(filepath.Join(dir, "ca.crt"), caDER); err != nil {
+		return err
+	}
+
+	for i, node := range cfg.Nodes {
+		pub, err := DecodePublicKey(node.PublicKey)
+		if err != nil {
+			return fmt.Errorf("node %q: %w", node.ID, err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: serialNumber(),
+			Subject:      pkix.Name{CommonName: node.ID},
+			DNSNames:     []string{node.ID},
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     now.AddDate(1, 0, 0),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		}
+		// Name the host too, so clients can check the node by its address.
+		if host, _, err := net.SplitHostPort(node.Address); err == nil && host != "" {
+			if ip := net.ParseIP(host); ip != nil {
+				template.IPAddresses = append(template.IPAddresses, ip)
+			} else if host != node.ID {
+				template.DNSNames = append(template.DNSNames, host)
+			}
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, pub, caKey)
+		if err != nil {
+			return fmt.Errorf("node %q: %w", node.ID, err)
+		}
+		name := node.ID + ".crt"
+		if err := writeCert(filepath.Join(dir, name), der); err != nil {
+			return err
+		}
+		cfg.Nodes[i].Cert = name
+	}
+
+	opPub, opKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serialNumber(),
+		Subject:      pkix.Name{CommonName: Operator},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, opPub, caKey)
+	if err != nil {
+		return fmt.Errorf("operator: %w", err)
+	}
+	if err := writeCert(filepath.Join(dir, Operator+".crt"), der); err != nil {
+		return err
+	}
+	if err := WritePrivateKey(filepath.Join(dir, Operator+".key"), opKey); err != nil {
+		return err
+	}
+	cfg.TLS = &TLSConfig{CA: "ca.crt", Operators: []string{Operator}}
+	cfg.dir = dir
+	return nil
+}
+
+// serialNumber returns a random certificate serial number.
+func serialNumber() *big.Int {
+	n, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func writeCert(path string, der []byte) error {
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+}
@@ -0,0 +1,214 @@
+package network_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// startTLSCluster is startCluster over mutual TLS, with certificates from
+// GenerateTLS in dir. It returns the cluster config it generated.
+func startTLSCluster(t *testing.T, n int, dir string) ([]*network.Node, []*network.Consensus, *network.ClusterConfig) {
+	t.Helper()
+	cfg := &network.ClusterConfig{}
+	listeners := make([]net.Listener, n)
+	for i := range listeners {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		listeners[i] = lis
+		id := fmt.Sprintf("node%d", i+1)
+		cfg.Nodes = append(cfg.Nodes, network.NodeConfig{
+			ID:        id,
+			Address:   lis.Addr().String(),
+			PublicKey: network.EncodePublicKey(keyFor(id).Public().(ed25519.PublicKey)),
+		})
+	}
+	if err := network.GenerateTLS(cfg, dir); err != nil {
+		t.Fatalf("GenerateTLS: %v", err)
+	}
+	members, err := cfg.Membership()
+	if err != nil {
+		t.Fatalf("Membership: %v", err)
+	}
+
+	nodes := make([]*network.Node, n)
+	cs := make([]*network.Consensus, n)
+	for i, nc := range cfg.Nodes {
+		node := network.NewNode(nc.ID, nc.Address, vm.NewVM(nil, io.Discard), nil)
+		node.SetKey(keyFor(nc.ID))
+		creds, err := network.LoadNodeTLS(cfg, nc.ID, keyFor(nc.ID))
+		if err != nil {
+			t.Fatalf("LoadNodeTLS %s: %v", nc.ID, err)
+		}
+		node.SetTLS(creds)
+		if cs[i], err = network.NewConsensus(node, members); err != nil {
+			t.Fatalf("NewConsensus: %v", err)
+		}
+		cs[i].SetTimeouts(testTimeouts)
+		node.SetReplicator(cs[i])
+		go func(lis net.Listener) { _ = node.Serve(lis) }(listeners[i])
+		t.Cleanup(node.Stop)
+		nodes[i] = node
+	}
+	for _, node := range nodes {
+		if err := node.ConnectToCluster(cfg); err != nil {
+			t.Fatalf("ConnectToCluster: %v", err)
+		}
+	}
+	return nodes, cs, cfg
+}
+
+// dialAs connects to node over TLS, trusting the CA in dir and presenting
+// the certificate of id with key, or no certificate if id is "".
+func dialAs(t *testing.T, node *network.Node, dir, id string, key ed25519.PrivateKey) pb.NodeServiceClient {
+	t.Helper()
+	roots, err := network.LoadCertPool(filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		t.Fatalf("LoadCertPool: %v", err)
+	}
+	config := &tls.Config{RootCAs: roots, ServerName: node.ID}
+	if id != "" {
+		data, err := os.ReadFile(filepath.Join(dir, id+".crt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, _ := pem.Decode(data)
+		config.Certificates = []tls.Certificate{{Certificate: [][]byte{block.Bytes}, PrivateKey: key}}
+	}
+	conn, err := grpc.NewClient(node.Address, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewNodeServiceClient(conn)
+}
+
+// signedBy returns a PREPARE signed by the node with the given ID.
+func signedBy(id string) *pb.ConsensusMessage {
+	signer := network.NewNode(id, "", vm.NewVM(nil, io.Discard), nil)
+	signer.SetKey(keyFor(id))
+	msg := &pb.ConsensusMessage{Type: pb.ConsensusMessage_PREPARE, Sequence: 1}
+	signer.Sign(msg)
+	return msg
+}
+
+func TestTLS_ClusterDecidesOverMutualTLS(t *testing.T) {
+	nodes, cs, _ := startTLSCluster(t, 4, t.TempDir())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := nodes[0].WaitForPeers(ctx, 3); err != nil {
+		t.Fatalf("WaitForPeers: %v", err)
+	}
+	if err := cs[0].StartConsensus(proposal(7)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	for i, c := range cs {
+		if got := proposedAcc(waitDecided(t, c, nodes[i].ID)); got != 7 {
+			t.Errorf("%s decided acc %d, expected 7", nodes[i].ID, got)
+		}
+	}
+}
+
+func TestTLS_PeersAreCheckedAgainstTheirCertificates(t *testing.T) {
+	dir := t.TempDir()
+	nodes, _, _ := startTLSCluster(t, 4, dir)
+	node1 := nodes[0]
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// A peer with node2's certificate may send what node2 signed...
+	asNode2 := dialAs(t, node1, dir, "node2", keyFor("node2"))
+	if _, err := asNode2.ReceiveMessage(ctx, signedBy("node2")); err != nil {
+		t.Errorf("node2's own message was refused: %v", err)
+	}
+	// ...but not what another node signed, even validly.
+	_, err := asNode2.ReceiveMessage(ctx, signedBy("node3"))
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for node3's message from node2, got %v", err)
+	}
+
+	// Without a client certificate, NodeService refuses to serve.
+	anonymous := dialAs(t, node1, dir, "", nil)
+	if _, err := anonymous.ReceiveMessage(ctx, signedBy("node2")); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without a certificate, got %v", err)
+	}
+	if _, err := anonymous.FetchState(ctx, &pb.FetchStateRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated for FetchState without a certificate, got %v", err)
+	}
+
+	// A certificate for node2's ID from another CA does not pass the
+	// handshake, and neither does plaintext.
+	other := t.TempDir()
+	cfg := &network.ClusterConfig{Nodes: []network.NodeConfig{{
+		ID: "node2", Address: "127.0.0.1:1", PublicKey: network.EncodePublicKey(keyFor("node2").Public().(ed25519.PublicKey)),
+	}}}
+	if err := network.GenerateTLS(cfg, other); err != nil {
+		t.Fatalf("GenerateTLS: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(other, "node2.crt"))
+	if err := os.WriteFile(filepath.Join(dir, "forged.crt"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	forged := dialAs(t, node1, dir, "forged", keyFor("node2"))
+	if _, err := forged.ReceiveMessage(ctx, signedBy("node2")); err == nil {
+		t.Error("a certificate from another CA was accepted")
+	}
+	conn, err := grpc.NewClient(node1.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if _, err := pb.NewNodeServiceClient(conn).ReceiveMessage(ctx, signedBy("node2")); err == nil {
+		t.Error("a plaintext connection was accepted")
+	}
+}
+
+func TestTLS_LoadChecksIDAndKey(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &network.ClusterConfig{}
+	for i, id := range []string{"node1", "node2"} {
+		cfg.Nodes = append(cfg.Nodes, network.NodeConfig{
+			ID: id, Address: fmt.Sprintf("localhost:%d", 50051+i), PublicKey: network.EncodePublicKey(keyFor(id).Public().(ed25519.PublicKey)),
+		})
+	}
+	if err := network.GenerateTLS(cfg, dir); err != nil {
+		t.Fatalf("GenerateTLS: %v", err)
+	}
+	path := filepath.Join(dir, "cluster.json")
+	if err := network.SaveClusterConfig(path, cfg); err != nil {
+		t.Fatalf("SaveClusterConfig: %v", err)
+	}
+	loaded, err := network.LoadClusterConfig(path)
+	if err != nil {
+		t.Fatalf("LoadClusterConfig: %v", err)
+	}
+	if _, err := network.LoadNodeTLS(loaded, "node1", keyFor("node1")); err != nil {
+		t.Errorf("LoadNodeTLS: %v", err)
+	}
+	if _, err := network.LoadNodeTLS(loaded, "node1", keyFor("node2")); err == nil {
+		t.Error("node1's certificate was accepted with node2's key")
+	}
+	loaded.Nodes[0].Cert = loaded.Nodes[1].Cert
+	if _, err := network.LoadNodeTLS(loaded, "node1", keyFor("node2")); err == nil {
+		t.Error("node2's certificate was accepted for node1")
+	}
+}
@@ -0,0 +1,225 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// Transport carries a node's consensus messages to its peers. The receiving
+// side hands them to Node.Deliver, or to NodeService for gRPC.
+type Transport interface {
+	// Send passes msg to the peer with the given ID. It may return before
+	// the peer has processed the message, and must not keep msg after it
+	// returns, since the caller may reuse it.
+	Send(to string, msg *pb.ConsensusMessage) error
+
+	// FetchState asks the peer with the given ID for its latest stable
+	// checkpoint and the state it certifies.
+	FetchState(ctx context.Context, from string) (*pb.StateSnapshot, error)
+
+	// ProveMemory asks the peer with the given ID to prove what its memory
+	// held, as req describes.
+	ProveMemory(ctx context.Context, from string, req *pb.ProveMemoryRequest) (*pb.MemoryProof, error)
+}
+
+// SetTransport replaces the transport Broadcast sends through. Nodes start
+// with one that calls each peer's NodeService over gRPC.
+func (n *Node) SetTransport(t Transport) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.transport = t
+}
+
+// fetchState asks peer for its latest stable checkpoint through the node's
+// transport.
+func (n *Node) fetchState(ctx context.Context, peer string) (*pb.StateSnapshot, error) {
+	n.mu.Lock()
+	t := n.transport
+	n.mu.Unlock()
+	return t.FetchState(ctx, peer)
+}
+
+// proveMemory asks peer for a memory proof through the node's transport.
+func (n *Node) proveMemory(ctx context.Context, peer string, req *pb.ProveMemoryRequest) (*pb.MemoryProof, error) {
+	n.mu.Lock()
+	t := n.transport
+	n.mu.Unlock()
+	return t.ProveMemory(ctx, peer, req)
+}
+
+// DefaultSendTimeout bounds each RPC a node makes to pass a peer a
+// message.
+const DefaultSendTimeout = 2 * time.Second
+
+// sendQueueSize is how many messages may wait for a slow peer before new
+// ones are dropped. The protocol recovers from lost messages, but not from
+// a node that blocks on one peer.
+const sendQueueSize = 1024
+
+// grpcTransport sends to the NodeService of peers connected with
+// ConnectToPeer. Every peer has its own queue, drained by its own
+// goroutine, so a broadcast goes out to all peers in parallel and a slow
+// or unreachable peer holds up nobody but itself. Each send gives up after
+// the node's send timeout.
+type grpcTransport struct {
+	node *Node
+
+	mu      sync.Mutex
+	queues  map[string]*sendQueue
+	closed  bool
+	drained sync.WaitGroup
+}
+
+// sendQueue holds the messages waiting for one peer, and the client they
+// go out on.
+type sendQueue struct {
+	client  pb.NodeServiceClient
+	msgs    chan *pb.ConsensusMessage
+	dropped chan struct{} // closed once the peer is gone
+	conn    io.Closer     // closed once the queue is drained; nil if not retired
+}
+
+func newGRPCTransport(n *Node) *grpcTransport {
+	return &grpcTransport{node: n, queues: make(map[string]*sendQueue)}
+}
+
+// Send queues msg for the peer and returns at once. A peer that was
+// connected again since its queue was made gets a new queue, on the new
+// connection.
+func (t *grpcTransport) Send(to string, msg *pb.ConsensusMessage) error {
+	peer, ok := t.node.peer(to)
+	if !ok || peer.Client == nil {
+		return fmt.Errorf("peer %s is not connected over gRPC", to)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return fmt.Errorf("transport of node %s is closed", t.node.ID)
+	}
+	q, ok := t.queues[to]
+	if ok && q.client != peer.Client {
+		t.dropLocked(to)
+		ok = false
+	}
+	if !ok {
+		q = &sendQueue{
+			client:  peer.Client,
+			msgs:    make(chan *pb.ConsensusMessage, sendQueueSize),
+			dropped: make(chan struct{}),
+		}
+		t.queues[to] = q
+		t.drained.Add(1)
+		go t.drain(to, q)
+	}
+	select {
+	case q.msgs <- proto.Clone(msg).(*pb.ConsensusMessage):
+		return nil
+	default:
+		return fmt.Errorf("send queue for peer %s is full", to)
+	}
+}
+
+// drain sends the messages queued for peer to until the queue is closed,
+// and throws away the ones left once the peer is dropped.
+func (t *grpcTransport) drain(to string, q *sendQueue) {
+	defer t.drained.Done()
+	for msg := range q.msgs {
+		select {
+		case <-q.dropped:
+			continue
+		default:
+		}
+		timeout := time.Duration(t.node.sendTimeout.Load())
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		_, err := q.client.ReceiveMessage(ctx, msg)
+		cancel()
+		if err != nil {
+			t.node.metrics.sendFailures.Inc(to)
+			t.node.logger.Warn("send failed", "peer", to, "type", msg.Type, "err", err)
+		}
+	}
+	if q.conn != nil {
+		q.conn.Close()
+	}
+}
+
+// drop stops sending to peer id and lets go of its queue, unsent messages
+// and all.
+func (t *grpcTransport) drop(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dropLocked(id)
+}
+
+func (t *grpcTransport) dropLocked(id string) {
+	q, ok := t.queues[id]
+	if !ok {
+		return
+	}
+	delete(t.queues, id)
+	close(q.dropped)
+	if !t.closed {
+		close(q.msgs)
+	}
+}
+
+// retire stops taking messages for peer id, but, unlike drop, sends the
+// ones already queued, then closes conn.
+func (t *grpcTransport) retire(id string, conn *grpc.ClientConn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	q, ok := t.queues[id]
+	switch {
+	case t.closed:
+		// close is already sending what is queued, and Stop closes conn.
+	case !ok:
+		if conn != nil {
+			conn.Close()
+		}
+	default:
+		delete(t.queues, id)
+		if conn != nil {
+			q.conn = conn
+		}
+		close(q.msgs)
+	}
+}
+
+// close stops taking messages and waits until the ones already queued
+// have been sent or timed out, so that whatever a node sent before it
+// stopped still goes out.
+func (t *grpcTransport) close() {
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+		for _, q := range t.queues {
+			close(q.msgs)
+		}
+	}
+	t.mu.Unlock()
+	t.drained.Wait()
+}
+
+func (t *grpcTransport) FetchState(ctx context.Context, from string) (*pb.StateSnapshot, error) {
+	peer, ok := t.node.peer(from)
+	if !ok || peer.Client == nil {
+		return nil, fmt.Errorf("peer %s is not connected over gRPC", from)
+	}
+	return peer.Client.FetchState(ctx, &pb.FetchStateRequest{})
+}
+
+func (t *grpcTransport) ProveMemory(ctx context.Context, from string, req *pb.ProveMemoryRequest) (*pb.MemoryProof, error) {
+	peer, ok := t.node.peer(from)
+	if !ok || peer.Client == nil {
+		return nil, fmt.Errorf("peer %s is not connected over gRPC", from)
+	}
+	return peer.Client.ProveMemory(ctx, req)
+}
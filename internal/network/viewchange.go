@@ -0,0 +1,544 @@
+package network
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"sort"
+	"time"
+
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+// Timeouts bounds how long a node waits in each consensus phase before it
+// suspects the primary and starts a view change.
+type Timeouts struct {
+	// PrePrepare is how long a replica that has seen votes for a round
+	// waits for the PRE_PREPARE itself.
+	PrePrepare time.Duration
+
+	// Prepare and Commit are how long a round may stay in that phase.
+	// They, and PrePrepare, double with every view in a row that ends
+	// without deciding a slot.
+	Prepare time.Duration
+	Commit  time.Duration
+
+	// ViewChange is how long a node waits for the NEW_VIEW of the view it
+	// asked for before asking for the next one. It doubles with every
+	// view skipped so that slow replicas eventually catch up.
+	ViewChange time.Duration
+}
+
+// DefaultTimeouts is what NewConsensus starts with.
+var DefaultTimeouts = Timeouts{
+	PrePrepare: 2 * time.Second,
+	Prepare:    2 * time.Second,
+	Commit:     2 * time.Second,
+	ViewChange: 4 * time.Second,
+}
+
+// SetTimeouts replaces the phase timeouts. It takes effect from the next
+// phase change.
+func (c *Consensus) SetTimeouts(t Timeouts) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeouts = t
+}
+
+// armTimer (re)starts the phase timer. c.mu must be held.
+func (c *Consensus) armTimer(d time.Duration) {
+	c.stopTimer()
+	gen := c.timerGen
+	c.timer = c.clock.AfterFunc(d, func() { c.onTimeout(gen) })
+}
+
+// stopTimer cancels the phase timer. c.mu must be held.
+func (c *Consensus) stopTimer() {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	c.timerGen++
+}
+
+func (c *Consensus) onTimeout(gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A timer that was replaced may still fire once; ignore it.
+	if gen != c.timerGen {
+		return
+	}
+	c.timer = nil
+
+	next := c.currentView + 1
+	if c.changing {
+		next = c.pendingView + 1
+	}
+	c.node.logger.Warn("timed out, changing view", "view", c.currentView, "next", next)
+	c.startViewChange(next)
+}
+
+// startViewChange stops taking part in the current view and asks the
+// cluster to move to view.
+func (c *Consensus) startViewChange(view int64) {
+	c.failQueue(fmt.Errorf("view change to view %d in progress", view))
+	c.changing = true
+	c.pendingView = view
+	c.armTimer(c.timeouts.ViewChange << (view - c.currentView - 1))
+	if err := c.sendViewChange(view); err != nil {
+		c.node.logger.Warn("sending view change failed", "view", view, "err", err)
+	}
+	c.checkNewView(view)
+}
+
+// sendViewChange broadcasts this node's VIEW_CHANGE for view. It carries
+// every value the node has prepared, with the proof, so the new primary
+// cannot drop one that may have been decided.
+func (c *Consensus) sendViewChange(view int64) error {
+	msg := &pb.ConsensusMessage{
+		Type:       pb.ConsensusMessage_VIEW_CHANGE,
+		View:       view,
+		Sequence:   c.lastApplied,
+		Sender:     c.node.ID,
+		Checkpoint: c.stable,
+	}
+	for _, s := range c.slots {
+		if s.isPrepared() {
+			msg.Prepared = append(msg.Prepared, s.preparedEntry())
+		}
+	}
+	sort.Slice(msg.Prepared, func(i, j int) bool { return msg.Prepared[i].Sequence < msg.Prepared[j].Sequence })
+	c.recordViewChange(msg)
+	return c.broadcast(msg)
+}
+
+func (c *Consensus) recordViewChange(msg *pb.ConsensusMessage) {
+	if c.viewChanges[msg.View] == nil {
+		c.viewChanges[msg.View] = make(map[string]*pb.ConsensusMessage)
+	}
+	c.viewChanges[msg.View][msg.Sender] = msg
+}
+
+func (c *Consensus) HandleViewChange(msg *pb.ConsensusMessage) (*pb.Empty, error) {
+	if c == nil {
+		return nil, fmt.Errorf("consensus object is nil")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if msg.View <= c.currentView {
+		return nil, fmt.Errorf("ViewChange for old view %d, current view is %d", msg.View, c.currentView)
+	}
+	if err := c.verifyViewChange(msg); err != nil {
+		return nil, err
+	}
+
+	c.node.logger.Debug("handling ViewChange", "view", msg.View, "from", msg.Sender)
+	c.recordViewChange(msg)
+	c.maybeJoinViewChange()
+	c.checkNewView(msg.View)
+	return &pb.Empty{}, nil
+}
+
+// maybeJoinViewChange moves to a later view once f+1 other nodes ask for
+// one, since at least one of them is honest. Without this a node whose own
+// timer has not fired, for instance because it has nothing pending, would
+// hold back a view change the others need it for.
+func (c *Consensus) maybeJoinViewChange() {
+	floor := c.currentView
+	if c.changing {
+		floor = c.pendingView
+	}
+	senders := make(map[string]bool)
+	target := int64(-1)
+	for view, vcs := range c.viewChanges {
+		if view <= floor {
+			continue
+		}
+		for id := range vcs {
+			if id == c.node.ID {
+				continue
+			}
+			senders[id] = true
+			if target < 0 || view < target {
+				target = view
+			}
+		}
+	}
+	if len(senders) > c.membership.F() {
+		c.node.logger.Info("joining view change", "view", target)
+		c.startViewChange(target)
+	}
+}
+
+// checkNewView opens view once this node is its primary and holds a quorum
+// of VIEW_CHANGEs for it.
+func (c *Consensus) checkNewView(view int64) {
+	if view <= c.currentView || c.primary(view) != c.node.ID {
+		return
+	}
+	vcs := c.viewChanges[view]
+	if len(vcs) < c.decisionQuorum {
+		return
+	}
+
+	proof := make([]*pb.ConsensusMessage, 0, len(vcs))
+	for _, vc := range vcs {
+		proof = append(proof, vc)
+	}
+	sort.Slice(proof, func(i, j int) bool { return proof[i].Sender < proof[j].Sender })
+
+	newView := &pb.ConsensusMessage{
+		Type:        pb.ConsensusMessage_NEW_VIEW,
+		View:        view,
+		Sender:      c.node.ID,
+		ViewChanges: proof,
+		PrePrepares: c.newViewPrePrepares(view, proof),
+	}
+	c.node.logger.Info("primary of new view", "view", view)
+	c.installView(newView)
+	if err := c.node.Broadcast(newView); err != nil {
+		c.node.logger.Warn("sending new view failed", "view", view, "err", err)
+	}
+
+	for _, pp := range newView.PrePrepares {
+		s, ok := c.slots[pp.Sequence]
+		if !ok && pp.Sequence <= c.lastApplied {
+			continue // behind this node's stable checkpoint
+		}
+		s = c.slot(pp.Sequence)
+		if s.decided != nil {
+			c.revote(s, true)
+			continue
+		}
+		// NEW_VIEW doubles as the PRE_PREPAREs of the new view.
+		s.accept(pp.Request, PrePrepare)
+		c.prepares.add(voteKey(view, pp.Sequence, pp.Request), pp)
+		if err := c.persistAccept(pp); err != nil {
+			c.node.logger.Error("persisting proposal failed", "view", view, "seq", pp.Sequence, "err", err)
+			continue
+		}
+		if err := c.checkPrepared(s); err != nil {
+			c.node.logger.Warn("sending commit failed", "view", view, "seq", pp.Sequence, "err", err)
+		}
+	}
+	c.resetTimer()
+	c.closeEpoch()
+}
+
+// newViewPrePrepares returns the PRE_PREPAREs a new primary issues for view:
+// one for every slot from the lowest one not applied everywhere in vcs up to
+// the highest one anybody has prepared. A slot keeps the value prepared in
+// it in the latest view. If nothing was prepared in a slot, no value can
+// have been decided there, so the primary re-proposes the one it had
+// accepted itself, or a no-op. Each PRE_PREPARE is signed on its own, since
+// it may end up in the proof that its value was prepared.
+func (c *Consensus) newViewPrePrepares(view int64, vcs []*pb.ConsensusMessage) []*pb.ConsensusMessage {
+	low, prepared := selectPrepared(vcs)
+	high := low
+	for seq := range prepared {
+		high = max(high, seq)
+	}
+	for seq, s := range c.slots {
+		if seq > low && s.accepted && s.decided == nil {
+			high = max(high, seq)
+		}
+	}
+
+	var pps []*pb.ConsensusMessage
+	for seq := low + 1; seq <= high; seq++ {
+		pp := &pb.ConsensusMessage{
+			Type:     pb.ConsensusMessage_PRE_PREPARE,
+			View:     view,
+			Sequence: seq,
+			Sender:   c.node.ID,
+		}
+		if e, ok := prepared[seq]; ok {
+			pp.Request = e.Request
+		} else if s, ok := c.slots[seq]; ok && s.accepted {
+			pp.Request = s.proposal
+		}
+		c.node.Sign(pp)
+		pps = append(pps, pp)
+	}
+	return pps
+}
+
+// selectPrepared returns the slot the new view starts after and, for every
+// slot above it, the value prepared there in the latest view. That is the
+// lowest last-applied slot among vcs, unless one of them carries a stable
+// checkpoint past it: slots up to a stable checkpoint are settled, and the
+// nodes that collected them no longer report what was prepared there.
+func selectPrepared(vcs []*pb.ConsensusMessage) (int64, map[int64]*pb.PreparedEntry) {
+	low := int64(-1)
+	for _, vc := range vcs {
+		if low < 0 || vc.Sequence < low {
+			low = vc.Sequence
+		}
+	}
+	low = max(low, 0)
+	for _, vc := range vcs {
+		low = max(low, vc.Checkpoint.GetSequence())
+	}
+
+	prepared := make(map[int64]*pb.PreparedEntry)
+	for _, vc := range vcs {
+		for _, e := range vc.Prepared {
+			if e.Sequence <= low {
+				continue
+			}
+			if best, ok := prepared[e.Sequence]; !ok || e.View > best.View {
+				prepared[e.Sequence] = e
+			}
+		}
+	}
+	return low, prepared
+}
+
+func (c *Consensus) HandleNewView(msg *pb.ConsensusMessage) (*pb.Empty, error) {
+	if c == nil {
+		return nil, fmt.Errorf("consensus object is nil")
+	}
+
+	c.mu.Lock()
+	behind, err := c.enterNewView(msg)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// The new view starts past slots this node never decided, so it can
+	// only get there through the stable checkpoint that covers them.
+	if behind {
+		if err := c.CatchUp(context.Background()); err != nil {
+			c.node.logger.Warn("catching up failed", "err", err)
+		}
+	}
+	return &pb.Empty{}, nil
+}
+
+// enterNewView checks msg and moves this node into its view, reporting
+// whether the view starts past this node's last applied slot. c.mu must be
+// held.
+func (c *Consensus) enterNewView(msg *pb.ConsensusMessage) (bool, error) {
+	if msg.View <= c.currentView {
+		return false, fmt.Errorf("NewView for old view %d, current view is %d", msg.View, c.currentView)
+	}
+	low, err := c.verifyNewView(msg)
+	if err != nil {
+		return false, err
+	}
+
+	c.node.logger.Info("entering new view", "view", msg.View, "primary", msg.Sender)
+	c.installView(msg)
+	for _, pp := range msg.PrePrepares {
+		s, ok := c.slots[pp.Sequence]
+		if !ok && pp.Sequence <= c.lastApplied {
+			continue // behind this node's stable checkpoint
+		}
+		s = c.slot(pp.Sequence)
+		if s.decided != nil {
+			c.revote(s, false)
+			continue
+		}
+		if !c.inWindow(pp.Sequence) {
+			continue
+		}
+		// NEW_VIEW counts as the new primary's PREPARE, as PRE_PREPARE does.
+		c.prepares.add(voteKey(msg.View, pp.Sequence, pp.Request), pp)
+		if err := c.acceptProposal(s, pp); err != nil {
+			return false, err
+		}
+	}
+	c.resetTimer()
+	return low > c.lastApplied, nil
+}
+
+// verifyNewView checks that msg comes from the primary of its view, carries
+// a quorum of signed VIEW_CHANGEs for that view from distinct nodes, and
+// re-proposes every value those messages require. It returns the slot the
+// new view starts after.
+func (c *Consensus) verifyNewView(msg *pb.ConsensusMessage) (int64, error) {
+	if primary := c.primary(msg.View); msg.Sender != primary {
+		return 0, fmt.Errorf("NewView for view %d from %s, primary is %s", msg.View, msg.Sender, primary)
+	}
+
+	var vcs []*pb.ConsensusMessage
+	senders := make(map[string]bool)
+	for _, vc := range msg.ViewChanges {
+		if vc.Type != pb.ConsensusMessage_VIEW_CHANGE || vc.View != msg.View || senders[vc.Sender] {
+			continue
+		}
+		// Each VIEW_CHANGE carries its sender's own signature, so the
+		// primary cannot make one up.
+		if err := c.node.Verify(vc); err == nil && c.verifyViewChange(vc) == nil {
+			senders[vc.Sender] = true
+			vcs = append(vcs, vc)
+		}
+	}
+	if len(senders) < c.decisionQuorum {
+		return 0, fmt.Errorf("NewView for view %d carries %d view changes, quorum is %d",
+			msg.View, len(senders), c.decisionQuorum)
+	}
+
+	proposed := make(map[int64]*pb.Execution)
+	for _, pp := range msg.PrePrepares {
+		if pp.Type != pb.ConsensusMessage_PRE_PREPARE || pp.View != msg.View {
+			return 0, fmt.Errorf("NewView for view %d re-proposes sequence %d in view %d", msg.View, pp.Sequence, pp.View)
+		}
+		if pp.Sender != msg.Sender || c.node.Verify(pp) != nil {
+			return 0, fmt.Errorf("NewView for view %d re-proposes sequence %d without %s's signature",
+				msg.View, pp.Sequence, msg.Sender)
+		}
+		proposed[pp.Sequence] = pp.Request
+	}
+	low, prepared := selectPrepared(vcs)
+	for seq, e := range prepared {
+		if req, ok := proposed[seq]; !ok || requestDigest(req) != requestDigest(e.Request) {
+			return 0, fmt.Errorf("NewView for view %d does not re-propose the value prepared in sequence %d", msg.View, seq)
+		}
+	}
+	return low, nil
+}
+
+// verifyViewChange checks what a VIEW_CHANGE claims. The stable checkpoint
+// it carries, if any, lets the new view skip every slot up to it, so it
+// must be certified by the members of the epoch it was taken in. Every
+// value it reports prepared above that checkpoint must have been prepared
+// in an earlier view, by a quorum of the members of the slot's epoch:
+// otherwise one faulty node could claim a late view for a value of its
+// own and have the new primary re-propose it over one already decided.
+// c.mu must be held.
+func (c *Consensus) verifyViewChange(msg *pb.ConsensusMessage) error {
+	known := c.knownConfigs()
+	if msg.Checkpoint != nil {
+		cfg := configIn(known, msg.Checkpoint.Sequence)
+		if cfg == nil {
+			return fmt.Errorf("ViewChange from %s: no known epoch covers sequence %d", msg.Sender, msg.Checkpoint.Sequence)
+		}
+		if err := c.verifyCheckpoint(msg.Checkpoint, cfg); err != nil {
+			return fmt.Errorf("ViewChange from %s: %w", msg.Sender, err)
+		}
+	}
+	for _, e := range msg.Prepared {
+		// selectPrepared ignores what the checkpoint covers.
+		if e.Sequence <= msg.Checkpoint.GetSequence() {
+			continue
+		}
+		if e.View < 0 || e.View >= msg.View {
+			return fmt.Errorf("ViewChange from %s for view %d reports sequence %d prepared in view %d",
+				msg.Sender, msg.View, e.Sequence, e.View)
+		}
+		cfg := configIn(known, e.Sequence)
+		if cfg == nil {
+			return fmt.Errorf("ViewChange from %s: no known epoch covers sequence %d", msg.Sender, e.Sequence)
+		}
+		if err := c.verifyPrepared(e, cfg); err != nil {
+			return fmt.Errorf("ViewChange from %s: %w", msg.Sender, err)
+		}
+	}
+	return nil
+}
+
+// verifyPrepared checks that e carries PRE_PREPAREs or PREPAREs for its
+// view, sequence and request signed by a quorum of the members of cfg, the
+// configuration in force at that sequence. A member that cfg lists a key
+// for must have signed with it.
+func (c *Consensus) verifyPrepared(e *pb.PreparedEntry, cfg *pb.Configuration) error {
+	members, err := membershipOf(cfg)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]ed25519.PublicKey)
+	for _, m := range cfg.GetMembers() {
+		keys[m.Id] = m.PublicKey
+	}
+	digest := requestDigest(e.Request)
+	senders := make(map[string]bool)
+	for _, msg := range e.Proof {
+		key, member := keys[msg.Sender]
+		vote := msg.Type == pb.ConsensusMessage_PRE_PREPARE || msg.Type == pb.ConsensusMessage_PREPARE
+		if !vote || msg.View != e.View || msg.Sequence != e.Sequence ||
+			requestDigest(msg.Request) != digest || senders[msg.Sender] || !member {
+			continue
+		}
+		if err := c.node.verifyWith(msg, key); err == nil {
+			senders[msg.Sender] = true
+		}
+	}
+	if len(senders) < members.Quorum() {
+		return fmt.Errorf("sequence %d is shown prepared in view %d by %d members of epoch %d, quorum is %d",
+			e.Sequence, e.View, len(senders), cfg.Epoch, members.Quorum())
+	}
+	return nil
+}
+
+// installView makes msg's view the current one. Slots that were not decided
+// lose the proposal of the previous view; those past the last slot the new
+// view re-proposes are dropped, since nothing in them can have been decided.
+// Votes already received for the new view are kept. If the view it ends
+// decided nothing, the phase timeouts back off.
+func (c *Consensus) installView(msg *pb.ConsensusMessage) {
+	if err := c.persist(pb.WALRecord_VIEW, msg); err != nil {
+		c.node.logger.Error("persisting view failed", "view", msg.View, "err", err)
+	}
+	last := c.lastApplied
+	for _, pp := range msg.PrePrepares {
+		last = max(last, pp.Sequence)
+	}
+
+	if !c.replaying {
+		c.node.metrics.viewChanges.Inc()
+	}
+	c.currentView = msg.View
+	c.changing = false
+	if c.progress {
+		c.backoff = 0
+	} else {
+		c.backoff = min(c.backoff+1, maxBackoff)
+	}
+	c.progress = false
+	c.nextSeq = last + 1
+	for seq, s := range c.slots {
+		if s.decided != nil {
+			continue
+		}
+		if seq > last {
+			delete(c.slots, seq)
+			continue
+		}
+		s.accepted = false
+		s.proposal = nil
+		s.phase = INITIAL
+	}
+	c.stopTimer()
+	for v := range c.viewChanges {
+		if v <= msg.View {
+			delete(c.viewChanges, v)
+		}
+	}
+}
+
+// revote repeats a node's votes for a slot it has already decided, so that
+// nodes which did not decide it before the view change can do so now. A
+// primary's PREPARE is implied by its NEW_VIEW.
+func (c *Consensus) revote(s *slot, primary bool) {
+	types := []pb.ConsensusMessage_Type{pb.ConsensusMessage_PREPARE, pb.ConsensusMessage_COMMIT}
+	if primary {
+		types = types[1:]
+	}
+	for _, t := range types {
+		msg := &pb.ConsensusMessage{
+			Type:     t,
+			View:     c.currentView,
+			Sequence: s.seq,
+			Request:  s.decided.Request,
+			Sender:   c.node.ID,
+		}
+		if err := c.broadcast(msg); err != nil {
+			c.node.logger.Warn("repeating votes failed", "seq", s.seq, "err", err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package network_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+)
+
+// ---------------------------------------------------------------------------
+// Primary rotation
+// ---------------------------------------------------------------------------
+
+func TestPrimaryRotatesWithView(t *testing.T) {
+	_, cs := startCluster(t, 4)
+
+	want := []string{"node1", "node2", "node3", "node4", "node1", "node2"}
+	for view, id := range want {
+		if got := cs[2].Primary(int64(view)); got != id {
+			t.Errorf("view %d: expected primary %s, got %s", view, id, got)
+		}
+	}
+}
+
+func TestStartConsensusRejectsBackup(t *testing.T) {
+	_, cs := startCluster(t, 4)
+
+	err := cs[1].StartConsensus(proposal(1))
+	if err == nil || !strings.Contains(err.Error(), "not the primary") {
+		t.Errorf("expected not-the-primary error, got %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// View change
+// ---------------------------------------------------------------------------
+
+func TestViewChange_PrimaryCrashesMidPrePrepare(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+
+	// node1 gets its PRE_PREPARE out to node2 only and then crashes. node2
+	// cannot prepare without the others, and node3 and node4 only hear
+	// about the round through node2's PREPARE.
+	delete(nodes[0].Peers, "node3")
+	delete(nodes[0].Peers, "node4")
+	if err := cs[0].StartConsensus(proposal(42)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	nodes[0].Stop()
+
+	for i := 1; i < 4; i++ {
+		v := waitDecided(t, cs[i], nodes[i].ID)
+		if proposedAcc(v) != 42 {
+			t.Errorf("%s: expected decided acc 42, got %d", nodes[i].ID, proposedAcc(v))
+		}
+		if v.View < 1 {
+			t.Errorf("%s: expected a decision after a view change, got view %d", nodes[i].ID, v.View)
+		}
+	}
+	if got := cs[1].View(); got != 1 {
+		t.Errorf("expected the survivors to settle in view 1, got %d", got)
+	}
+}
+
+func TestViewChange_PrimaryCrashesAfterPrePrepare(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+
+	if err := cs[0].StartConsensus(proposal(7)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	nodes[0].Stop()
+
+	for i := 1; i < 4; i++ {
+		if v := waitDecided(t, cs[i], nodes[i].ID); proposedAcc(v) != 7 {
+			t.Errorf("%s: expected decided acc 7, got %d", nodes[i].ID, proposedAcc(v))
+		}
+	}
+}
+
+func TestViewChange_BackupCrashDoesNotStall(t *testing.T) {
+	nodes, cs := startCluster(t, 4)
+
+	nodes[3].Stop()
+	if err := cs[0].StartConsensus(proposal(9)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		v := waitDecided(t, cs[i], nodes[i].ID)
+		if proposedAcc(v) != 9 || v.View != 0 {
+			t.Errorf("%s: expected acc 9 in view 0, got acc %d in view %d", nodes[i].ID, proposedAcc(v), v.View)
+		}
+	}
+}
+
+func TestViewChange_TimeoutsBackOffOnASlowNetwork(t *testing.T) {
+	net, _, cs := startMemoryCluster(t, 4, 1)
+	// Every message takes longer than a phase may, so no view decides
+	// anything until the timeouts have doubled enough.
+	net.SetFaults(network.Faults{Delay: 500 * time.Millisecond})
+	if err := cs[0].StartConsensus(proposal(3)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	if !net.RunUntil(allApplied(1, cs...), 20000) {
+		t.Fatalf("the cluster did not decide on a slow network; views %d %d %d %d",
+			cs[0].View(), cs[1].View(), cs[2].View(), cs[3].View())
+	}
+	for i, c := range cs {
+		if v := c.View(); v > 4 {
+			t.Errorf("node%d went through %d views", i+1, v)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// SyncPolicy says when a WAL forces what it has written to disk.
+type SyncPolicy int
+
+const (
+	// SyncAlways syncs every record before Append returns, so nothing a
+	// node has sent can be lost in a crash. It is the default.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval syncs in the background every WALOptions.Interval. A
+	// crash loses at most that much, and with it the guarantee that the
+	// node never contradicts a vote it sent.
+	SyncInterval
+	// SyncNever leaves syncing to the operating system, which protects
+	// against the process crashing but not the machine.
+	SyncNever
+)
+
+// ParseSyncPolicy returns the policy named always, interval or never.
+func ParseSyncPolicy(name string) (SyncPolicy, error) {
+	switch name {
+	case "always":
+		return SyncAlways, nil
+	case "interval":
+		return SyncInterval, nil
+	case "never":
+		return SyncNever, nil
+	}
+	return 0, fmt.Errorf("unknown fsync policy %q (want always, interval or never)", name)
+}
+
+// WALOptions configures a WAL.
+type WALOptions struct {
+	Sync     SyncPolicy
+	Interval time.Duration // for SyncInterval; DefaultSyncInterval if zero
+	Logger   *slog.Logger  // for errors syncing in the background; nil discards them
+}
+
+// DefaultSyncInterval is how often SyncInterval syncs unless told otherwise.
+const DefaultSyncInterval = 100 * time.Millisecond
+
+// walHeader is the length and CRC-32C of the record that follows it.
+const walHeader = 8
+
+var walCRC = crc32.MakeTable(crc32.Castagnoli)
+
+// WAL is a node's write-ahead log: an append-only file of WALRecords, each
+// prefixed with its length and checksum. Consensus writes a record before
+// acting on it, so that Recover can put a restarted node back where it was.
+type WAL struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	opts    WALOptions
+	dirty   bool
+	records []*pb.WALRecord
+	done    chan struct{}
+	closed  bool
+}
+
+// OpenWAL opens the log at path, creating it if it does not exist, and
+// reads the records already in it. A record cut short by a crash while it
+// was being written is dropped, along with anything after it.
+func OpenWAL(path string, opts WALOptions) (*WAL, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	records, end, err := readWAL(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := f.Truncate(end); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(end, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	w := &WAL{path: path, file: f, opts: opts, records: records, done: make(chan struct{})}
+	if opts.Sync == SyncInterval {
+		if w.opts.Interval <= 0 {
+			w.opts.Interval = DefaultSyncInterval
+		}
+		go w.syncLoop()
+	}
+	return w, nil
+}
+
+// readWAL returns the records in f and the offset just past the last
+// intact one.
+func readWAL(f *os.File) ([]*pb.WALRecord, int64, error) {
+	r := bufio.NewReader(f)
+	var records []*pb.WALRecord
+	var end int64
+	header := make([]byte, walHeader)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return records, end, nil
+			}
+			return nil, 0, err
+		}
+		size := binary.BigEndian.Uint32(header)
+		sum := binary.BigEndian.Uint32(header[4:])
+		body := make([]byte, size)
+		if _, err := io.ReadFull(r, body); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return records, end, nil
+			}
+			return nil, 0, err
+		}
+		rec := &pb.WALRecord{}
+		if crc32.Checksum(body, walCRC) != sum || proto.Unmarshal(body, rec) != nil {
+			return records, end, nil
+		}
+		records = append(records, rec)
+		end += walHeader + int64(size)
+	}
+}
+
+// Records returns the records the log held when it was opened.
+func (w *WAL) Records() []*pb.WALRecord {
+	return w.records
+}
+
+// encodeWAL returns rec as it is stored in the log.
+func encodeWAL(rec *pb.WALRecord) ([]byte, error) {
+	body, err := proto.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, walHeader+len(body))
+	binary.BigEndian.PutUint32(buf, uint32(len(body)))
+	binary.BigEndian.PutUint32(buf[4:], crc32.Checksum(body, walCRC))
+	copy(buf[walHeader:], body)
+	return buf, nil
+}
+
+// Append writes rec to the end of the log, syncing it first if the policy
+// is SyncAlways.
+func (w *WAL) Append(rec *pb.WALRecord) error {
+	buf, err := encodeWAL(rec)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.file.Write(buf); err != nil {
+		return fmt.Errorf("writing %s: %w", w.file.Name(), err)
+	}
+	w.dirty = true
+	if w.opts.Sync == SyncAlways {
+		return w.sync()
+	}
+	return nil
+}
+
+// Sync forces everything appended so far to disk.
+func (w *WAL) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sync()
+}
+
+// sync is Sync with w.mu held.
+func (w *WAL) sync() error {
+	if !w.dirty {
+		return nil
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("syncing %s: %w", w.file.Name(), err)
+	}
+	w.dirty = false
+	return nil
+}
+
+// Compact rewrites the log as head followed by the records in it that keep
+// accepts, in their original order. The new log is written and synced
+// beside the old one and then renamed over it, and the rename is synced
+// before Compact returns, so a crash leaves one or the other intact and
+// a log Compact returned from is the new one.
+func (w *WAL) Compact(head *pb.WALRecord, keep func(*pb.WALRecord) bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	old, err := os.Open(w.path)
+	if err != nil {
+		return err
+	}
+	records, _, err := readWAL(old)
+	old.Close()
+	if err != nil {
+		return err
+	}
+
+	tmp := w.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(f)
+	for _, rec := range append([]*pb.WALRecord{head}, records...) {
+		if rec != head && !keep(rec) {
+			continue
+		}
+		buf, err := encodeWAL(rec)
+		if err == nil {
+			_, err = out.Write(buf)
+		}
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := out.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := os.Rename(tmp, w.path); err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file.Close()
+	w.file = f
+	w.dirty = false
+	if err := syncDir(filepath.Dir(w.path)); err != nil {
+		return err
+	}
+	_, err = f.Seek(0, io.SeekEnd)
+	return err
+}
+
+// syncDir makes the entries of the directory at path, such as a file
+// renamed into it, durable.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+func (w *WAL) syncLoop() {
+	tick := time.NewTicker(w.opts.Interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-tick.C:
+			if err := w.Sync(); err != nil && w.opts.Logger != nil {
+				w.opts.Logger.Error("WAL sync failed", "err", err)
+			}
+		}
+	}
+}
+
+// Close syncs the log and closes its file. Closing it again does nothing.
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	close(w.done)
+	err := w.sync()
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+package network_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func openWAL(t *testing.T, path string) *network.WAL {
+	t.Helper()
+	w, err := network.OpenWAL(path, network.WALOptions{Sync: network.SyncAlways})
+	if err != nil {
+		t.Fatalf("OpenWAL: %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+// restart replaces old on net with a fresh node of the same ID and key,
+// recovered from the WAL at path, as if old had crashed and come back.
+func restart(t *testing.T, net *network.MemoryNetwork, old *network.Node, path string) (*network.Node, *network.Consensus) {
+	t.Helper()
+	old.Stop()
+	node := network.NewNode(old.ID, "", vm.NewVM(nil, io.Discard), nil)
+	node.SetKey(keyFor(old.ID))
+	c, err := network.NewConsensus(node, old.Replicator().Membership())
+	if err != nil {
+		t.Fatalf("NewConsensus: %v", err)
+	}
+	c.SetTimeouts(testTimeouts)
+	node.SetReplicator(c)
+	net.Join(node)
+	if err := c.Recover(openWAL(t, path)); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	return node, c
+}
+
+func TestWAL_TornTailIsDropped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.wal")
+	w := openWAL(t, path)
+	for seq := int64(1); seq <= 3; seq++ {
+		msg := proposal(int8(seq))
+		msg.Sequence = seq
+		if err := w.Append(&pb.WALRecord{Type: pb.WALRecord_ACCEPT, Message: msg}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	w.Close()
+
+	// A crash in the middle of a write leaves part of a record behind.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 0, 0, 40, 1, 2, 3})
+	f.Close()
+
+	w = openWAL(t, path)
+	if got := len(w.Records()); got != 3 {
+		t.Fatalf("expected 3 intact records, got %d", got)
+	}
+	if err := w.Append(&pb.WALRecord{Type: pb.WALRecord_DECIDE, Message: proposal(4)}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	w.Close()
+
+	w = openWAL(t, path)
+	records := w.Records()
+	if len(records) != 4 || records[3].Type != pb.WALRecord_DECIDE {
+		t.Fatalf("expected the new record after the 3 old ones, got %d records", len(records))
+	}
+	if got := records[1].Message.Sequence; got != 2 {
+		t.Errorf("expected the second record for sequence 2, got %d", got)
+	}
+}
+
+func TestWAL_RestartedNodeRecoversAndCatchesUp(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	path := filepath.Join(t.TempDir(), "node2.wal")
+	cs[1].SetCheckpointInterval(2)
+	if err := cs[1].Recover(openWAL(t, path)); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+
+	for i := int8(1); i <= 3; i++ {
+		if err := cs[0].StartConsensus(proposal(i)); err != nil {
+			t.Fatalf("StartConsensus %d: %v", i, err)
+		}
+	}
+	if !net.RunUntil(allApplied(3, cs...), 10000) {
+		t.Fatal("the cluster did not apply 3 slots")
+	}
+
+	nodes[1], cs[1] = restart(t, net, nodes[1], path)
+	if got := cs[1].LastApplied(); got != 3 {
+		t.Fatalf("expected the restarted node to have applied 3 slots, got %d", got)
+	}
+	want := cs[0].NodeState().State
+	if got := cs[1].NodeState().State; !bytes.Equal(got.Memory, want.Memory) || got.Acc != want.Acc {
+		t.Errorf("restarted VM has acc %d, expected %d", got.Acc, want.Acc)
+	}
+
+	if err := cs[0].StartConsensus(proposal(4)); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	if !net.RunUntil(allApplied(4, cs...), 10000) {
+		t.Fatal("the restarted node did not take part in slot 4")
+	}
+	sameDecisions(t, 4, nodes, cs)
+	if got := proposedAcc(cs[1].Decided(4)); got != 4 {
+		t.Errorf("expected acc 4 in slot 4, got %d", got)
+	}
+}
+
+func TestWAL_RestartedNodeDoesNotVoteTwice(t *testing.T) {
+	net, nodes, cs := startMemoryCluster(t, 4, 1)
+	net.SetFaults(network.Faults{Delay: time.Millisecond})
+	path := filepath.Join(t.TempDir(), "node2.wal")
+	if err := cs[1].Recover(openWAL(t, path)); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+
+	first := proposal(1)
+	if err := cs[0].StartConsensus(first); err != nil {
+		t.Fatalf("StartConsensus: %v", err)
+	}
+	net.Step() // node2 accepts the PRE_PREPARE and sends its PREPARE
+	nodes[1], cs[1] = restart(t, net, nodes[1], path)
+
+	// A Byzantine primary now offers the restarted node something else for
+	// the same slot, hoping it forgot what it voted for.
+	other := proto.Clone(first).(*pb.ConsensusMessage)
+	other.Request = proposal(2).Request
+	nodes[0].Sign(other)
+	if err := nodes[1].Deliver(other); err == nil {
+		t.Fatal("the restarted node accepted a second PRE_PREPARE for the same slot")
+	}
+
+	if !net.RunUntil(allApplied(1, cs...), 10000) {
+		t.Fatal("the cluster did not decide")
+	}
+	if got := proposedAcc(cs[1].Decided(1)); got != 1 {
+		t.Errorf("expected acc 1, got %d", got)
+	}
+}
@@ -0,0 +1,117 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+)
+
+// Fixed delays every message by the same amount.
+type Fixed time.Duration
+
+func (d Fixed) Sample(*rand.Rand) time.Duration { return time.Duration(d) }
+
+func (d Fixed) String() string { return "fixed:" + time.Duration(d).String() }
+
+// Uniform draws delays evenly from [Min, Max).
+type Uniform struct {
+	Min, Max time.Duration
+}
+
+func (d Uniform) Sample(rng *rand.Rand) time.Duration {
+	if d.Max <= d.Min {
+		return d.Min
+	}
+	return d.Min + time.Duration(rng.Int63n(int64(d.Max-d.Min)))
+}
+
+func (d Uniform) String() string { return fmt.Sprintf("uniform:%v,%v", d.Min, d.Max) }
+
+// Normal draws delays from a normal distribution. Negative draws count as
+// no delay.
+type Normal struct {
+	Mean, StdDev time.Duration
+}
+
+func (d Normal) Sample(rng *rand.Rand) time.Duration {
+	return d.Mean + time.Duration(rng.NormFloat64()*float64(d.StdDev))
+}
+
+func (d Normal) String() string { return fmt.Sprintf("normal:%v,%v", d.Mean, d.StdDev) }
+
+// LogNormal draws delays whose logarithm is normally distributed, around
+// Median with shape Sigma: a long tail of slow messages, as on real
+// networks.
+type LogNormal struct {
+	Median time.Duration
+	Sigma  float64
+}
+
+func (d LogNormal) Sample(rng *rand.Rand) time.Duration {
+	return time.Duration(float64(d.Median) * math.Exp(rng.NormFloat64()*d.Sigma))
+}
+
+func (d LogNormal) String() string { return fmt.Sprintf("lognormal:%v,%g", d.Median, d.Sigma) }
+
+// Exponential draws delays from an exponential distribution with the given
+// mean.
+type Exponential time.Duration
+
+func (d Exponential) Sample(rng *rand.Rand) time.Duration {
+	return time.Duration(rng.ExpFloat64() * float64(d))
+}
+
+func (d Exponential) String() string { return "exp:" + time.Duration(d).String() }
+
+// ParseDistribution parses a latency distribution written as its name and
+// parameters, the way the distributions print themselves:
+//
+//	fixed:10ms
+//	uniform:5ms,20ms
+//	normal:20ms,5ms
+//	lognormal:20ms,0.5
+//	exp:10ms
+func ParseDistribution(s string) (network.Distribution, error) {
+	name, params, _ := strings.Cut(s, ":")
+	args := strings.Split(params, ",")
+	want := map[string]int{"fixed": 1, "uniform": 2, "normal": 2, "lognormal": 2, "exp": 1}[name]
+	if want == 0 {
+		return nil, fmt.Errorf("unknown latency distribution %q: expected fixed, uniform, normal, lognormal or exp", name)
+	}
+	if len(args) != want {
+		return nil, fmt.Errorf("latency distribution %q takes %d parameters, got %q", name, want, params)
+	}
+	durations := make([]time.Duration, len(args))
+	for i, arg := range args {
+		if name == "lognormal" && i == 1 {
+			break
+		}
+		d, err := time.ParseDuration(arg)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("latency distribution %q: invalid duration %q", name, arg)
+		}
+		durations[i] = d
+	}
+
+	switch name {
+	case "fixed":
+		return Fixed(durations[0]), nil
+	case "uniform":
+		return Uniform{Min: durations[0], Max: durations[1]}, nil
+	case "normal":
+		return Normal{Mean: durations[0], StdDev: durations[1]}, nil
+	case "lognormal":
+		sigma, err := strconv.ParseFloat(args[1], 64)
+		if err != nil || sigma < 0 {
+			return nil, fmt.Errorf("latency distribution %q: invalid sigma %q", name, args[1])
+		}
+		return LogNormal{Median: durations[0], Sigma: sigma}, nil
+	default:
+		return Exponential(durations[0]), nil
+	}
+}
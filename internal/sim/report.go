@@ -0,0 +1,115 @@
+package sim
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+)
+
+// Report is what a run measured. A request counts as decided once a quorum
+// of replicas has applied it, and its latency runs from when its client
+// first sent it.
+type Report struct {
+	Nodes    int
+	F        int
+	Seed     int64
+	Sent     int           // requests the clients sent
+	Decided  int           // requests decided before the run ended
+	Elapsed  time.Duration // virtual time the run took
+	Latency  Percentiles
+	Messages network.NetworkStats
+	ByType   map[string]int // messages sent, by type
+	View     int64          // highest view any replica reached
+}
+
+// Percentiles summarizes decision latencies.
+type Percentiles struct {
+	P50, P90, P99, Max, Mean time.Duration
+}
+
+// percentiles returns the percentiles of ds, using the nearest rank. It
+// sorts ds.
+func percentiles(ds []time.Duration) Percentiles {
+	if len(ds) == 0 {
+		return Percentiles{}
+	}
+	slices.Sort(ds)
+	rank := func(p int) time.Duration {
+		return ds[max((len(ds)*p+99)/100, 1)-1]
+	}
+	var sum time.Duration
+	for _, d := range ds {
+		sum += d
+	}
+	return Percentiles{
+		P50:  rank(50),
+		P90:  rank(90),
+		P99:  rank(99),
+		Max:  ds[len(ds)-1],
+		Mean: sum / time.Duration(len(ds)),
+	}
+}
+
+// report sums up the run.
+func (r *run) report() *Report {
+	rep := &Report{
+		Nodes:    len(r.cs),
+		F:        r.cs[0].Membership().F(),
+		Seed:     r.cfg.Seed,
+		Sent:     len(r.requests),
+		Decided:  len(r.latency),
+		Elapsed:  r.net.Now(),
+		Latency:  percentiles(r.latency),
+		Messages: r.net.Stats(),
+		ByType:   make(map[string]int),
+	}
+	for t, n := range r.net.SentByType() {
+		rep.ByType[t.String()] = n
+	}
+	for _, c := range r.cs {
+		rep.View = max(rep.View, c.View())
+	}
+	return rep
+}
+
+// Write prints rep for people to read.
+func (rep *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "cluster\t%d nodes (f=%d), seed %d\n", rep.Nodes, rep.F, rep.Seed)
+	fmt.Fprintf(tw, "requests\t%d sent, %d decided, in %v of virtual time\n", rep.Sent, rep.Decided, rep.Elapsed)
+	if rep.Decided > 0 {
+		l := rep.Latency
+		fmt.Fprintf(tw, "latency\tp50 %v  p90 %v  p99 %v  max %v  mean %v\n",
+			round(l.P50), round(l.P90), round(l.P99), round(l.Max), round(l.Mean))
+	}
+	m := rep.Messages
+	fmt.Fprintf(tw, "messages\t%d sent (%d bytes), %d delivered, %d dropped, %d duplicated\n",
+		m.Sent, m.Bytes, m.Delivered, m.Dropped, m.Duplicated)
+	types := make([]string, 0, len(rep.ByType))
+	for t := range rep.ByType {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	for i, t := range types {
+		types[i] = fmt.Sprintf("%s %d", strings.ToLower(t), rep.ByType[t])
+	}
+	fmt.Fprintf(tw, "by type\t%s\n", strings.Join(types, ", "))
+	fmt.Fprintf(tw, "view\t%d\n", rep.View)
+	return tw.Flush()
+}
+
+// round rounds d to a precision that suits its size.
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d
+}
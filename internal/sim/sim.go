@@ -0,0 +1,328 @@
+// Package sim runs PBFT clusters of any size in one process, on the virtual
+// clock of a network.MemoryNetwork, to measure how latency, loss, bandwidth
+// and partitions affect how long requests take to be decided.
+//
+// A run starts n replicas, sends them requests at random times for a while
+// and reports decision latency percentiles and message counts. Nothing in
+// it depends on the wall clock or the scheduler: every random choice comes
+// from the seed, so the same Config gives the same Report.
+package sim
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
+)
+
+// Config describes a simulated run.
+type Config struct {
+	// Nodes is how many replicas the cluster has, named node1 to nodeN.
+	Nodes int
+
+	// Seed drives the network and the workload.
+	Seed int64
+
+	// Clients send Rate requests per second on average, at random times,
+	// for Duration. Each request carries Payload bytes of input.
+	Rate     float64
+	Duration time.Duration
+	Payload  int
+
+	// Drain is how long the run goes on after the last request is sent,
+	// for the ones still in flight to be decided.
+	Drain time.Duration
+
+	// Network is what the network does to every message: its latency,
+	// loss and bandwidth.
+	Network network.Faults
+
+	// Partitions split the network for a while.
+	Partitions []Partition
+
+	// Timeouts and Batching configure every replica. A client that has not
+	// seen its request decided after Retry sends it again to whoever it
+	// then takes for the primary.
+	Timeouts network.Timeouts
+	Batching network.Batching
+	Retry    time.Duration
+
+	// MaxSteps bounds how many messages and timers the run handles.
+	MaxSteps int
+}
+
+// DefaultConfig is a cluster of four on a fast network.
+var DefaultConfig = Config{
+	Nodes:    4,
+	Seed:     1,
+	Rate:     200,
+	Duration: 5 * time.Second,
+	Drain:    10 * time.Second,
+	Network:  network.Faults{Latency: Fixed(time.Millisecond)},
+	Timeouts: network.DefaultTimeouts,
+	Batching: network.DefaultBatching,
+	Retry:    5 * time.Second,
+	MaxSteps: 50000000,
+}
+
+// Partition splits the network into Groups from From until To: nodes in
+// different groups cannot reach each other, and nodes left out of every
+// group form one more group. If partitions overlap, the one that started
+// last holds until the earliest end.
+type Partition struct {
+	From, To time.Duration
+	Groups   [][]string
+}
+
+func (p Partition) String() string {
+	groups := make([]string, len(p.Groups))
+	for i, g := range p.Groups {
+		groups[i] = strings.Join(g, ",")
+	}
+	return fmt.Sprintf("%v-%v:%s", p.From, p.To, strings.Join(groups, "/"))
+}
+
+// ParsePartition parses a partition written as its time span and groups,
+// the groups separated by slashes: "2s-5s:node1,node2/node3" cuts node1
+// and node2, node3, and the rest of the nodes off from each other between
+// 2s and 5s.
+func ParsePartition(s string) (Partition, error) {
+	span, groups, ok := strings.Cut(s, ":")
+	from, to, ok2 := strings.Cut(span, "-")
+	if !ok || !ok2 || groups == "" {
+		return Partition{}, fmt.Errorf("invalid partition %q: expected from-to:node1,node2/node3", s)
+	}
+	var p Partition
+	var err error
+	if p.From, err = time.ParseDuration(from); err != nil {
+		return Partition{}, fmt.Errorf("invalid partition %q: %v", s, err)
+	}
+	if p.To, err = time.ParseDuration(to); err != nil {
+		return Partition{}, fmt.Errorf("invalid partition %q: %v", s, err)
+	}
+	if p.To <= p.From {
+		return Partition{}, fmt.Errorf("invalid partition %q: it ends before it starts", s)
+	}
+	for _, g := range strings.Split(groups, "/") {
+		p.Groups = append(p.Groups, strings.Split(g, ","))
+	}
+	return p, nil
+}
+
+// request is a client request the run tracks until it is decided.
+type request struct {
+	exec    *pb.Execution
+	sent    time.Duration
+	decided bool
+}
+
+// slot is a slot of the log the run tracks until a quorum applies it. A
+// replica may drop a slot as soon as it applies it, when that makes a
+// checkpoint stable, so the request in a slot is learned from whichever
+// replica still had it.
+type slot struct {
+	id      string // ID of the request in the slot, once known
+	known   bool
+	applied int // replicas that applied the slot
+	done    bool
+}
+
+// run is the state of one simulation.
+type run struct {
+	cfg      Config
+	net      *network.MemoryNetwork
+	cs       []*network.Consensus
+	byID     map[string]*network.Consensus
+	quorum   int
+	rng      *rand.Rand
+	requests map[string]*request
+	slots    map[int64]*slot
+	seen     []int64 // last slot looked at on each replica
+	latency  []time.Duration
+	pending  int  // requests sent and not decided yet
+	sentAll  bool // whether the clients are done sending
+	over     bool // whether the time is up
+}
+
+// Run simulates cfg and reports how it went.
+func Run(cfg Config) (*Report, error) {
+	if cfg.Nodes < 1 {
+		return nil, fmt.Errorf("a cluster needs at least one node, got %d", cfg.Nodes)
+	}
+	if cfg.Rate <= 0 || cfg.Duration <= 0 {
+		return nil, fmt.Errorf("the clients need a rate and a duration, got %g/s for %v", cfg.Rate, cfg.Duration)
+	}
+	ids := make([]string, cfg.Nodes)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("node%d", i+1)
+	}
+	for _, p := range cfg.Partitions {
+		for _, g := range p.Groups {
+			for _, id := range g {
+				if !slices.Contains(ids, id) {
+					return nil, fmt.Errorf("partition %v names %q, which is not in the cluster", p, id)
+				}
+			}
+		}
+	}
+	members, err := network.NewMembership(ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &run{
+		cfg:      cfg,
+		net:      network.NewMemoryNetwork(cfg.Seed),
+		cs:       make([]*network.Consensus, cfg.Nodes),
+		quorum:   members.Quorum(),
+		rng:      rand.New(rand.NewSource(cfg.Seed)),
+		requests: make(map[string]*request),
+		slots:    make(map[int64]*slot),
+		seen:     make([]int64, cfg.Nodes),
+		byID:     make(map[string]*network.Consensus),
+	}
+	nodes := make([]*network.Node, cfg.Nodes)
+	for i, id := range ids {
+		nodes[i] = network.NewNode(id, "", vm.NewVM(nil, io.Discard), nil)
+		nodes[i].SetKey(keyFor(cfg.Seed, id))
+		if r.cs[i], err = network.NewConsensus(nodes[i], members); err != nil {
+			return nil, err
+		}
+		r.cs[i].SetTimeouts(cfg.Timeouts)
+		r.cs[i].SetBatching(cfg.Batching)
+		nodes[i].SetReplicator(r.cs[i])
+		r.byID[id] = r.cs[i]
+	}
+	r.net.Join(nodes...)
+	r.net.SetFaults(cfg.Network)
+
+	for _, p := range cfg.Partitions {
+		r.net.At(p.From, func() { r.net.Partition(p.Groups...) })
+		r.net.At(p.To, r.net.Heal)
+	}
+	end := cfg.Duration + cfg.Drain
+	r.net.At(end, func() { r.over = true })
+	r.arrive(r.nextArrival(0), 0)
+
+	done := r.net.RunUntil(r.finished, cfg.MaxSteps)
+	if !done {
+		return nil, fmt.Errorf("the run did not finish in %d steps, at %v of virtual time", cfg.MaxSteps, r.net.Now())
+	}
+	return r.report(), nil
+}
+
+// keyFor derives the key of node id from the seed, so that runs are the
+// same down to the signatures.
+func keyFor(seed int64, id string) ed25519.PrivateKey {
+	h := sha256.New()
+	binary.Write(h, binary.BigEndian, seed)
+	h.Write([]byte(id))
+	return ed25519.NewKeyFromSeed(h.Sum(nil))
+}
+
+// nextArrival returns when the request after one sent at t arrives: the
+// clients send requests as a Poisson process.
+func (r *run) nextArrival(t time.Duration) time.Duration {
+	return t + time.Duration(r.rng.ExpFloat64()/r.cfg.Rate*float64(time.Second))
+}
+
+// arrive schedules request i, due at t, and the ones after it.
+func (r *run) arrive(t time.Duration, i int) {
+	if t >= r.cfg.Duration {
+		r.sentAll = true
+		return
+	}
+	r.net.At(t, func() {
+		input := make([]byte, r.cfg.Payload)
+		r.rng.Read(input)
+		exec := network.NewExecution(program, nil, input)
+		exec.Id = fmt.Sprintf("req-%d", i)
+		req := &request{exec: exec, sent: t}
+		r.requests[exec.Id] = req
+		r.pending++
+		r.submit(req)
+		r.arrive(r.nextArrival(t), i+1)
+	})
+}
+
+// program is what every request runs: it halts at once.
+var program = []byte{byte(vm.HALT) << 4}
+
+// submit sends req to the primary of the highest view any replica is in,
+// and sends it again after the retry interval until it is decided.
+func (r *run) submit(req *request) {
+	if req.decided || r.over {
+		return
+	}
+	latest := r.cs[0]
+	for _, c := range r.cs[1:] {
+		if c.View() > latest.View() {
+			latest = c
+		}
+	}
+	// A context that has already ended makes Submit queue the request and
+	// return at once: the run learns that it is decided from the replicas.
+	// A replica that is not the primary after all refuses it, and the
+	// client tries again later.
+	r.byID[latest.Leader()].Submit(ended, req.exec)
+	if r.cfg.Retry > 0 {
+		r.net.At(r.net.Now()+r.cfg.Retry, func() { r.submit(req) })
+	}
+}
+
+// ended is a context that is already done.
+var ended = func() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}()
+
+// finished records the slots the replicas applied since it was last
+// called and reports whether the run is over: every request has been sent
+// and decided, or the time is up.
+func (r *run) finished() bool {
+	for i, c := range r.cs {
+		last := c.LastApplied()
+		for seq := r.seen[i] + 1; seq <= last; seq++ {
+			s := r.slots[seq]
+			if s == nil {
+				s = &slot{}
+				r.slots[seq] = s
+			}
+			s.applied++
+			if d := c.Decided(seq); d != nil {
+				s.id, s.known = d.Request.GetId(), true
+			}
+			r.decide(seq)
+		}
+		r.seen[i] = max(r.seen[i], last)
+	}
+	return r.over || (r.sentAll && r.pending == 0)
+}
+
+// decide records the request in slot seq as decided once a quorum has
+// applied the slot and the request in it is known.
+func (r *run) decide(seq int64) {
+	s := r.slots[seq]
+	if s.done || !s.known || s.applied < r.quorum {
+		return
+	}
+	s.done = true
+	req := r.requests[s.id]
+	if req == nil || req.decided {
+		return
+	}
+	req.decided = true
+	r.pending--
+	r.latency = append(r.latency, r.net.Now()-req.sent)
+}
@@ -0,0 +1,149 @@
+package sim_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/network"
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/sim"
+)
+
+// small returns a short run on a cluster of four with the given network.
+func small(faults network.Faults) sim.Config {
+	cfg := sim.DefaultConfig
+	cfg.Rate = 100
+	cfg.Duration = 500 * time.Millisecond
+	cfg.Network = faults
+	cfg.Timeouts = network.Timeouts{
+		PrePrepare: 200 * time.Millisecond,
+		Prepare:    200 * time.Millisecond,
+		Commit:     200 * time.Millisecond,
+		ViewChange: 400 * time.Millisecond,
+	}
+	cfg.Retry = 500 * time.Millisecond
+	return cfg
+}
+
+func run(t *testing.T, cfg sim.Config) *sim.Report {
+	t.Helper()
+	rep, err := sim.Run(cfg)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if rep.Sent == 0 || rep.Decided != rep.Sent {
+		t.Fatalf("%d of %d requests decided", rep.Decided, rep.Sent)
+	}
+	return rep
+}
+
+func TestRun_SameSeedSameReport(t *testing.T) {
+	cfg := small(network.Faults{Latency: sim.LogNormal{Median: 5 * time.Millisecond, Sigma: 0.5}})
+	first, second := run(t, cfg), run(t, cfg)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("runs with the same seed differ:\n%+v\n%+v", first, second)
+	}
+	cfg.Seed++
+	if other := run(t, cfg); reflect.DeepEqual(first.Latency, other.Latency) {
+		t.Errorf("runs with different seeds have the same latencies: %+v", other.Latency)
+	}
+}
+
+func TestRun_LatencyFollowsTheNetwork(t *testing.T) {
+	cfg := small(network.Faults{Latency: sim.Fixed(10 * time.Millisecond)})
+	cfg.Batching.Delay = 0
+	rep := run(t, cfg)
+	// A request goes through PRE_PREPARE, PREPARE and COMMIT before a
+	// replica applies it.
+	if rep.Latency.P50 < 30*time.Millisecond || rep.Latency.Max > 50*time.Millisecond {
+		t.Errorf("expected latencies of 30ms to 50ms on a 10ms network, got %+v", rep.Latency)
+	}
+	if rep.Messages.Sent == 0 || rep.ByType["PRE_PREPARE"] == 0 || rep.ByType["COMMIT"] == 0 {
+		t.Errorf("expected the report to count messages, got %+v by type %v", rep.Messages, rep.ByType)
+	}
+}
+
+func TestRun_BandwidthDelaysDecisions(t *testing.T) {
+	cfg := small(network.Faults{Latency: sim.Fixed(time.Millisecond)})
+	cfg.Payload = 1000
+	fast := run(t, cfg)
+	cfg.Network.Bandwidth = 200000
+	slow := run(t, cfg)
+	if slow.Latency.P50 <= fast.Latency.P50 {
+		t.Errorf("expected a narrow network to slow decisions down, got p50 %v vs %v", slow.Latency.P50, fast.Latency.P50)
+	}
+	if slow.Messages.Bytes < int64(cfg.Payload*slow.Sent) {
+		t.Errorf("%d bytes sent for %d requests of %d bytes", slow.Messages.Bytes, slow.Sent, cfg.Payload)
+	}
+}
+
+func TestRun_ClusterWithoutQuorumResumesAfterHealing(t *testing.T) {
+	cfg := small(network.Faults{Latency: sim.Fixed(time.Millisecond)})
+	cfg.Duration = 2 * time.Second
+	cfg.Partitions = []sim.Partition{{
+		From:   500 * time.Millisecond,
+		To:     time.Second,
+		Groups: [][]string{{"node1", "node2"}, {"node3", "node4"}},
+	}}
+	rep := run(t, cfg)
+	if rep.View == 0 {
+		t.Error("the stalled rounds did not lead to a view change")
+	}
+	if rep.Messages.Dropped == 0 {
+		t.Error("expected the partition to drop messages")
+	}
+	if rep.Latency.Max < 500*time.Millisecond || rep.Latency.P50 > 10*time.Millisecond {
+		t.Errorf("expected the requests sent during the partition to wait for it, and only those: %+v", rep.Latency)
+	}
+}
+
+func TestRun_RejectsPartitionsOfUnknownNodes(t *testing.T) {
+	cfg := small(network.Faults{})
+	cfg.Partitions = []sim.Partition{{From: 0, To: time.Second, Groups: [][]string{{"node9"}}}}
+	if _, err := sim.Run(cfg); err == nil || !strings.Contains(err.Error(), "node9") {
+		t.Errorf("expected an error naming node9, got %v", err)
+	}
+}
+
+func TestParseDistribution(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want network.Distribution
+	}{
+		{"fixed:10ms", sim.Fixed(10 * time.Millisecond)},
+		{"uniform:5ms,20ms", sim.Uniform{Min: 5 * time.Millisecond, Max: 20 * time.Millisecond}},
+		{"normal:20ms,5ms", sim.Normal{Mean: 20 * time.Millisecond, StdDev: 5 * time.Millisecond}},
+		{"lognormal:20ms,0.5", sim.LogNormal{Median: 20 * time.Millisecond, Sigma: 0.5}},
+		{"exp:10ms", sim.Exponential(10 * time.Millisecond)},
+	} {
+		got, err := sim.ParseDistribution(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseDistribution(%q) = %v, %v, expected %v", tc.in, got, err, tc.want)
+		}
+		if err == nil && got.(interface{ String() string }).String() != tc.in {
+			t.Errorf("%q prints as %q", tc.in, got)
+		}
+	}
+	for _, in := range []string{"", "fixed", "gamma:1ms", "uniform:5ms", "fixed:-1ms", "lognormal:20ms,x"} {
+		if d, err := sim.ParseDistribution(in); err == nil {
+			t.Errorf("ParseDistribution(%q) = %v, expected an error", in, d)
+		}
+	}
+}
+
+func TestParsePartition(t *testing.T) {
+	p, err := sim.ParsePartition("2s-5s:node1,node2/node3")
+	if err != nil {
+		t.Fatalf("ParsePartition: %v", err)
+	}
+	want := sim.Partition{From: 2 * time.Second, To: 5 * time.Second, Groups: [][]string{{"node1", "node2"}, {"node3"}}}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("got %+v, expected %+v", p, want)
+	}
+	for _, in := range []string{"node1", "2s:node1", "5s-2s:node1", "2s-5s:", "x-5s:node1"} {
+		if p, err := sim.ParsePartition(in); err == nil {
+			t.Errorf("ParsePartition(%q) = %v, expected an error", in, p)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package vm
+
+import "fmt"
+
+// FaultKind classifies the reason a program stopped abnormally.
+type FaultKind int
+
+const (
+	FaultIllegalOpcode FaultKind = iota
+	FaultDivideByZero
+	FaultStepLimit
+	FaultIndexOutOfRange
+	FaultMemoryOutOfRange
+)
+
+var faultNames = [...]string{
+	FaultIllegalOpcode:    "illegal opcode",
+	FaultDivideByZero:     "divide by zero",
+	FaultStepLimit:        "step limit exceeded",
+	FaultIndexOutOfRange:  "index out of range",
+	FaultMemoryOutOfRange: "memory access out of range",
+}
+
+func (k FaultKind) String() string {
+	if int(k) >= len(faultNames) {
+		return "unknown fault"
+	}
+	return faultNames[k]
+}
+
+// Fault is returned by Run when execution cannot continue.
+// PC is the code-segment offset of the faulting instruction.
+type Fault struct {
+	Kind FaultKind
+	PC   uint8
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("vm fault at pc=%d: %s", f.PC, f.Kind)
+}
@@ -0,0 +1,49 @@
+package vm_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+)
+
+// fuzzStepBudget bounds each fuzzed execution; random bytecode loops often.
+const fuzzStepBudget = 4096
+
+// FuzzRun executes arbitrary bytecode and initial data. The VM may fault,
+// but it must never panic and must always stop within the step budget.
+func FuzzRun(f *testing.F) {
+	f.Add([]byte{encode(opLOAD, 0x00), encode(opOUT, 0), encode(opHALT, 0)}, []byte{77})
+	f.Add([]byte{encode(opJUMP, 0x00)}, []byte{})
+	f.Add([]byte{0x78, 0x80, 0x70, 0x4A, 0x19, 0xB8, 0x7A, 0x99, 0x79, 0xAE, 0x79, 0xD0, 0xE0},
+		[]byte{0, 0, 0, 0, 0, 0, 0, 0, 10, 0, 1})
+
+	f.Fuzz(func(t *testing.T, bytecode, data []byte) {
+		if len(bytecode) > vm.CodeSegmentSize {
+			bytecode = bytecode[:vm.CodeSegmentSize]
+		}
+		v := vm.NewVM(bytes.NewReader(data), io.Discard)
+		if err := v.LoadProgram(bytecode); err != nil {
+			t.Fatalf("LoadProgram: %v", err)
+		}
+		seed := make(map[uint8]byte, len(data))
+		for i, b := range data {
+			if i > 0xFF {
+				break
+			}
+			seed[uint8(i)] = b
+		}
+		v.LoadData(seed)
+
+		err := v.RunSteps(fuzzStepBudget)
+		var fault *vm.Fault
+		if err != nil && !errors.As(err, &fault) {
+			t.Fatalf("RunSteps returned a non-fault error: %v", err)
+		}
+		if v.Running() {
+			t.Fatal("VM still running after RunSteps returned")
+		}
+	})
+}
@@ -1,5 +1,7 @@
 package vm
 
+import "fmt"
+
 type Opcode byte
 
 const (
@@ -18,8 +20,41 @@ const (
 	IN    Opcode = 0x0C
 	OUT   Opcode = 0x0D
 	HALT  Opcode = 0x0E
+	EXT   Opcode = 0x0F // prefix of a two-byte extended instruction
+)
+
+// Extended opcodes. An extended instruction is an EXT byte whose low nibble
+// selects the operation, followed by a full operand byte. Low nibbles
+// 0x0–0xB are the long forms of ADD through JNZ: they behave exactly like
+// the one-byte forms but can reach any data-segment address or code offset
+// up to 255. The remaining nibbles select the operations below.
+//
+// Memory operands are W bytes wide, little-endian, and the accumulator
+// wraps to W bytes after every operation. W starts at 1, which gives the
+// original 8-bit machine.
+const (
+	IDX Opcode = 0x1C // fault unless 0 <= ACC < operand, then IX = ACC
+	LDX Opcode = 0x1D // ACC = mem[operand + IX*W]
+	STX Opcode = 0x1E // mem[operand + IX*W] = ACC
+	WID Opcode = 0x1F // W = operand (1, 2 or 4)
 )
 
+var opcodeNames = map[Opcode]string{
+	ADD: "ADD", SUB: "SUB", MUL: "MUL", DIV: "DIV",
+	AND: "AND", OR: "OR", XOR: "XOR",
+	LOAD: "LOAD", STORE: "STORE",
+	JUMP: "JUMP", JZ: "JZ", JNZ: "JNZ",
+	IN: "IN", OUT: "OUT", HALT: "HALT", EXT: "EXT",
+	IDX: "IDX", LDX: "LDX", STX: "STX", WID: "WID",
+}
+
+func (op Opcode) String() string {
+	if name, ok := opcodeNames[op]; ok {
+		return name
+	}
+	return fmt.Sprintf("Opcode(%#x)", byte(op))
+}
+
 type Instruction struct {
 	Opcode  Opcode
 	Operand byte
@@ -31,3 +66,13 @@ func DecodeInstruction(value byte) Instruction {
 		Operand: value & 0x0F,
 	}
 }
+
+// DecodeExtended decodes a two-byte extended instruction from its EXT byte
+// and the operand byte that follows it.
+func DecodeExtended(ext, operand byte) Instruction {
+	op := Opcode(ext & 0x0F)
+	if op > JNZ {
+		op |= 0x10
+	}
+	return Instruction{Opcode: op, Operand: operand}
+}
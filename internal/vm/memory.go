@@ -6,12 +6,20 @@ const (
 	CodeSegmentSize = 512
 )
 
+// Memory is the VM's address space. It keeps a MerkleTree over its pages
+// up to date lazily: writes mark their page dirty, and Root rehashes only
+// the dirty pages. Code that writes Data directly must call Touch for the
+// addresses it changed.
 type Memory struct {
-	Data [MemorySize]byte
+	Data  [MemorySize]byte
+	tree  MerkleTree
+	dirty [NumPages]bool
 }
 
 func NewMemory() *Memory {
-	return &Memory{}
+	m := &Memory{}
+	m.Touch(0, MemorySize)
+	return m
 }
 
 func (m *Memory) Read(address uint16) byte {
@@ -23,8 +31,53 @@ func (m *Memory) Read(address uint16) byte {
 
 func (m *Memory) Write(address uint16, value byte) {
 	if address < MemorySize {
-		m.Data[address] = value
+		if m.Data[address] != value {
+			m.Data[address] = value
+			m.dirty[PageOf(address)] = true
+		}
 	} else {
 		panic("Memory write out of bounds")
 	}
 }
+
+// Load copies b into memory from address on. Only the pages whose
+// contents change are marked dirty.
+func (m *Memory) Load(address uint16, b []byte) {
+	for i, v := range b[:min(len(b), MemorySize-int(address))] {
+		m.Write(address+uint16(i), v)
+	}
+}
+
+// Touch marks the n bytes from address on as changed.
+func (m *Memory) Touch(address uint16, n int) {
+	if n <= 0 {
+		return
+	}
+	last := min(int(address)+n, MemorySize) - 1
+	for p := PageOf(address); p <= PageOf(uint16(last)); p++ {
+		m.dirty[p] = true
+	}
+}
+
+// Root returns the Merkle root of memory, rehashing the pages written
+// since the last call.
+func (m *Memory) Root() []byte {
+	for p, dirty := range m.dirty {
+		if dirty {
+			m.tree.Update(p, m.Data[p*PageSize:(p+1)*PageSize])
+			m.dirty[p] = false
+		}
+	}
+	return m.tree.Root()
+}
+
+// Dirty returns how many pages Root would rehash.
+func (m *Memory) Dirty() int {
+	n := 0
+	for _, dirty := range m.dirty {
+		if dirty {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,191 @@
+package vm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
+
+const (
+	// PageSize is how many bytes of memory each leaf of a MerkleTree covers.
+	PageSize = 64
+	// NumPages is how many pages memory is split into. It is a power of
+	// two, so the tree is complete.
+	NumPages = MemorySize / PageSize
+)
+
+// Hashes of leaves and inner nodes are kept apart by a prefix, so that an
+// inner node can never pass for a page.
+const (
+	leafPrefix  = 0
+	innerPrefix = 1
+)
+
+// MerkleTree commits to the contents of memory page by page. Its root
+// changes if any byte does, an inclusion proof for one page needs only the
+// hashes along its path, and two trees can be compared top-down to find
+// the pages where they differ.
+type MerkleTree struct {
+	// nodes holds the tree in heap order: the root is at 1, the children
+	// of i at 2i and 2i+1, and page p at NumPages+p.
+	nodes [2 * NumPages][sha256.Size]byte
+}
+
+// NewMerkleTree hashes every page of memory, which must be MemorySize bytes
+// long or shorter; missing bytes count as zero.
+func NewMerkleTree(memory []byte) *MerkleTree {
+	t := &MerkleTree{}
+	for p := 0; p < NumPages; p++ {
+		t.nodes[NumPages+p] = hashPage(p, page(memory, p))
+	}
+	for i := NumPages - 1; i >= 1; i-- {
+		t.nodes[i] = hashInner(t.nodes[2*i], t.nodes[2*i+1])
+	}
+	return t
+}
+
+// page returns the bytes of memory in page p, padded with zeros.
+func page(memory []byte, p int) []byte {
+	start := p * PageSize
+	if start+PageSize <= len(memory) {
+		return memory[start : start+PageSize]
+	}
+	b := make([]byte, PageSize)
+	if start < len(memory) {
+		copy(b, memory[start:])
+	}
+	return b
+}
+
+func hashPage(p int, data []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix, byte(p)})
+	h.Write(data)
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func hashInner(left, right [sha256.Size]byte) [sha256.Size]byte {
+	var b [1 + 2*sha256.Size]byte
+	b[0] = innerPrefix
+	copy(b[1:], left[:])
+	copy(b[1+sha256.Size:], right[:])
+	return sha256.Sum256(b[:])
+}
+
+// Root returns the hash that commits to the whole memory.
+func (t *MerkleTree) Root() []byte {
+	return bytes.Clone(t.nodes[1][:])
+}
+
+// Update rehashes page p, which now holds data, and the nodes above it.
+func (t *MerkleTree) Update(p int, data []byte) {
+	i := NumPages + p
+	t.nodes[i] = hashPage(p, data)
+	for i /= 2; i >= 1; i /= 2 {
+		t.nodes[i] = hashInner(t.nodes[2*i], t.nodes[2*i+1])
+	}
+}
+
+// Pages returns the hash of every page, in order.
+func (t *MerkleTree) Pages() [][]byte {
+	pages := make([][]byte, NumPages)
+	for p := range pages {
+		pages[p] = bytes.Clone(t.nodes[NumPages+p][:])
+	}
+	return pages
+}
+
+// Diff returns the pages whose contents differ between t and other. It
+// only descends into subtrees whose hashes differ, so trees that agree
+// are compared in one step.
+func (t *MerkleTree) Diff(other *MerkleTree) []int {
+	var pages []int
+	var walk func(i int)
+	walk = func(i int) {
+		if t.nodes[i] == other.nodes[i] {
+			return
+		}
+		if i >= NumPages {
+			pages = append(pages, i-NumPages)
+			return
+		}
+		walk(2 * i)
+		walk(2*i + 1)
+	}
+	walk(1)
+	return pages
+}
+
+// TreeFromPages rebuilds a tree from the page hashes another replica
+// reported, so it can be compared with Diff.
+func TreeFromPages(pages [][]byte) (*MerkleTree, error) {
+	if len(pages) != NumPages {
+		return nil, fmt.Errorf("expected %d page hashes, got %d", NumPages, len(pages))
+	}
+	t := &MerkleTree{}
+	for p, h := range pages {
+		if len(h) != sha256.Size {
+			return nil, fmt.Errorf("page %d hash is %d bytes long", p, len(h))
+		}
+		copy(t.nodes[NumPages+p][:], h)
+	}
+	for i := NumPages - 1; i >= 1; i-- {
+		t.nodes[i] = hashInner(t.nodes[2*i], t.nodes[2*i+1])
+	}
+	return t, nil
+}
+
+// Proof shows that a page held Data in the memory a tree's root commits
+// to.
+type Proof struct {
+	Page int
+	Data []byte
+	// Siblings are the hashes next to the path from the page up to the
+	// root, starting at the page.
+	Siblings [][]byte
+}
+
+// Prove returns an inclusion proof for page p, which holds data.
+func (t *MerkleTree) Prove(p int, data []byte) Proof {
+	proof := Proof{Page: p, Data: bytes.Clone(data)}
+	for i := NumPages + p; i > 1; i /= 2 {
+		proof.Siblings = append(proof.Siblings, bytes.Clone(t.nodes[i^1][:]))
+	}
+	return proof
+}
+
+// Verify checks that proof leads from its page to root.
+func (proof Proof) Verify(root []byte) error {
+	if proof.Page < 0 || proof.Page >= NumPages {
+		return fmt.Errorf("page %d is out of range", proof.Page)
+	}
+	if len(proof.Data) != PageSize {
+		return fmt.Errorf("page %d holds %d bytes, expected %d", proof.Page, len(proof.Data), PageSize)
+	}
+	h := hashPage(proof.Page, proof.Data)
+	i := NumPages + proof.Page
+	for _, s := range proof.Siblings {
+		if i <= 1 || len(s) != sha256.Size {
+			return fmt.Errorf("malformed proof for page %d", proof.Page)
+		}
+		var sibling [sha256.Size]byte
+		copy(sibling[:], s)
+		if i%2 == 0 {
+			h = hashInner(h, sibling)
+		} else {
+			h = hashInner(sibling, h)
+		}
+		i /= 2
+	}
+	if i != 1 || !bytes.Equal(h[:], root) {
+		return fmt.Errorf("proof for page %d does not lead to the root", proof.Page)
+	}
+	return nil
+}
+
+// PageOf returns the page that holds address.
+func PageOf(address uint16) int {
+	return int(address) / PageSize
+}
@@ -0,0 +1,80 @@
+package vm_test
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
+)
+
+func TestMerkle_ProofsVerify(t *testing.T) {
+	mem := make([]byte, vm.MemorySize)
+	for i := range mem {
+		mem[i] = byte(i * 7)
+	}
+	tree := vm.NewMerkleTree(mem)
+	root := tree.Root()
+	for p := 0; p < vm.NumPages; p++ {
+		data := mem[p*vm.PageSize : (p+1)*vm.PageSize]
+		if err := tree.Prove(p, data).Verify(root); err != nil {
+			t.Errorf("page %d: %v", p, err)
+		}
+	}
+
+	proof := tree.Prove(3, mem[3*vm.PageSize:4*vm.PageSize])
+	proof.Data[5]++
+	if err := proof.Verify(root); err == nil {
+		t.Error("a proof for tampered data verified")
+	}
+	proof = tree.Prove(3, mem[3*vm.PageSize:4*vm.PageSize])
+	proof.Page = 4
+	if err := proof.Verify(root); err == nil {
+		t.Error("a proof verified for the wrong page")
+	}
+}
+
+func TestMerkle_DiffFindsChangedPages(t *testing.T) {
+	a := make([]byte, vm.MemorySize)
+	b := bytes.Clone(a)
+	b[70] = 1  // page 1
+	b[700] = 2 // page 10
+	got := vm.NewMerkleTree(a).Diff(vm.NewMerkleTree(b))
+	if !slices.Equal(got, []int{1, 10}) {
+		t.Errorf("expected pages [1 10], got %v", got)
+	}
+	if d := vm.NewMerkleTree(a).Diff(vm.NewMerkleTree(a)); len(d) != 0 {
+		t.Errorf("expected identical trees to have no diff, got %v", d)
+	}
+
+	rebuilt, err := vm.TreeFromPages(vm.NewMerkleTree(b).Pages())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rebuilt.Root(), vm.NewMerkleTree(b).Root()) {
+		t.Error("a tree rebuilt from page hashes has a different root")
+	}
+}
+
+func TestMerkle_RootRehashesOnlyTouchedPages(t *testing.T) {
+	v := vm.NewVM(nil, io.Discard)
+	// STORE 0x00, HALT: only page 0 of the data segment changes.
+	program := []byte{encode(opLOAD, 0x01), encode(opSTORE, 0x00), encode(opHALT, 0)}
+	loadAndRun(t, v, program, map[uint8]byte{0x01: 9})
+	v.Memory.Root()
+
+	if err := v.LoadProgram(program); err != nil {
+		t.Fatal(err)
+	}
+	v.Memory.Write(0x01, 10)
+	if err := v.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if n := v.Memory.Dirty(); n != 1 {
+		t.Errorf("expected re-running the same program to dirty 1 page, got %d", n)
+	}
+	if got, want := v.Memory.Root(), vm.NewMerkleTree(v.Memory.Data[:]).Root(); !bytes.Equal(got, want) {
+		t.Error("the incremental root differs from a full rehash")
+	}
+}
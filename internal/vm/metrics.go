@@ -0,0 +1,51 @@
+package vm
+
+import (
+	"strings"
+
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/metrics"
+)
+
+// numOpcodes bounds the opcodes, extended ones included.
+const numOpcodes = 0x20
+
+// vmMetrics is what a VM counts: the instructions it executes, by opcode,
+// and the faults that stop it, by kind, with underscores for spaces.
+type vmMetrics struct {
+	instructions [numOpcodes]*metrics.Series
+	faults       *metrics.Counter
+}
+
+// SetMetrics makes the VM count into reg. Instructions are counted locally
+// while a program runs and added to reg when it stops.
+func (vm *VM) SetMetrics(reg *metrics.Registry) {
+	if reg == nil {
+		vm.metrics = nil
+		return
+	}
+	m := &vmMetrics{
+		faults: reg.Counter("atlasvm_vm_faults_total", "Programs stopped by a fault, by kind.", "fault"),
+	}
+	instructions := reg.Counter("atlasvm_vm_instructions_total", "Instructions executed, by opcode.", "opcode")
+	for op := range m.instructions {
+		if _, ok := opcodeNames[Opcode(op)]; ok {
+			m.instructions[op] = instructions.With(Opcode(op).String())
+		}
+	}
+	vm.metrics = m
+}
+
+// record adds the counts of a run to the VM's metrics.
+func (m *vmMetrics) record(executed *[numOpcodes]uint64, fault *Fault) {
+	if m == nil {
+		return
+	}
+	for op, n := range executed {
+		if n > 0 {
+			m.instructions[op].Add(n)
+		}
+	}
+	if fault != nil {
+		m.faults.Inc(strings.ReplaceAll(fault.Kind.String(), " ", "_"))
+	}
+}
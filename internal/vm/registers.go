@@ -2,12 +2,15 @@ package vm
 
 type Registers struct {
 	PC  uint8 // Program Counter
-	ACC int8  // Accumulator
+	ACC int32 // Accumulator, wrapped to W bytes after every operation
+	IX  uint8 // Index register, set by IDX and used by LDX/STX
+	W   uint8 // Operand width in bytes (1, 2 or 4), set by WID
 }
 
 func NewRegisters() *Registers {
 	return &Registers{
 		PC:  0,
 		ACC: 0,
+		W:   1,
 	}
 }
@@ -2,7 +2,7 @@ package vm
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	pb "github.com/HMZElidrissi/atlas-virtual-machine/proto"
 	"io"
 )
@@ -15,6 +15,8 @@ type VM struct {
 	running   bool
 	input     io.Reader
 	output    io.Writer
+	metrics   *vmMetrics
+	logger    *slog.Logger
 }
 
 func NewVM(input io.Reader, output io.Writer) *VM {
@@ -28,38 +30,84 @@ func NewVM(input io.Reader, output io.Writer) *VM {
 	}
 }
 
-func (vm *VM) Run() {
-	log.Println("Running VM...")
+// Run executes the loaded program until HALT or a fault.
+// A program that never halts keeps Run busy forever; use RunSteps to bound it.
+func (vm *VM) Run() error {
+	return vm.RunSteps(0)
+}
+
+// RunSteps is like Run but stops with a FaultStepLimit fault once maxSteps
+// instructions have executed. A maxSteps of 0 means no limit.
+func (vm *VM) RunSteps(maxSteps int) error {
+	var executed [numOpcodes]uint64
+	fault := vm.run(maxSteps, &executed)
+	vm.metrics.record(&executed, fault)
+	if fault != nil {
+		if vm.logger != nil {
+			vm.logger.Debug("program faulted", "fault", fault.Kind.String(), "pc", fault.PC)
+		}
+		return fault
+	}
+	if vm.logger != nil {
+		vm.logger.Debug("program halted", "pc", vm.Registers.PC, "acc", vm.Registers.ACC)
+	}
+	return nil
+}
+
+// SetLogger makes the VM log how each program it runs stops to logger, at debug
+// level. A VM logs nothing until it is given one.
+func (vm *VM) SetLogger(logger *slog.Logger) {
+	vm.logger = logger
+}
+
+// run executes up to maxSteps instructions, counting them by opcode into
+// executed, and returns the fault that stopped it, if any.
+func (vm *VM) run(maxSteps int, executed *[numOpcodes]uint64) *Fault {
 	vm.running = true
-	for vm.running {
-		// PC is a relative offset within the code segment.
-		// Add DataSegmentSize to get the absolute memory address.
-		instruction := DecodeInstruction(vm.Memory.Read(DataSegmentSize + uint16(vm.Registers.PC)))
-		vm.Registers.PC++
-		vm.executeInstruction(instruction)
+	for steps := 0; vm.running; steps++ {
+		if maxSteps > 0 && steps >= maxSteps {
+			vm.running = false
+			return &Fault{Kind: FaultStepLimit, PC: vm.Registers.PC}
+		}
+		pc := vm.Registers.PC
+		first := vm.fetch()
+		instruction := DecodeInstruction(first)
+		if instruction.Opcode == EXT {
+			instruction = DecodeExtended(first, vm.fetch())
+		}
+		executed[instruction.Opcode&(numOpcodes-1)]++
+		if kind, ok := vm.executeInstruction(instruction); !ok {
+			vm.running = false
+			return &Fault{Kind: kind, PC: pc}
+		}
 	}
+	return nil
+}
+
+// fetch reads the code byte at PC and advances PC past it.
+// PC is a relative offset within the code segment; adding DataSegmentSize
+// gives the absolute memory address.
+func (vm *VM) fetch() byte {
+	b := vm.Memory.Read(DataSegmentSize + uint16(vm.Registers.PC))
+	vm.Registers.PC++
+	return b
 }
 
-func (vm *VM) executeInstruction(instruction Instruction) {
+// executeInstruction applies one instruction to the machine state.
+// It reports false together with the fault kind when the instruction
+// cannot be executed.
+func (vm *VM) executeInstruction(instruction Instruction) (FaultKind, bool) {
 	switch instruction.Opcode {
-	case ADD:
-		vm.Registers.ACC += int8(vm.Memory.Read(uint16(instruction.Operand)))
-	case SUB:
-		vm.Registers.ACC -= int8(vm.Memory.Read(uint16(instruction.Operand)))
-	case MUL:
-		vm.Registers.ACC *= int8(vm.Memory.Read(uint16(instruction.Operand)))
-	case DIV:
-		vm.Registers.ACC /= int8(vm.Memory.Read(uint16(instruction.Operand)))
-	case AND:
-		vm.Registers.ACC &= int8(vm.Memory.Read(uint16(instruction.Operand)))
-	case OR:
-		vm.Registers.ACC |= int8(vm.Memory.Read(uint16(instruction.Operand)))
-	case XOR:
-		vm.Registers.ACC ^= int8(vm.Memory.Read(uint16(instruction.Operand)))
-	case LOAD:
-		vm.Registers.ACC = int8(vm.Memory.Read(uint16(instruction.Operand)))
+	case ADD, SUB, MUL, DIV, AND, OR, XOR, LOAD:
+		value, ok := vm.readData(uint16(instruction.Operand))
+		if !ok {
+			return FaultMemoryOutOfRange, false
+		}
+		return vm.executeALU(instruction.Opcode, value)
 	case STORE:
-		vm.Memory.Write(uint16(instruction.Operand), byte(vm.Registers.ACC))
+		if !vm.writeData(uint16(instruction.Operand)) {
+			return FaultMemoryOutOfRange, false
+		}
 	case JUMP:
 		vm.Registers.PC = instruction.Operand
 	case JZ:
@@ -71,23 +119,124 @@ func (vm *VM) executeInstruction(instruction Instruction) {
 			vm.Registers.PC = instruction.Operand
 		}
 	case IN:
-		var input byte
+		var input int32
 		fmt.Fscan(vm.input, &input)
-		vm.Registers.ACC = int8(input)
+		vm.Registers.ACC = vm.wrap(input)
 	case OUT:
 		fmt.Fprintf(vm.output, "%d\n", vm.Registers.ACC)
 	case HALT:
 		vm.running = false
+	case IDX:
+		if vm.Registers.ACC < 0 || vm.Registers.ACC >= int32(instruction.Operand) {
+			return FaultIndexOutOfRange, false
+		}
+		vm.Registers.IX = uint8(vm.Registers.ACC)
+	case LDX:
+		value, ok := vm.readData(vm.indexed(instruction.Operand))
+		if !ok {
+			return FaultMemoryOutOfRange, false
+		}
+		vm.Registers.ACC = value
+	case STX:
+		if !vm.writeData(vm.indexed(instruction.Operand)) {
+			return FaultMemoryOutOfRange, false
+		}
+	case WID:
+		switch instruction.Operand {
+		case 1, 2, 4:
+			vm.Registers.W = instruction.Operand
+		default:
+			return FaultIllegalOpcode, false
+		}
 	default:
-		panic(fmt.Sprintf("Unknown opcode: %d", instruction.Opcode))
+		return FaultIllegalOpcode, false
 	}
+	return 0, true
+}
+
+// executeALU combines ACC with a value read from memory and wraps the
+// result to the current operand width.
+func (vm *VM) executeALU(op Opcode, value int32) (FaultKind, bool) {
+	acc := vm.Registers.ACC
+	switch op {
+	case ADD:
+		acc += value
+	case SUB:
+		acc -= value
+	case MUL:
+		acc *= value
+	case DIV:
+		if value == 0 {
+			return FaultDivideByZero, false
+		}
+		acc /= value
+	case AND:
+		acc &= value
+	case OR:
+		acc |= value
+	case XOR:
+		acc ^= value
+	case LOAD:
+		acc = value
+	}
+	vm.Registers.ACC = vm.wrap(acc)
+	return 0, true
+}
+
+// wrap truncates v to the current operand width and sign-extends it back,
+// giving the two's-complement overflow of an int8, int16 or int32.
+func (vm *VM) wrap(v int32) int32 {
+	switch vm.Registers.W {
+	case 1:
+		return int32(int8(v))
+	case 2:
+		return int32(int16(v))
+	default:
+		return v
+	}
+}
+
+// indexed returns the address of element IX of the array starting at base.
+func (vm *VM) indexed(base byte) uint16 {
+	return uint16(base) + uint16(vm.Registers.IX)*uint16(vm.Registers.W)
+}
+
+// readData reads a little-endian value of the current operand width from
+// the data segment. It reports false if the value would cross into the
+// code segment.
+func (vm *VM) readData(addr uint16) (int32, bool) {
+	width := uint16(vm.Registers.W)
+	if addr+width > DataSegmentSize {
+		return 0, false
+	}
+	var v uint32
+	for i := width; i > 0; i-- {
+		v = v<<8 | uint32(vm.Memory.Read(addr+i-1))
+	}
+	return vm.wrap(int32(v)), true
+}
+
+// writeData stores the low bytes of ACC, little-endian, at the current
+// operand width. It reports false if the value would cross into the code
+// segment.
+func (vm *VM) writeData(addr uint16) bool {
+	width := uint16(vm.Registers.W)
+	if addr+width > DataSegmentSize {
+		return false
+	}
+	v := uint32(vm.Registers.ACC)
+	for i := uint16(0); i < width; i++ {
+		vm.Memory.Write(addr+i, byte(v))
+		v >>= 8
+	}
+	return true
 }
 
 func (vm *VM) UpdateState(state *pb.VMState) {
 	// Copy the state memory into VM memory
-	copy(vm.Memory.Data[:], state.Memory)
+	vm.Memory.Load(0, state.Memory)
 	vm.Registers.PC = uint8(state.Pc)
-	vm.Registers.ACC = int8(state.Acc)
+	vm.Registers.ACC = state.Acc
 }
 
 // LoadProgram copies bytecode into the code segment and resets the CPU state.
@@ -97,17 +246,17 @@ func (vm *VM) LoadProgram(program []byte) error {
 		return fmt.Errorf("program size (%d bytes) exceeds code segment size (%d bytes)", len(program), CodeSegmentSize)
 	}
 
-	// Clear the existing code segment
-	for i := DataSegmentSize; i < MemorySize; i++ {
-		vm.Memory.Data[i] = 0
-	}
-
-	// Load the program into the code segment
-	copy(vm.Memory.Data[DataSegmentSize:], program)
+	// Replace the code segment, zero-filled past the program. Loading the
+	// same program again leaves memory, and its Merkle tree, untouched.
+	code := make([]byte, CodeSegmentSize)
+	copy(code, program)
+	vm.Memory.Load(DataSegmentSize, code)
 
 	// PC = 0 means the first instruction at absolute address DataSegmentSize.
 	vm.Registers.PC = 0
 	vm.Registers.ACC = 0
+	vm.Registers.IX = 0
+	vm.Registers.W = 1
 
 	return nil
 }
@@ -116,10 +265,17 @@ func (vm *VM) LoadProgram(program []byte) error {
 // Used by the compiler to seed constant pools and pre-initialized variables.
 func (vm *VM) LoadData(data map[uint8]byte) {
 	for addr, val := range data {
-		vm.Memory.Data[addr] = val
+		vm.Memory.Write(uint16(addr), val)
 	}
 }
 
+// SetIO replaces the reader IN takes values from and the writer OUT prints
+// to.
+func (vm *VM) SetIO(input io.Reader, output io.Writer) {
+	vm.input = input
+	vm.output = output
+}
+
 // Running reports whether the VM is currently executing.
 func (vm *VM) Running() bool {
 	return vm.running
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/HMZElidrissi/atlas-virtual-machine/internal/metrics"
 	"github.com/HMZElidrissi/atlas-virtual-machine/internal/vm"
 )
 
@@ -253,3 +254,240 @@ func TestVM_DemoProgram_Even(t *testing.T) {
 		t.Errorf("expected output '0' (even), got %q", got)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Extended instructions
+// ---------------------------------------------------------------------------
+
+const (
+	extIDX = byte(0xFC)
+	extLDX = byte(0xFD)
+	extSTX = byte(0xFE)
+	extWID = byte(0xFF)
+)
+
+func TestVM_LongFormLoadStore(t *testing.T) {
+	var out bytes.Buffer
+	v := makeVM(&out)
+
+	// LOAD.L 0x40; STORE.L 0x41; LOAD 0x00 (ACC=0); LOAD.L 0x41; OUT; HALT
+	bytecode := []byte{
+		0xF0 | opLOAD, 0x40,
+		0xF0 | opSTORE, 0x41,
+		encode(opLOAD, 0x00),
+		0xF0 | opLOAD, 0x41,
+		encode(opOUT, 0),
+		encode(opHALT, 0),
+	}
+	loadAndRun(t, v, bytecode, map[uint8]byte{0x40: 33})
+
+	if got := strings.TrimSpace(out.String()); got != "33" {
+		t.Errorf("expected output '33', got %q", got)
+	}
+}
+
+func TestVM_IndexedLoadStore(t *testing.T) {
+	var out bytes.Buffer
+	v := makeVM(&out)
+
+	// data[0x00]=2 (index), data[0x01]=9 (value), array at 0x20.
+	// LOAD 0; IDX 4; LOAD 1; STX 0x20 → mem[0x22]=9; LOAD.L 0x22; OUT; HALT
+	bytecode := []byte{
+		encode(opLOAD, 0x00),
+		extIDX, 4,
+		encode(opLOAD, 0x01),
+		extSTX, 0x20,
+		encode(opLOAD, 0x00),
+		extIDX, 4,
+		extLDX, 0x20,
+		encode(opOUT, 0),
+		encode(opHALT, 0),
+	}
+	loadAndRun(t, v, bytecode, map[uint8]byte{0x00: 2, 0x01: 9})
+
+	if got := strings.TrimSpace(out.String()); got != "9" {
+		t.Errorf("expected output '9', got %q", got)
+	}
+	if got := v.Memory.Read(0x22); got != 9 {
+		t.Errorf("expected mem[0x22]=9, got %d", got)
+	}
+}
+
+func TestVM_IndexOutOfRangeFaults(t *testing.T) {
+	for _, index := range []byte{4, 0xFF} { // 4 is past the end, 0xFF is -1
+		var out bytes.Buffer
+		v := makeVM(&out)
+		if err := v.LoadProgram([]byte{encode(opLOAD, 0x00), extIDX, 4, encode(opHALT, 0)}); err != nil {
+			t.Fatalf("LoadProgram: %v", err)
+		}
+		v.LoadData(map[uint8]byte{0x00: index})
+		err := v.Run()
+		fault, ok := err.(*vm.Fault)
+		if !ok || fault.Kind != vm.FaultIndexOutOfRange {
+			t.Errorf("index %d: expected index-out-of-range fault, got %v", int8(index), err)
+		}
+	}
+}
+
+func TestVM_WideArithmetic(t *testing.T) {
+	var out bytes.Buffer
+	v := makeVM(&out)
+
+	// WID 2; LOAD.L 0x20 (300); ADD.L 0x22 (-1000); STORE.L 0x24; OUT; HALT
+	bytecode := []byte{
+		extWID, 2,
+		0xF0 | opLOAD, 0x20,
+		0xF0 | opADD, 0x22,
+		0xF0 | opSTORE, 0x24,
+		encode(opOUT, 0),
+		encode(opHALT, 0),
+	}
+	loadAndRun(t, v, bytecode, map[uint8]byte{0x20: 0x2C, 0x21: 0x01, 0x22: 0x18, 0x23: 0xFC})
+
+	if got := strings.TrimSpace(out.String()); got != "-700" {
+		t.Errorf("expected output '-700', got %q", got)
+	}
+	if lo, hi := v.Memory.Read(0x24), v.Memory.Read(0x25); lo != 0x44 || hi != 0xFD {
+		t.Errorf("expected -700 stored little-endian as 44 FD, got %02X %02X", lo, hi)
+	}
+}
+
+func TestVM_AccumulatorWrapsAtWidth(t *testing.T) {
+	for _, tc := range []struct {
+		width byte
+		want  string
+	}{
+		{1, "-56"}, // 100+100 overflows int8
+		{2, "200"}, // fits in int16
+	} {
+		var out bytes.Buffer
+		v := makeVM(&out)
+		bytecode := []byte{
+			extWID, tc.width,
+			0xF0 | opLOAD, 0x20,
+			0xF0 | opADD, 0x20,
+			encode(opOUT, 0),
+			encode(opHALT, 0),
+		}
+		loadAndRun(t, v, bytecode, map[uint8]byte{0x20: 100})
+
+		if got := strings.TrimSpace(out.String()); got != tc.want {
+			t.Errorf("width %d: expected output %q, got %q", tc.width, tc.want, got)
+		}
+	}
+}
+
+func TestVM_WideIndexedAccessScalesByWidth(t *testing.T) {
+	var out bytes.Buffer
+	v := makeVM(&out)
+
+	// WID 4; LOAD 0 (index 2); IDX 3; LDX 0x20 reads the int32 at 0x28.
+	bytecode := []byte{
+		extWID, 4,
+		encode(opLOAD, 0x00),
+		extIDX, 3,
+		extLDX, 0x20,
+		encode(opOUT, 0),
+		encode(opHALT, 0),
+	}
+	loadAndRun(t, v, bytecode, map[uint8]byte{0x00: 2, 0x28: 0x40, 0x29: 0x42, 0x2A: 0x0F})
+
+	if got := strings.TrimSpace(out.String()); got != "1000000" {
+		t.Errorf("expected output '1000000', got %q", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Faults
+// ---------------------------------------------------------------------------
+
+func TestVM_IllegalWidthFaults(t *testing.T) {
+	var out bytes.Buffer
+	v := makeVM(&out)
+	if err := v.LoadProgram([]byte{extWID, 3, encode(opHALT, 0)}); err != nil {
+		t.Fatalf("LoadProgram: %v", err)
+	}
+	err := v.Run()
+	fault, ok := err.(*vm.Fault)
+	if !ok || fault.Kind != vm.FaultIllegalOpcode {
+		t.Errorf("expected illegal-opcode fault, got %v", err)
+	}
+}
+
+func TestVM_WideAccessPastDataSegmentFaults(t *testing.T) {
+	var out bytes.Buffer
+	v := makeVM(&out)
+
+	// Element 100 of an int32 array at 0xFF lies in the code segment.
+	bytecode := []byte{extWID, 4, encode(opLOAD, 0x00), extIDX, 200, extLDX, 0xFF, encode(opHALT, 0)}
+	if err := v.LoadProgram(bytecode); err != nil {
+		t.Fatalf("LoadProgram: %v", err)
+	}
+	v.LoadData(map[uint8]byte{0x00: 100})
+	err := v.Run()
+	fault, ok := err.(*vm.Fault)
+	if !ok || fault.Kind != vm.FaultMemoryOutOfRange || fault.PC != 5 {
+		t.Errorf("expected memory-out-of-range fault at pc=5, got %v", err)
+	}
+}
+
+func TestVM_DivideByZeroFaults(t *testing.T) {
+	var out bytes.Buffer
+	v := makeVM(&out)
+
+	// data[0x00]=0; DIV 0x00 must fault instead of panicking.
+	if err := v.LoadProgram([]byte{encode(0x3, 0x00), encode(opHALT, 0)}); err != nil {
+		t.Fatalf("LoadProgram: %v", err)
+	}
+	err := v.Run()
+	fault, ok := err.(*vm.Fault)
+	if !ok {
+		t.Fatalf("expected *vm.Fault, got %v", err)
+	}
+	if fault.Kind != vm.FaultDivideByZero || fault.PC != 0 {
+		t.Errorf("expected divide-by-zero at pc=0, got %v", fault)
+	}
+}
+
+func TestVM_RunStepsStopsInfiniteLoop(t *testing.T) {
+	var out bytes.Buffer
+	v := makeVM(&out)
+
+	// JUMP 0 never halts.
+	if err := v.LoadProgram([]byte{encode(opJUMP, 0x00)}); err != nil {
+		t.Fatalf("LoadProgram: %v", err)
+	}
+	err := v.RunSteps(100)
+	fault, ok := err.(*vm.Fault)
+	if !ok || fault.Kind != vm.FaultStepLimit {
+		t.Fatalf("expected step-limit fault, got %v", err)
+	}
+	if v.Running() {
+		t.Error("VM should not be running after a fault")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Metrics
+// ---------------------------------------------------------------------------
+
+func TestVM_CountsInstructionsAndFaults(t *testing.T) {
+	var out bytes.Buffer
+	v := makeVM(&out)
+	reg := metrics.NewRegistry()
+	v.SetMetrics(reg)
+
+	// JUMP 0 runs until the step limit.
+	if err := v.LoadProgram([]byte{encode(opJUMP, 0x00)}); err != nil {
+		t.Fatalf("LoadProgram: %v", err)
+	}
+	v.RunSteps(10)
+	instructions := reg.Counter("atlasvm_vm_instructions_total", "", "opcode")
+	if got := instructions.Value("JUMP"); got != 10 {
+		t.Errorf("expected 10 JUMPs, got %d", got)
+	}
+	faults := reg.Counter("atlasvm_vm_faults_total", "", "fault")
+	if got := faults.Value("step_limit_exceeded"); got != 1 {
+		t.Errorf("expected 1 step-limit fault, got %d", got)
+	}
+}
@@ -20,6 +20,8 @@ const _ = grpc.SupportPackageIsVersion8
 
 const (
 	NodeService_ReceiveMessage_FullMethodName = "/atlas.NodeService/ReceiveMessage"
+	NodeService_FetchState_FullMethodName     = "/atlas.NodeService/FetchState"
+	NodeService_ProveMemory_FullMethodName    = "/atlas.NodeService/ProveMemory"
 )
 
 // NodeServiceClient is the client API for NodeService service.
@@ -27,6 +29,12 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type NodeServiceClient interface {
 	ReceiveMessage(ctx context.Context, in *ConsensusMessage, opts ...grpc.CallOption) (*Empty, error)
+	// FetchState returns the node's latest stable checkpoint, for replicas
+	// that fell too far behind to catch up from the log.
+	FetchState(ctx context.Context, in *FetchStateRequest, opts ...grpc.CallOption) (*StateSnapshot, error)
+	// ProveMemory returns inclusion proofs for memory pages of the state
+	// after a slot, and the hash of every page to narrow down divergences.
+	ProveMemory(ctx context.Context, in *ProveMemoryRequest, opts ...grpc.CallOption) (*MemoryProof, error)
 }
 
 type nodeServiceClient struct {
@@ -47,11 +55,37 @@ func (c *nodeServiceClient) ReceiveMessage(ctx context.Context, in *ConsensusMes
 	return out, nil
 }
 
+func (c *nodeServiceClient) FetchState(ctx context.Context, in *FetchStateRequest, opts ...grpc.CallOption) (*StateSnapshot, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StateSnapshot)
+	err := c.cc.Invoke(ctx, NodeService_FetchState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeServiceClient) ProveMemory(ctx context.Context, in *ProveMemoryRequest, opts ...grpc.CallOption) (*MemoryProof, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MemoryProof)
+	err := c.cc.Invoke(ctx, NodeService_ProveMemory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NodeServiceServer is the server API for NodeService service.
 // All implementations must embed UnimplementedNodeServiceServer
 // for forward compatibility
 type NodeServiceServer interface {
 	ReceiveMessage(context.Context, *ConsensusMessage) (*Empty, error)
+	// FetchState returns the node's latest stable checkpoint, for replicas
+	// that fell too far behind to catch up from the log.
+	FetchState(context.Context, *FetchStateRequest) (*StateSnapshot, error)
+	// ProveMemory returns inclusion proofs for memory pages of the state
+	// after a slot, and the hash of every page to narrow down divergences.
+	ProveMemory(context.Context, *ProveMemoryRequest) (*MemoryProof, error)
 	mustEmbedUnimplementedNodeServiceServer()
 }
 
@@ -62,6 +96,12 @@ type UnimplementedNodeServiceServer struct {
 func (UnimplementedNodeServiceServer) ReceiveMessage(context.Context, *ConsensusMessage) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ReceiveMessage not implemented")
 }
+func (UnimplementedNodeServiceServer) FetchState(context.Context, *FetchStateRequest) (*StateSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchState not implemented")
+}
+func (UnimplementedNodeServiceServer) ProveMemory(context.Context, *ProveMemoryRequest) (*MemoryProof, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProveMemory not implemented")
+}
 func (UnimplementedNodeServiceServer) mustEmbedUnimplementedNodeServiceServer() {}
 
 // UnsafeNodeServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -93,6 +133,42 @@ func _NodeService_ReceiveMessage_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _NodeService_FetchState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServiceServer).FetchState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeService_FetchState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServiceServer).FetchState(ctx, req.(*FetchStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeService_ProveMemory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProveMemoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServiceServer).ProveMemory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeService_ProveMemory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServiceServer).ProveMemory(ctx, req.(*ProveMemoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // NodeService_ServiceDesc is the grpc.ServiceDesc for NodeService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -104,6 +180,520 @@ var NodeService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ReceiveMessage",
 			Handler:    _NodeService_ReceiveMessage_Handler,
 		},
+		{
+			MethodName: "FetchState",
+			Handler:    _NodeService_FetchState_Handler,
+		},
+		{
+			MethodName: "ProveMemory",
+			Handler:    _NodeService_ProveMemory_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/atlas.proto",
+}
+
+const (
+	ClientService_SubmitProgram_FullMethodName   = "/atlas.ClientService/SubmitProgram"
+	ClientService_GetResult_FullMethodName       = "/atlas.ClientService/GetResult"
+	ClientService_GetState_FullMethodName        = "/atlas.ClientService/GetState"
+	ClientService_WatchExecutions_FullMethodName = "/atlas.ClientService/WatchExecutions"
+)
+
+// ClientServiceClient is the client API for ClientService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ClientService is how programs get into the cluster and results out of
+// it. Every node serves it next to NodeService.
+type ClientServiceClient interface {
+	SubmitProgram(ctx context.Context, in *SubmitRequest, opts ...grpc.CallOption) (*SubmitResponse, error)
+	GetResult(ctx context.Context, in *GetResultRequest, opts ...grpc.CallOption) (*ExecutionReport, error)
+	GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*NodeState, error)
+	// Streams a report for every slot once its result has settled, in log
+	// order: certified, or contradicted by a quorum.
+	WatchExecutions(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (ClientService_WatchExecutionsClient, error)
+}
+
+type clientServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClientServiceClient(cc grpc.ClientConnInterface) ClientServiceClient {
+	return &clientServiceClient{cc}
+}
+
+func (c *clientServiceClient) SubmitProgram(ctx context.Context, in *SubmitRequest, opts ...grpc.CallOption) (*SubmitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitResponse)
+	err := c.cc.Invoke(ctx, ClientService_SubmitProgram_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientServiceClient) GetResult(ctx context.Context, in *GetResultRequest, opts ...grpc.CallOption) (*ExecutionReport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExecutionReport)
+	err := c.cc.Invoke(ctx, ClientService_GetResult_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientServiceClient) GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*NodeState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NodeState)
+	err := c.cc.Invoke(ctx, ClientService_GetState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientServiceClient) WatchExecutions(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (ClientService_WatchExecutionsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ClientService_ServiceDesc.Streams[0], ClientService_WatchExecutions_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &clientServiceWatchExecutionsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ClientService_WatchExecutionsClient interface {
+	Recv() (*ExecutionReport, error)
+	grpc.ClientStream
+}
+
+type clientServiceWatchExecutionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *clientServiceWatchExecutionsClient) Recv() (*ExecutionReport, error) {
+	m := new(ExecutionReport)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ClientServiceServer is the server API for ClientService service.
+// All implementations must embed UnimplementedClientServiceServer
+// for forward compatibility
+//
+// ClientService is how programs get into the cluster and results out of
+// it. Every node serves it next to NodeService.
+type ClientServiceServer interface {
+	SubmitProgram(context.Context, *SubmitRequest) (*SubmitResponse, error)
+	GetResult(context.Context, *GetResultRequest) (*ExecutionReport, error)
+	GetState(context.Context, *GetStateRequest) (*NodeState, error)
+	// Streams a report for every slot once its result has settled, in log
+	// order: certified, or contradicted by a quorum.
+	WatchExecutions(*WatchRequest, ClientService_WatchExecutionsServer) error
+	mustEmbedUnimplementedClientServiceServer()
+}
+
+// UnimplementedClientServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedClientServiceServer struct {
+}
+
+func (UnimplementedClientServiceServer) SubmitProgram(context.Context, *SubmitRequest) (*SubmitResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitProgram not implemented")
+}
+func (UnimplementedClientServiceServer) GetResult(context.Context, *GetResultRequest) (*ExecutionReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResult not implemented")
+}
+func (UnimplementedClientServiceServer) GetState(context.Context, *GetStateRequest) (*NodeState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetState not implemented")
+}
+func (UnimplementedClientServiceServer) WatchExecutions(*WatchRequest, ClientService_WatchExecutionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchExecutions not implemented")
+}
+func (UnimplementedClientServiceServer) mustEmbedUnimplementedClientServiceServer() {}
+
+// UnsafeClientServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClientServiceServer will
+// result in compilation errors.
+type UnsafeClientServiceServer interface {
+	mustEmbedUnimplementedClientServiceServer()
+}
+
+func RegisterClientServiceServer(s grpc.ServiceRegistrar, srv ClientServiceServer) {
+	s.RegisterService(&ClientService_ServiceDesc, srv)
+}
+
+func _ClientService_SubmitProgram_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientServiceServer).SubmitProgram(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClientService_SubmitProgram_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientServiceServer).SubmitProgram(ctx, req.(*SubmitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientService_GetResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientServiceServer).GetResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClientService_GetResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientServiceServer).GetResult(ctx, req.(*GetResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientService_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientServiceServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClientService_GetState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientServiceServer).GetState(ctx, req.(*GetStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientService_WatchExecutions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ClientServiceServer).WatchExecutions(m, &clientServiceWatchExecutionsServer{ServerStream: stream})
+}
+
+type ClientService_WatchExecutionsServer interface {
+	Send(*ExecutionReport) error
+	grpc.ServerStream
+}
+
+type clientServiceWatchExecutionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *clientServiceWatchExecutionsServer) Send(m *ExecutionReport) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ClientService_ServiceDesc is the grpc.ServiceDesc for ClientService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ClientService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "atlas.ClientService",
+	HandlerType: (*ClientServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitProgram",
+			Handler:    _ClientService_SubmitProgram_Handler,
+		},
+		{
+			MethodName: "GetResult",
+			Handler:    _ClientService_GetResult_Handler,
+		},
+		{
+			MethodName: "GetState",
+			Handler:    _ClientService_GetState_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchExecutions",
+			Handler:       _ClientService_WatchExecutions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/atlas.proto",
+}
+
+const (
+	NodeAdmin_Health_FullMethodName           = "/atlas.NodeAdmin/Health"
+	NodeAdmin_Status_FullMethodName           = "/atlas.NodeAdmin/Status"
+	NodeAdmin_SetLogLevel_FullMethodName      = "/atlas.NodeAdmin/SetLogLevel"
+	NodeAdmin_Shutdown_FullMethodName         = "/atlas.NodeAdmin/Shutdown"
+	NodeAdmin_ChangeMembership_FullMethodName = "/atlas.NodeAdmin/ChangeMembership"
+)
+
+// NodeAdminClient is the client API for NodeAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// NodeAdmin is how operators inspect and control a running node. Every
+// node serves it next to NodeService. Health and Status are open to
+// anyone; the others need an operator certificate from the cluster CA, or,
+// on a cluster without TLS, a call from the same host.
+type NodeAdminClient interface {
+	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*NodeStatus, error)
+	SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error)
+	// Shutdown makes the node stop gracefully: it returns at once, then the
+	// node finishes the calls in progress and sends what it has queued.
+	Shutdown(ctx context.Context, in *ShutdownRequest, opts ...grpc.CallOption) (*Empty, error)
+	// ChangeMembership submits a Reconfiguration if the node is the leader,
+	// and otherwise says where the leader is. It needs the same
+	// authorization as Shutdown, and PBFT replication.
+	ChangeMembership(ctx context.Context, in *ChangeMembershipRequest, opts ...grpc.CallOption) (*ChangeMembershipResponse, error)
+}
+
+type nodeAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNodeAdminClient(cc grpc.ClientConnInterface) NodeAdminClient {
+	return &nodeAdminClient{cc}
+}
+
+func (c *nodeAdminClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, NodeAdmin_Health_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeAdminClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*NodeStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NodeStatus)
+	err := c.cc.Invoke(ctx, NodeAdmin_Status_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeAdminClient) SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetLogLevelResponse)
+	err := c.cc.Invoke(ctx, NodeAdmin_SetLogLevel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeAdminClient) Shutdown(ctx context.Context, in *ShutdownRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, NodeAdmin_Shutdown_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeAdminClient) ChangeMembership(ctx context.Context, in *ChangeMembershipRequest, opts ...grpc.CallOption) (*ChangeMembershipResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChangeMembershipResponse)
+	err := c.cc.Invoke(ctx, NodeAdmin_ChangeMembership_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NodeAdminServer is the server API for NodeAdmin service.
+// All implementations must embed UnimplementedNodeAdminServer
+// for forward compatibility
+//
+// NodeAdmin is how operators inspect and control a running node. Every
+// node serves it next to NodeService. Health and Status are open to
+// anyone; the others need an operator certificate from the cluster CA, or,
+// on a cluster without TLS, a call from the same host.
+type NodeAdminServer interface {
+	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	Status(context.Context, *StatusRequest) (*NodeStatus, error)
+	SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error)
+	// Shutdown makes the node stop gracefully: it returns at once, then the
+	// node finishes the calls in progress and sends what it has queued.
+	Shutdown(context.Context, *ShutdownRequest) (*Empty, error)
+	// ChangeMembership submits a Reconfiguration if the node is the leader,
+	// and otherwise says where the leader is. It needs the same
+	// authorization as Shutdown, and PBFT replication.
+	ChangeMembership(context.Context, *ChangeMembershipRequest) (*ChangeMembershipResponse, error)
+	mustEmbedUnimplementedNodeAdminServer()
+}
+
+// UnimplementedNodeAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedNodeAdminServer struct {
+}
+
+func (UnimplementedNodeAdminServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+func (UnimplementedNodeAdminServer) Status(context.Context, *StatusRequest) (*NodeStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedNodeAdminServer) SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
+}
+func (UnimplementedNodeAdminServer) Shutdown(context.Context, *ShutdownRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Shutdown not implemented")
+}
+func (UnimplementedNodeAdminServer) ChangeMembership(context.Context, *ChangeMembershipRequest) (*ChangeMembershipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangeMembership not implemented")
+}
+func (UnimplementedNodeAdminServer) mustEmbedUnimplementedNodeAdminServer() {}
+
+// UnsafeNodeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NodeAdminServer will
+// result in compilation errors.
+type UnsafeNodeAdminServer interface {
+	mustEmbedUnimplementedNodeAdminServer()
+}
+
+func RegisterNodeAdminServer(s grpc.ServiceRegistrar, srv NodeAdminServer) {
+	s.RegisterService(&NodeAdmin_ServiceDesc, srv)
+}
+
+func _NodeAdmin_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeAdminServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeAdmin_Health_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeAdminServer).Health(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeAdmin_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeAdminServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeAdmin_Status_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeAdminServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeAdmin_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeAdminServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeAdmin_SetLogLevel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeAdminServer).SetLogLevel(ctx, req.(*SetLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeAdmin_Shutdown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShutdownRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeAdminServer).Shutdown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeAdmin_Shutdown_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeAdminServer).Shutdown(ctx, req.(*ShutdownRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeAdmin_ChangeMembership_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangeMembershipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeAdminServer).ChangeMembership(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeAdmin_ChangeMembership_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeAdminServer).ChangeMembership(ctx, req.(*ChangeMembershipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// NodeAdmin_ServiceDesc is the grpc.ServiceDesc for NodeAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var NodeAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "atlas.NodeAdmin",
+	HandlerType: (*NodeAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Health",
+			Handler:    _NodeAdmin_Health_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _NodeAdmin_Status_Handler,
+		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    _NodeAdmin_SetLogLevel_Handler,
+		},
+		{
+			MethodName: "Shutdown",
+			Handler:    _NodeAdmin_Shutdown_Handler,
+		},
+		{
+			MethodName: "ChangeMembership",
+			Handler:    _NodeAdmin_ChangeMembership_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/atlas.proto",